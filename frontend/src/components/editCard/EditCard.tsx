@@ -25,7 +25,9 @@ const EditCardComponent: React.FC<EditsProps> = ({ edit }) => {
   let editVariant: BadgeProps["variant"] = "warning";
   if (
     edit.status === VoteStatusEnum.REJECTED ||
-    edit.status === VoteStatusEnum.IMMEDIATE_REJECTED
+    edit.status === VoteStatusEnum.IMMEDIATE_REJECTED ||
+    edit.status === VoteStatusEnum.CLOSED ||
+    edit.status === VoteStatusEnum.FAILED
   )
     editVariant = "danger";
   else if (
@@ -91,4 +91,6 @@ export const EditStatusTypes: EnumDictionary<VoteStatusEnum, string> = {
   [VoteStatusEnum.IMMEDIATE_REJECTED]: "Cancelled",
   [VoteStatusEnum.ACCEPTED]: "Accepted",
   [VoteStatusEnum.REJECTED]: "Rejected",
+  [VoteStatusEnum.CLOSED]: "Closed",
+  [VoteStatusEnum.FAILED]: "Failed",
 };
@@ -135,6 +135,8 @@ export enum TargetTypeEnum {
 
 export enum VoteStatusEnum {
   ACCEPTED = "ACCEPTED",
+  CLOSED = "CLOSED",
+  FAILED = "FAILED",
   IMMEDIATE_ACCEPTED = "IMMEDIATE_ACCEPTED",
   IMMEDIATE_REJECTED = "IMMEDIATE_REJECTED",
   PENDING = "PENDING",
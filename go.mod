@@ -19,6 +19,7 @@ require (
 	github.com/minio/minio-go/v7 v7.0.7
 	github.com/mitchellh/mapstructure v1.4.1 // indirect
 	github.com/pkg/errors v0.9.1
+	github.com/prometheus/client_golang v1.11.0
 	github.com/rs/cors v1.6.0
 	github.com/sirupsen/logrus v1.8.1
 	github.com/spf13/afero v1.2.0 // indirect
@@ -29,7 +30,6 @@ require (
 	github.com/vektah/gqlparser/v2 v2.1.0
 	golang.org/x/crypto v0.0.0-20210513164829-c07d793c2f9a
 	golang.org/x/image v0.0.0-20191009234506-e7c1f5e7dbb8
-	golang.org/x/sys v0.0.0-20210521203332-0cec03c779c1 // indirect
 	golang.org/x/tools v0.1.1 // indirect
 	honnef.co/go/tools v0.2.1
 )
@@ -2,12 +2,21 @@
 package main
 
 import (
+	"context"
 	"embed"
+	"errors"
+	"os"
+	"os/signal"
+	"sync/atomic"
+	"syscall"
+	"time"
 
 	"github.com/stashapp/stash-box/pkg/api"
 	"github.com/stashapp/stash-box/pkg/database"
+	"github.com/stashapp/stash-box/pkg/logger"
 	"github.com/stashapp/stash-box/pkg/manager"
 	"github.com/stashapp/stash-box/pkg/manager/config"
+	"github.com/stashapp/stash-box/pkg/metrics"
 	"github.com/stashapp/stash-box/pkg/sqlx"
 	"github.com/stashapp/stash-box/pkg/sqlx/postgres"
 	"github.com/stashapp/stash-box/pkg/user"
@@ -16,18 +25,118 @@ import (
 //go:embed frontend/build
 var ui embed.FS
 
+// databaseRetryDelay is the time between attempts to connect to the
+// database on startup.
+const databaseRetryDelay = 10 * time.Second
+
+var errDatabaseNotConnected = errors.New("database not connected")
+
 func main() {
 	manager.Initialize()
 
 	const databaseProvider = "postgres"
-	db := database.Initialize(databaseProvider, config.GetDatabasePath())
+	db, err := database.Open(databaseProvider, config.GetDatabasePath())
+	if err != nil {
+		logger.Fatal(err)
+	}
 	txnMgr := sqlx.NewTxnMgr(db, &postgres.Dialect{})
-	user.CreateRoot(txnMgr.Repo())
-	api.Start(txnMgr, ui)
-	blockForever()
+	metrics.RegisterDatabase(db.DB)
+
+	if command := manager.GetCommand(); len(command) > 0 {
+		if err := database.Migrate(databaseProvider, config.GetDatabasePath()); err != nil {
+			logger.Fatal(err)
+		}
+		user.CreateRoot(txnMgr.Repo())
+
+		if err := manager.RunCommand(txnMgr.Repo(), command); err != nil {
+			logger.Fatal(err)
+		}
+		return
+	}
+
+	// the server is started before the database is reachable, reporting
+	// that it is not ready and holding back the API and background jobs
+	// until the database is migrated
+	var connected int32
+	server, err := api.Start(txnMgr, ui, api.ReadinessCheck{
+		Name: "database",
+		Check: func(ctx context.Context) error {
+			if atomic.LoadInt32(&connected) == 0 {
+				return errDatabaseNotConnected
+			}
+			return database.Check(ctx, db)
+		},
+	})
+	if err != nil {
+		logger.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		if connectDatabase(ctx, databaseProvider, config.GetDatabasePath()) {
+			user.CreateRoot(txnMgr.Repo())
+			atomic.StoreInt32(&connected, 1)
+			server.SetReady()
+		}
+	}()
+
+	waitForShutdown(server)
+	cancel()
+
+	if err := db.Close(); err != nil {
+		logger.Errorf("Error closing database: %s", err.Error())
+	}
 }
 
-func blockForever() {
-	c := make(chan struct{})
-	<-c
+// connectDatabase migrates the database, retrying until it succeeds or the
+// context is cancelled. It returns false if the context was cancelled. The
+// process exits if the database is reached but cannot be migrated.
+func connectDatabase(ctx context.Context, provider string, databasePath string) bool {
+	for {
+		err := database.Migrate(provider, databasePath)
+		if err == nil {
+			return true
+		}
+
+		var migrationErr *database.MigrationError
+		if errors.As(err, &migrationErr) {
+			logger.Fatalf("Error migrating database: %s", err.Error())
+		}
+
+		logger.Errorf("Error connecting to database, retrying in %s: %s", databaseRetryDelay, err.Error())
+		select {
+		case <-ctx.Done():
+			return false
+		case <-time.After(databaseRetryDelay):
+		}
+	}
+}
+
+// waitForShutdown blocks until the process is interrupted or terminated, or
+// the server fails, and then shuts down the server.
+func waitForShutdown(server *api.Server) {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+
+	select {
+	case sig := <-signals:
+		logger.Infof("Received %s, shutting down", sig)
+	case err := <-server.Errors():
+		logger.Errorf("Server error, shutting down: %s", err.Error())
+	}
+
+	// a second signal stops waiting for requests to finish
+	ctx, cancel := context.WithTimeout(context.Background(), config.GetShutdownTimeout())
+	defer cancel()
+	go func() {
+		select {
+		case <-signals:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	if err := server.Shutdown(ctx); err != nil {
+		logger.Errorf("Error shutting down: %s", err.Error())
+	}
 }
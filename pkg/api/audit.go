@@ -0,0 +1,60 @@
+package api
+
+import (
+	"context"
+
+	"github.com/gofrs/uuid"
+
+	"github.com/stashapp/stash-box/pkg/models"
+)
+
+// auditLog records a privileged action of the current user in the audit log.
+// It must be called within the transaction performing the action, so that
+// the entry is only committed with the action.
+func auditLog(ctx context.Context, fac models.Repo, action models.AuditActionEnum, targetType models.AuditTargetTypeEnum, targetID uuid.UUID, oldData interface{}, newData interface{}) error {
+	id, err := uuid.NewV4()
+	if err != nil {
+		return err
+	}
+
+	entry, err := models.NewAuditLog(id, getCurrentUser(ctx), action, targetType, targetID, oldData, newData)
+	if err != nil {
+		return err
+	}
+
+	_, err = fac.AuditLog().Create(*entry)
+	return err
+}
+
+type auditRoles struct {
+	Roles []models.RoleEnum `json:"roles"`
+}
+
+type auditInviteTokens struct {
+	InviteTokens int `json:"invite_tokens"`
+}
+
+type auditEditStatus struct {
+	Status  string `json:"status"`
+	Applied bool   `json:"applied"`
+}
+
+// rolesChanged returns true if the roles differ, disregarding their order.
+func rolesChanged(oldRoles []models.RoleEnum, newRoles []models.RoleEnum) bool {
+	if len(oldRoles) != len(newRoles) {
+		return true
+	}
+
+	remaining := make(map[models.RoleEnum]int)
+	for _, role := range oldRoles {
+		remaining[role]++
+	}
+	for _, role := range newRoles {
+		if remaining[role] == 0 {
+			return true
+		}
+		remaining[role]--
+	}
+
+	return false
+}
@@ -0,0 +1,185 @@
+//go:build integration
+// +build integration
+
+package api_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stashapp/stash-box/pkg/api"
+	"github.com/stashapp/stash-box/pkg/models"
+)
+
+type auditTestRunner struct {
+	testRunner
+}
+
+func createAuditTestRunner(t *testing.T) *auditTestRunner {
+	return &auditTestRunner{
+		testRunner: *asAdmin(t),
+	}
+}
+
+func (s *auditTestRunner) queryTargetAuditLog(targetType models.AuditTargetTypeEnum, targetID string) []*models.AuditLog {
+	s.t.Helper()
+
+	result, err := s.resolver.Query().QueryAuditLog(s.ctx, &models.AuditLogFilterType{
+		TargetType: &targetType,
+		TargetID:   &targetID,
+	}, nil)
+	if err != nil {
+		s.t.Errorf("Error querying audit log: %s", err.Error())
+		return nil
+	}
+
+	return result.Entries
+}
+
+func (s *auditTestRunner) testPerformerDestroyAuditLog() {
+	performer, err := s.createTestPerformer(nil)
+	if err != nil {
+		return
+	}
+
+	if _, err := s.resolver.Mutation().PerformerDestroy(s.ctx, models.PerformerDestroyInput{
+		ID: performer.ID.String(),
+	}); err != nil {
+		s.t.Errorf("Error destroying performer: %s", err.Error())
+		return
+	}
+
+	entries := s.queryTargetAuditLog(models.AuditTargetTypeEnumPerformer, performer.ID.String())
+	if len(entries) != 1 {
+		s.t.Errorf("audit log entries: got %d want 1", len(entries))
+		return
+	}
+
+	entry := entries[0]
+	if entry.Action != models.AuditActionEnumPerformerDestroy.String() {
+		s.fieldMismatch(models.AuditActionEnumPerformerDestroy.String(), entry.Action, "Action")
+	}
+	if !entry.UserID.Valid || entry.UserID.UUID != userDB.admin.ID {
+		s.fieldMismatch(userDB.admin.ID, entry.UserID, "UserID")
+	}
+	if entry.UserName != userDB.admin.Name {
+		s.fieldMismatch(userDB.admin.Name, entry.UserName, "UserName")
+	}
+
+	var oldData models.Performer
+	if err := json.Unmarshal(entry.OldData, &oldData); err != nil {
+		s.t.Errorf("Error unmarshalling old data: %s", err.Error())
+	} else if oldData.Name != performer.Name {
+		s.fieldMismatch(performer.Name, oldData.Name, "OldData.Name")
+	}
+}
+
+func (s *auditTestRunner) testUserUpdateAuditLog() {
+	createdUser, err := s.createTestUser(nil)
+	if err != nil {
+		return
+	}
+
+	// updating without changing the roles is not logged
+	name := s.generateUserName()
+	if _, err := s.resolver.Mutation().UserUpdate(s.ctx, models.UserUpdateInput{
+		ID:    createdUser.ID.String(),
+		Name:  &name,
+		Roles: []models.RoleEnum{models.RoleEnumAdmin},
+	}); err != nil {
+		s.t.Errorf("Error updating user: %s", err.Error())
+		return
+	}
+
+	if entries := s.queryTargetAuditLog(models.AuditTargetTypeEnumUser, createdUser.ID.String()); len(entries) != 0 {
+		s.t.Errorf("audit log entries: got %d want 0", len(entries))
+		return
+	}
+
+	if _, err := s.resolver.Mutation().UserUpdate(s.ctx, models.UserUpdateInput{
+		ID:    createdUser.ID.String(),
+		Roles: []models.RoleEnum{models.RoleEnumRead},
+	}); err != nil {
+		s.t.Errorf("Error updating user: %s", err.Error())
+		return
+	}
+
+	entries := s.queryTargetAuditLog(models.AuditTargetTypeEnumUser, createdUser.ID.String())
+	if len(entries) != 1 {
+		s.t.Errorf("audit log entries: got %d want 1", len(entries))
+		return
+	}
+
+	if entries[0].Action != models.AuditActionEnumUserUpdateRoles.String() {
+		s.fieldMismatch(models.AuditActionEnumUserUpdateRoles.String(), entries[0].Action, "Action")
+	}
+	if string(entries[0].NewData) != `{"roles":["READ"]}` {
+		s.fieldMismatch(`{"roles":["READ"]}`, string(entries[0].NewData), "NewData")
+	}
+}
+
+func (s *auditTestRunner) testApplyEditAuditLog() {
+	name := s.generateTagName()
+	createdEdit, err := s.createTestTagEdit(models.OperationEnumCreate, &models.TagEditDetailsInput{
+		Name: &name,
+	}, nil)
+	if err != nil {
+		return
+	}
+
+	if _, err := s.applyEdit(createdEdit.ID.String()); err != nil {
+		return
+	}
+
+	action := models.AuditActionEnumEditApply
+	result, err := s.resolver.Query().QueryAuditLog(s.ctx, &models.AuditLogFilterType{
+		Action: &action,
+	}, nil)
+	if err != nil {
+		s.t.Errorf("Error querying audit log: %s", err.Error())
+		return
+	}
+
+	found := false
+	for _, entry := range result.Entries {
+		if entry.TargetID == createdEdit.ID {
+			found = true
+		}
+		if entry.Action != action.String() {
+			s.fieldMismatch(action.String(), entry.Action, "Action")
+		}
+	}
+	if !found {
+		s.t.Error("applied edit was not found in the audit log")
+	}
+}
+
+func (s *auditTestRunner) testUnauthorisedQueryAuditLog() {
+	// admin only
+	_, err := s.resolver.Query().QueryAuditLog(s.ctx, nil, nil)
+	if err != api.ErrUnauthorized {
+		s.t.Errorf("QueryAuditLog: got %v want %v", err, api.ErrUnauthorized)
+	}
+}
+
+func TestPerformerDestroyAuditLog(t *testing.T) {
+	pt := createAuditTestRunner(t)
+	pt.testPerformerDestroyAuditLog()
+}
+
+func TestUserUpdateAuditLog(t *testing.T) {
+	pt := createAuditTestRunner(t)
+	pt.testUserUpdateAuditLog()
+}
+
+func TestApplyEditAuditLog(t *testing.T) {
+	pt := createAuditTestRunner(t)
+	pt.testApplyEditAuditLog()
+}
+
+func TestUnauthorisedQueryAuditLog(t *testing.T) {
+	pt := &auditTestRunner{
+		testRunner: *asModify(t),
+	}
+	pt.testUnauthorisedQueryAuditLog()
+}
@@ -21,6 +21,16 @@ func getCurrentUser(ctx context.Context) *models.User {
 	return nil
 }
 
+// currentUserID returns the ID of the current user, or uuid.Nil if there is
+// no current user.
+func currentUserID(ctx context.Context) uuid.UUID {
+	if user := getCurrentUser(ctx); user != nil {
+		return user.ID
+	}
+
+	return uuid.Nil
+}
+
 func validateRole(ctx context.Context, requiredRole models.RoleEnum) error {
 	var roles []models.RoleEnum
 
@@ -4,8 +4,11 @@ package api_test
 
 import (
 	"testing"
+	"time"
 
 	"github.com/stashapp/stash-box/pkg/api"
+	dbtest "github.com/stashapp/stash-box/pkg/database/databasetest"
+	"github.com/stashapp/stash-box/pkg/manager/edit"
 	"github.com/stashapp/stash-box/pkg/models"
 )
 
@@ -99,6 +102,204 @@ func (s *editTestRunner) verifyEditComment(edit *models.Edit, comment string) {
 	}
 }
 
+func (s *editTestRunner) testEditVote() {
+	// edits are created by the admin user
+	createdEdit, err := s.createTestTagEdit(models.OperationEnumCreate, nil, nil)
+	if err != nil {
+		return
+	}
+
+	voter := &editTestRunner{
+		testRunner: *asEdit(s.t),
+	}
+
+	comment := "vote comment"
+	voteInput := models.EditVoteInput{
+		ID:      createdEdit.ID.String(),
+		Comment: &comment,
+		Type:    models.VoteTypeEnumAccept,
+	}
+	votedEdit, err := voter.resolver.Mutation().EditVote(voter.ctx, voteInput)
+	if err != nil {
+		s.t.Errorf("Error voting on edit: %s", err.Error())
+		return
+	}
+
+	if votedEdit.VoteCount != 1 {
+		s.fieldMismatch(1, votedEdit.VoteCount, "Vote count")
+	}
+
+	// changing the vote should replace the existing vote
+	voteInput.Type = models.VoteTypeEnumReject
+	votedEdit, err = voter.resolver.Mutation().EditVote(voter.ctx, voteInput)
+	if err != nil {
+		s.t.Errorf("Error voting on edit: %s", err.Error())
+		return
+	}
+
+	if votedEdit.VoteCount != -1 {
+		s.fieldMismatch(-1, votedEdit.VoteCount, "Vote count")
+	}
+
+	votes, _ := s.resolver.Edit().Votes(s.ctx, votedEdit)
+	if len(votes) != 1 {
+		s.fieldMismatch(1, len(votes), "Votes")
+	} else if !votes[0].Comment.Valid || votes[0].Comment.String != comment {
+		s.fieldMismatch(comment, votes[0].Comment, "Vote comment")
+	}
+
+	s.verifyEditStatus(models.VoteStatusEnumPending.String(), votedEdit)
+}
+
+func (s *editTestRunner) testOwnEditVote() {
+	createdEdit, err := s.createTestTagEdit(models.OperationEnumCreate, nil, nil)
+	if err != nil {
+		return
+	}
+
+	voteInput := models.EditVoteInput{
+		ID:   createdEdit.ID.String(),
+		Type: models.VoteTypeEnumAccept,
+	}
+	_, err = s.resolver.Mutation().EditVote(s.ctx, voteInput)
+	if err != edit.ErrOwnEditVote {
+		s.t.Errorf("EditVote: got %v want %v", err, edit.ErrOwnEditVote)
+	}
+}
+
+func (s *editTestRunner) testUnauthorisedImmediateVote() {
+	voteInput := models.EditVoteInput{
+		Type: models.VoteTypeEnumImmediateAccept,
+	}
+	_, err := s.resolver.Mutation().EditVote(s.ctx, voteInput)
+	if err != api.ErrUnauthorized {
+		s.t.Errorf("EditVote: got %v want %v", err, api.ErrUnauthorized)
+	}
+}
+
+func (s *editTestRunner) testImmediateAcceptVote() {
+	createdEdit, err := s.createTestTagEdit(models.OperationEnumCreate, nil, nil)
+	if err != nil {
+		return
+	}
+
+	voteInput := models.EditVoteInput{
+		ID:   createdEdit.ID.String(),
+		Type: models.VoteTypeEnumImmediateAccept,
+	}
+	votedEdit, err := s.resolver.Mutation().EditVote(s.ctx, voteInput)
+	if err != nil {
+		s.t.Errorf("Error voting on edit: %s", err.Error())
+		return
+	}
+
+	s.verifyEditStatus(models.VoteStatusEnumImmediateAccepted.String(), votedEdit)
+	s.verifyEditApplication(true, votedEdit)
+}
+
+func (s *editTestRunner) testResolveEdits() {
+	acceptedEdit, err := s.createTestTagEdit(models.OperationEnumCreate, nil, nil)
+	if err != nil {
+		return
+	}
+	rejectedEdit, err := s.createTestTagEdit(models.OperationEnumCreate, nil, nil)
+	if err != nil {
+		return
+	}
+
+	voter := &editTestRunner{
+		testRunner: *asEdit(s.t),
+	}
+
+	votes := map[*models.Edit]models.VoteTypeEnum{
+		acceptedEdit: models.VoteTypeEnumAccept,
+		rejectedEdit: models.VoteTypeEnumReject,
+	}
+	for e, voteType := range votes {
+		voteInput := models.EditVoteInput{
+			ID:   e.ID.String(),
+			Type: voteType,
+		}
+		if _, err := voter.resolver.Mutation().EditVote(voter.ctx, voteInput); err != nil {
+			s.t.Errorf("Error voting on edit: %s", err.Error())
+			return
+		}
+	}
+
+	if err := edit.ResolveEdits(dbtest.Repo(), 1, time.Hour); err != nil {
+		s.t.Errorf("Error resolving edits: %s", err.Error())
+		return
+	}
+
+	editID := acceptedEdit.ID.String()
+	resolvedEdit, _ := s.resolver.Query().FindEdit(s.ctx, &editID)
+	s.verifyEditStatus(models.VoteStatusEnumAccepted.String(), resolvedEdit)
+	s.verifyEditApplication(true, resolvedEdit)
+
+	editID = rejectedEdit.ID.String()
+	resolvedEdit, _ = s.resolver.Query().FindEdit(s.ctx, &editID)
+	s.verifyEditStatus(models.VoteStatusEnumRejected.String(), resolvedEdit)
+	s.verifyEditApplication(false, resolvedEdit)
+}
+
+func (s *editTestRunner) testResolveExpiredEdit() {
+	createdEdit, err := s.createTestTagEdit(models.OperationEnumCreate, nil, nil)
+	if err != nil {
+		return
+	}
+
+	// a zero length voting period expires all pending edits
+	if err := edit.ResolveEdits(dbtest.Repo(), 0, 0); err != nil {
+		s.t.Errorf("Error resolving edits: %s", err.Error())
+		return
+	}
+
+	editID := createdEdit.ID.String()
+	resolvedEdit, _ := s.resolver.Query().FindEdit(s.ctx, &editID)
+	s.verifyEditStatus(models.VoteStatusEnumClosed.String(), resolvedEdit)
+	s.verifyEditApplication(false, resolvedEdit)
+}
+
+func (s *editTestRunner) testResolveFailedEdit() {
+	name := s.generateTagName()
+	createdEdit, err := s.createTestTagEdit(models.OperationEnumCreate, &models.TagEditDetailsInput{
+		Name: &name,
+	}, nil)
+	if err != nil {
+		return
+	}
+
+	// the edit cannot be applied once a tag with the same name exists
+	if _, err := s.createTestTag(&models.TagCreateInput{Name: name}); err != nil {
+		return
+	}
+
+	voter := &editTestRunner{
+		testRunner: *asEdit(s.t),
+	}
+	voteInput := models.EditVoteInput{
+		ID:   createdEdit.ID.String(),
+		Type: models.VoteTypeEnumAccept,
+	}
+	if _, err := voter.resolver.Mutation().EditVote(voter.ctx, voteInput); err != nil {
+		s.t.Errorf("Error voting on edit: %s", err.Error())
+		return
+	}
+
+	// failed edits are not retried
+	for i := 0; i < 2; i++ {
+		if err := edit.ResolveEdits(dbtest.Repo(), 1, time.Hour); err != nil {
+			s.t.Errorf("Error resolving edits: %s", err.Error())
+			return
+		}
+
+		editID := createdEdit.ID.String()
+		resolvedEdit, _ := s.resolver.Query().FindEdit(s.ctx, &editID)
+		s.verifyEditStatus(models.VoteStatusEnumFailed.String(), resolvedEdit)
+		s.verifyEditApplication(false, resolvedEdit)
+	}
+}
+
 func TestUnauthorisedEditEdit(t *testing.T) {
 	pt := &editTestRunner{
 		testRunner: *asRead(t),
@@ -129,3 +330,40 @@ func TestEditComment(t *testing.T) {
 	pt := createEditTestRunner(t)
 	pt.testEditComment()
 }
+
+func TestEditVote(t *testing.T) {
+	pt := createEditTestRunner(t)
+	pt.testEditVote()
+}
+
+func TestOwnEditVote(t *testing.T) {
+	pt := createEditTestRunner(t)
+	pt.testOwnEditVote()
+}
+
+func TestUnauthorisedImmediateVote(t *testing.T) {
+	pt := &editTestRunner{
+		testRunner: *asEdit(t),
+	}
+	pt.testUnauthorisedImmediateVote()
+}
+
+func TestImmediateAcceptVote(t *testing.T) {
+	pt := createEditTestRunner(t)
+	pt.testImmediateAcceptVote()
+}
+
+func TestResolveEdits(t *testing.T) {
+	pt := createEditTestRunner(t)
+	pt.testResolveEdits()
+}
+
+func TestResolveExpiredEdit(t *testing.T) {
+	pt := createEditTestRunner(t)
+	pt.testResolveExpiredEdit()
+}
+
+func TestResolveFailedEdit(t *testing.T) {
+	pt := createEditTestRunner(t)
+	pt.testResolveFailedEdit()
+}
@@ -0,0 +1,33 @@
+package api
+
+import (
+	"context"
+	"errors"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+)
+
+// extendedError is implemented by errors with details for GraphQL clients,
+// such as the reason an image was rejected.
+type extendedError interface {
+	Extensions() map[string]interface{}
+}
+
+// errorPresenter includes the details of extended errors in the extensions
+// of the GraphQL error.
+func errorPresenter(ctx context.Context, err error) *gqlerror.Error {
+	gqlErr := graphql.DefaultErrorPresenter(ctx, err)
+
+	var extended extendedError
+	if errors.As(err, &extended) {
+		if gqlErr.Extensions == nil {
+			gqlErr.Extensions = make(map[string]interface{})
+		}
+		for k, v := range extended.Extensions() {
+			gqlErr.Extensions[k] = v
+		}
+	}
+
+	return gqlErr
+}
@@ -0,0 +1,142 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/stashapp/stash-box/pkg/image"
+	"github.com/stashapp/stash-box/pkg/logger"
+	"github.com/stashapp/stash-box/pkg/manager/config"
+)
+
+// readinessTimeout is the time after which readiness checks are abandoned.
+const readinessTimeout = 5 * time.Second
+
+const (
+	componentOK       = "ok"
+	componentError    = "error"
+	componentDisabled = "disabled"
+)
+
+// errComponentDisabled is returned by readiness checks of components which
+// are not configured, and do not need to be.
+var errComponentDisabled = errors.New("disabled")
+
+// ReadinessCheck checks whether a component is ready to serve requests.
+// Failures of optional components are reported without failing readiness.
+type ReadinessCheck struct {
+	Name     string
+	Check    func(ctx context.Context) error
+	Optional bool
+}
+
+type componentHealth struct {
+	Status string `json:"status"`
+}
+
+type healthResponse struct {
+	Status     string                     `json:"status"`
+	Components map[string]componentHealth `json:"components,omitempty"`
+}
+
+// defaultReadinessChecks returns the checks of the components configured
+// outside of the database.
+func defaultReadinessChecks() []ReadinessCheck {
+	return []ReadinessCheck{
+		{Name: "image_backend", Check: checkImageBackend},
+		{Name: "email", Check: checkEmail, Optional: true},
+	}
+}
+
+func checkImageBackend(ctx context.Context) error {
+	backend, err := image.GetBackend(config.GetImageBackend())
+	if err != nil {
+		return err
+	}
+
+	checker, ok := backend.(image.HealthChecker)
+	if !ok {
+		return nil
+	}
+
+	return checker.CheckHealth(ctx)
+}
+
+func checkEmail(ctx context.Context) error {
+	if missing := config.GetMissingEmailSettings(); len(missing) > 0 {
+		return fmt.Errorf("missing email settings: %s", strings.Join(missing, ", "))
+	}
+
+	if config.GetEmailHost() == "" {
+		return errComponentDisabled
+	}
+
+	return nil
+}
+
+// handleHealth reports that the server is running.
+func handleHealth(w http.ResponseWriter, r *http.Request) {
+	writeHealthResponse(w, http.StatusOK, healthResponse{Status: componentOK})
+}
+
+// readinessHandler runs the readiness checks concurrently, responding with
+// the status of each component. The response status is 503 if any required
+// component is not ready. Errors are logged rather than returned, since the
+// endpoint is public.
+func readinessHandler(checks []ReadinessCheck) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx, cancel := context.WithTimeout(r.Context(), readinessTimeout)
+		defer cancel()
+
+		response := healthResponse{
+			Status:     componentOK,
+			Components: make(map[string]componentHealth),
+		}
+
+		var mutex sync.Mutex
+		var wg sync.WaitGroup
+		for _, check := range checks {
+			wg.Add(1)
+			go func(check ReadinessCheck) {
+				defer wg.Done()
+
+				health := componentHealth{Status: componentOK}
+				if err := check.Check(ctx); errors.Is(err, errComponentDisabled) {
+					health.Status = componentDisabled
+				} else if err != nil {
+					health.Status = componentError
+					logger.Errorf("Readiness check %s failed: %s", check.Name, err.Error())
+				}
+
+				mutex.Lock()
+				response.Components[check.Name] = health
+				if health.Status == componentError && !check.Optional {
+					response.Status = componentError
+				}
+				mutex.Unlock()
+			}(check)
+		}
+		wg.Wait()
+
+		status := http.StatusOK
+		if response.Status != componentOK {
+			status = http.StatusServiceUnavailable
+		}
+		writeHealthResponse(w, status, response)
+	}
+}
+
+func writeHealthResponse(w http.ResponseWriter, status int, response healthResponse) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		logger.Errorf("Error writing health response: %s", err.Error())
+	}
+}
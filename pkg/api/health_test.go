@@ -0,0 +1,133 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stashapp/stash-box/pkg/user"
+)
+
+func TestReadinessHandler(t *testing.T) {
+	errSecret := errors.New("password authentication failed for user secret")
+	ok := func(ctx context.Context) error { return nil }
+	failing := func(ctx context.Context) error { return errSecret }
+	disabled := func(ctx context.Context) error { return errComponentDisabled }
+
+	tests := []struct {
+		name       string
+		checks     []ReadinessCheck
+		wantStatus int
+		want       healthResponse
+	}{
+		{
+			"ready",
+			[]ReadinessCheck{
+				{Name: "database", Check: ok},
+				{Name: "email", Check: disabled, Optional: true},
+			},
+			http.StatusOK,
+			healthResponse{Status: componentOK, Components: map[string]componentHealth{
+				"database": {Status: componentOK},
+				"email":    {Status: componentDisabled},
+			}},
+		},
+		{
+			"optional component failing",
+			[]ReadinessCheck{
+				{Name: "database", Check: ok},
+				{Name: "email", Check: failing, Optional: true},
+			},
+			http.StatusOK,
+			healthResponse{Status: componentOK, Components: map[string]componentHealth{
+				"database": {Status: componentOK},
+				"email":    {Status: componentError},
+			}},
+		},
+		{
+			"required component failing",
+			[]ReadinessCheck{
+				{Name: "database", Check: failing},
+				{Name: "image_backend", Check: ok},
+			},
+			http.StatusServiceUnavailable,
+			healthResponse{Status: componentError, Components: map[string]componentHealth{
+				"database":      {Status: componentError},
+				"image_backend": {Status: componentOK},
+			}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			readinessHandler(tt.checks)(rr, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+
+			if rr.Code != tt.wantStatus {
+				t.Errorf("status: got %d want %d", rr.Code, tt.wantStatus)
+			}
+			// error details are only logged
+			if strings.Contains(rr.Body.String(), "secret") {
+				t.Errorf("response contains error details: %s", rr.Body.String())
+			}
+
+			var got healthResponse
+			if err := json.NewDecoder(rr.Body).Decode(&got); err != nil {
+				t.Fatal(err)
+			}
+			if got.Status != tt.want.Status {
+				t.Errorf("status: got %s want %s", got.Status, tt.want.Status)
+			}
+			if len(got.Components) != len(tt.want.Components) {
+				t.Errorf("components: got %v want %v", got.Components, tt.want.Components)
+			}
+			for name, want := range tt.want.Components {
+				if got.Components[name] != want {
+					t.Errorf("component %s: got %v want %v", name, got.Components[name], want)
+				}
+			}
+		})
+	}
+}
+
+func TestRequireReady(t *testing.T) {
+	s := &Server{apiCalls: user.NewAPICallCounter()}
+	handler := s.requireReady(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/graphql", nil))
+	if rr.Code != http.StatusServiceUnavailable {
+		t.Errorf("before ready: got %d want %d", rr.Code, http.StatusServiceUnavailable)
+	}
+	if rr.Header().Get("Retry-After") == "" {
+		t.Error("before ready: missing Retry-After header")
+	}
+
+	s.SetReady()
+	defer func() { _ = s.scheduler.Stop(context.Background()) }()
+
+	rr = httptest.NewRecorder()
+	handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/graphql", nil))
+	if rr.Code != http.StatusOK {
+		t.Errorf("after ready: got %d want %d", rr.Code, http.StatusOK)
+	}
+}
+
+func TestSetReadyAfterShutdown(t *testing.T) {
+	s := &Server{apiCalls: user.NewAPICallCounter(), stopped: true}
+	s.SetReady()
+
+	if s.scheduler != nil {
+		t.Error("jobs started after shutdown")
+	}
+	if atomic.LoadInt32(&s.ready) != 0 {
+		t.Error("server ready after shutdown")
+	}
+}
@@ -0,0 +1,291 @@
+//go:build integration
+// +build integration
+
+package api_test
+
+import (
+	"database/sql"
+	"encoding/hex"
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/gofrs/uuid"
+
+	"github.com/stashapp/stash-box/pkg/database/databasetest"
+	"github.com/stashapp/stash-box/pkg/models"
+)
+
+type imageTestRunner struct {
+	testRunner
+}
+
+func createImageTestRunner(t *testing.T) *imageTestRunner {
+	return &imageTestRunner{
+		testRunner: *asAdmin(t),
+	}
+}
+
+// createTestImage creates an image without a stored file.
+func (s *imageTestRunner) createTestImage(created time.Time, phash *int64) *models.Image {
+	s.t.Helper()
+
+	id := uuid.Must(uuid.NewV4())
+	newImage := models.Image{
+		ID:        id,
+		Checksum:  hex.EncodeToString(id.Bytes()),
+		Width:     1,
+		Height:    1,
+		CreatedAt: models.SQLiteTimestamp{Timestamp: created},
+	}
+	if phash != nil {
+		newImage.PHash = sql.NullInt64{Int64: *phash, Valid: true}
+	}
+
+	repo := databasetest.Repo()
+	var image *models.Image
+	err := repo.WithTxn(func() error {
+		var err error
+		image, err = repo.Image().Create(newImage)
+		return err
+	})
+	if err != nil {
+		s.t.Fatalf("Error creating image: %s", err.Error())
+	}
+
+	return image
+}
+
+func (s *imageTestRunner) imageExists(id uuid.UUID) bool {
+	s.t.Helper()
+
+	repo := databasetest.Repo()
+	var image *models.Image
+	err := repo.WithTxn(func() error {
+		var err error
+		image, err = repo.Image().Find(id)
+		return err
+	})
+	if err != nil {
+		s.t.Fatalf("Error finding image: %s", err.Error())
+	}
+
+	return image != nil
+}
+
+func containsImage(images []*models.Image, id uuid.UUID) bool {
+	for _, image := range images {
+		if image.ID == id {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *imageTestRunner) testImageGarbageCollect() {
+	old := time.Now().Add(-30 * 24 * time.Hour)
+	orphan := s.createTestImage(old, nil)
+	recentOrphan := s.createTestImage(time.Now(), nil)
+	used := s.createTestImage(old, nil)
+	edited := s.createTestImage(old, nil)
+	removed := s.createTestImage(old, nil)
+
+	name := s.generatePerformerName()
+	if _, err := s.createTestPerformer(&models.PerformerCreateInput{
+		Name:     name,
+		ImageIds: []string{used.ID.String()},
+	}); err != nil {
+		return
+	}
+
+	editName := s.generatePerformerName()
+	if _, err := s.createTestPerformerEdit(models.OperationEnumCreate, &models.PerformerEditDetailsInput{
+		Name:     &editName,
+		ImageIds: []string{edited.ID.String()},
+	}, nil, nil); err != nil {
+		return
+	}
+
+	// an image removed by a recently applied edit is kept, so that the
+	// edit can be reverted
+	removedName := s.generatePerformerName()
+	performer, err := s.createTestPerformer(&models.PerformerCreateInput{
+		Name:     removedName,
+		ImageIds: []string{removed.ID.String()},
+	})
+	if err != nil {
+		return
+	}
+	performerID := performer.ID.String()
+	removeEdit, err := s.createTestPerformerEdit(models.OperationEnumModify, &models.PerformerEditDetailsInput{
+		Name:     &removedName,
+		ImageIds: []string{},
+	}, &models.EditInput{
+		Operation: models.OperationEnumModify,
+		ID:        &performerID,
+	}, nil)
+	if err != nil {
+		return
+	}
+	if _, err := s.applyEdit(removeEdit.ID.String()); err != nil {
+		return
+	}
+
+	dryRun := true
+	result, err := s.resolver.Mutation().ImageGarbageCollect(s.ctx, &dryRun)
+	if err != nil {
+		s.t.Errorf("Error collecting images: %s", err.Error())
+		return
+	}
+
+	if !containsImage(result.Images, orphan.ID) {
+		s.t.Error("orphaned image was not found")
+	}
+	for _, image := range []*models.Image{recentOrphan, used, edited, removed} {
+		if containsImage(result.Images, image.ID) {
+			s.t.Errorf("image %s was incorrectly found as orphaned", image.ID)
+		}
+	}
+	if !s.imageExists(orphan.ID) {
+		s.t.Error("orphaned image was destroyed in a dry run")
+	}
+
+	if _, err := s.resolver.Mutation().ImageGarbageCollect(s.ctx, nil); err != nil {
+		s.t.Errorf("Error collecting images: %s", err.Error())
+		return
+	}
+
+	if s.imageExists(orphan.ID) {
+		s.t.Error("orphaned image was not destroyed")
+	}
+	for _, image := range []*models.Image{recentOrphan, used, edited, removed} {
+		if !s.imageExists(image.ID) {
+			s.t.Errorf("image %s was incorrectly destroyed", image.ID)
+		}
+	}
+}
+
+func (s *imageTestRunner) testFindImageDuplicates() {
+	phash := rand.Int63()
+	first := s.createTestImage(time.Now(), &phash)
+	second := s.createTestImage(time.Now(), &phash)
+	other := phash ^ 0xffff
+	different := s.createTestImage(time.Now(), &other)
+
+	clusters, err := s.resolver.Query().FindImageDuplicates(s.ctx, nil)
+	if err != nil {
+		s.t.Errorf("Error finding image duplicates: %s", err.Error())
+		return
+	}
+
+	var cluster []*models.Image
+	for _, c := range clusters {
+		if containsImage(c, first.ID) {
+			cluster = c
+		}
+	}
+
+	if len(cluster) != 2 || !containsImage(cluster, second.ID) {
+		s.t.Errorf("duplicate cluster: got %v want [%s %s]", cluster, first.ID, second.ID)
+	}
+	if containsImage(cluster, different.ID) {
+		s.t.Error("image with a different phash was found as a duplicate")
+	}
+}
+
+func (s *imageTestRunner) testImageMerge() {
+	destination := s.createTestImage(time.Now(), nil)
+	source := s.createTestImage(time.Now(), nil)
+
+	// performer with both the source and destination images
+	both, err := s.createTestPerformer(&models.PerformerCreateInput{
+		Name:     s.generatePerformerName(),
+		ImageIds: []string{source.ID.String(), destination.ID.String()},
+	})
+	if err != nil {
+		return
+	}
+	sourceOnly, err := s.createTestPerformer(&models.PerformerCreateInput{
+		Name:     s.generatePerformerName(),
+		ImageIds: []string{source.ID.String()},
+	})
+	if err != nil {
+		return
+	}
+
+	merged, err := s.resolver.Mutation().ImageMerge(s.ctx, models.ImageMergeInput{
+		SourceIds:     []string{source.ID.String()},
+		DestinationID: destination.ID.String(),
+	})
+	if err != nil {
+		s.t.Errorf("Error merging images: %s", err.Error())
+		return
+	}
+	if merged.ID != destination.ID {
+		s.t.Errorf("merged image: got %s want %s", merged.ID, destination.ID)
+	}
+
+	repo := databasetest.Repo()
+	for _, performer := range []*models.Performer{both, sourceOnly} {
+		var images models.Images
+		err := repo.WithTxn(func() error {
+			var err error
+			images, err = repo.Image().FindByPerformerID(performer.ID)
+			return err
+		})
+		if err != nil {
+			s.t.Errorf("Error finding performer images: %s", err.Error())
+			return
+		}
+
+		if len(images) != 1 || images[0].ID != destination.ID {
+			s.t.Errorf("performer images: got %v want [%s]", images, destination.ID)
+		}
+	}
+
+	if s.imageExists(source.ID) {
+		s.t.Error("merged source image was not destroyed")
+	}
+
+	if _, err := s.resolver.Mutation().ImageMerge(s.ctx, models.ImageMergeInput{
+		SourceIds:     []string{destination.ID.String()},
+		DestinationID: destination.ID.String(),
+	}); err == nil {
+		s.t.Error("expected error merging image into itself")
+	}
+
+	// applying a pending edit would restore a merged source
+	pending := s.createTestImage(time.Now(), nil)
+	editName := s.generatePerformerName()
+	if _, err := s.createTestPerformerEdit(models.OperationEnumCreate, &models.PerformerEditDetailsInput{
+		Name:     &editName,
+		ImageIds: []string{pending.ID.String()},
+	}, nil, nil); err != nil {
+		return
+	}
+
+	if _, err := s.resolver.Mutation().ImageMerge(s.ctx, models.ImageMergeInput{
+		SourceIds:     []string{pending.ID.String()},
+		DestinationID: destination.ID.String(),
+	}); err == nil {
+		s.t.Error("expected error merging image used by a pending edit")
+	}
+	if !s.imageExists(pending.ID) {
+		s.t.Error("image used by a pending edit was destroyed")
+	}
+}
+
+func TestImageGarbageCollect(t *testing.T) {
+	pt := createImageTestRunner(t)
+	pt.testImageGarbageCollect()
+}
+
+func TestFindImageDuplicates(t *testing.T) {
+	pt := createImageTestRunner(t)
+	pt.testFindImageDuplicates()
+}
+
+func TestImageMerge(t *testing.T) {
+	pt := createImageTestRunner(t)
+	pt.testImageMerge()
+}
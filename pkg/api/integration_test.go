@@ -16,6 +16,7 @@ import (
 	"github.com/stashapp/stash-box/pkg/user"
 
 	"github.com/99designs/gqlgen/graphql"
+	"github.com/gofrs/uuid"
 )
 
 // we need to create some users to test the api with, otherwise all calls
@@ -159,7 +160,11 @@ func createTestRunner(t *testing.T, user *models.User, roles []models.RoleEnum)
 	ctx := context.TODO()
 	ctx = context.WithValue(ctx, api.ContextUser, user)
 	ctx = context.WithValue(ctx, api.ContextRoles, roles)
-	ctx = context.WithValue(ctx, dataloader.GetLoadersKey(), dataloader.GetLoaders(dbtest.Repo()))
+	var userID uuid.UUID
+	if user != nil {
+		userID = user.ID
+	}
+	ctx = context.WithValue(ctx, dataloader.GetLoadersKey(), dataloader.GetLoaders(dbtest.Repo(), userID))
 	ctx = graphql.WithOperationContext(ctx, &graphql.OperationContext{})
 
 	return &testRunner{
@@ -502,6 +507,42 @@ func (s *testRunner) createTestStudioEdit(operation models.OperationEnum, detail
 	return createdEdit, nil
 }
 
+func (s *testRunner) createTestSceneEdit(operation models.OperationEnum, detailsInput *models.SceneEditDetailsInput, editInput *models.EditInput) (*models.Edit, error) {
+	s.t.Helper()
+
+	if editInput == nil {
+		input := models.EditInput{
+			Operation: operation,
+		}
+		editInput = &input
+	}
+
+	if detailsInput == nil {
+		title := "title"
+		input := models.SceneEditDetailsInput{
+			Title: &title,
+			Fingerprints: []*models.FingerprintEditInput{
+				s.generateSceneFingerprint(),
+			},
+		}
+		detailsInput = &input
+	}
+
+	sceneEditInput := models.SceneEditInput{
+		Edit:    editInput,
+		Details: detailsInput,
+	}
+
+	createdEdit, err := s.resolver.Mutation().SceneEdit(s.ctx, sceneEditInput)
+
+	if err != nil {
+		s.t.Errorf("Error creating edit: %s", err.Error())
+		return nil, err
+	}
+
+	return createdEdit, nil
+}
+
 func (s *testRunner) applyEdit(id string) (*models.Edit, error) {
 	s.t.Helper()
 
@@ -554,6 +595,24 @@ func (s *testRunner) getEditStudioTarget(input *models.Edit) *models.Studio {
 	return tagTarget
 }
 
+func (s *testRunner) getEditSceneDetails(input *models.Edit) *models.SceneEdit {
+	s.t.Helper()
+	r := s.resolver.Edit()
+
+	details, _ := r.Details(s.ctx, input)
+	sceneDetails := details.(*models.SceneEdit)
+	return sceneDetails
+}
+
+func (s *testRunner) getEditSceneTarget(input *models.Edit) *models.Scene {
+	s.t.Helper()
+	r := s.resolver.Edit()
+
+	target, _ := r.Target(s.ctx, input)
+	sceneTarget := target.(*models.Scene)
+	return sceneTarget
+}
+
 func compareUrls(input []*models.URLInput, urls []*models.URL) bool {
 	if len(urls) != len(input) {
 		return false
@@ -0,0 +1,58 @@
+package api
+
+import (
+	"context"
+
+	"github.com/stashapp/stash-box/pkg/image"
+	"github.com/stashapp/stash-box/pkg/logger"
+	"github.com/stashapp/stash-box/pkg/manager/config"
+	"github.com/stashapp/stash-box/pkg/manager/cron"
+	"github.com/stashapp/stash-box/pkg/manager/edit"
+	"github.com/stashapp/stash-box/pkg/user"
+	"github.com/stashapp/stash-box/pkg/webhook"
+)
+
+// startJobs schedules the recurring background jobs. Each job run uses its
+// own Repo, since Repo objects must not be shared between goroutines.
+func startJobs(rfp RepoProvider, apiCalls *user.APICallCounter) *cron.Scheduler {
+	scheduler := cron.NewScheduler()
+
+	scheduler.Every(config.GetEditResolutionInterval(), func(ctx context.Context) {
+		err := edit.ResolveEdits(rfp.Repo(), config.GetVoteApplicationThreshold(), config.GetVotingPeriod())
+		if err != nil {
+			logger.Errorf("Error resolving edits: %s", err.Error())
+		}
+	})
+
+	scheduler.Every(config.GetAPICallFlushInterval(), func(ctx context.Context) {
+		if err := apiCalls.Flush(rfp.Repo(), config.GetAPICallWindow()); err != nil {
+			logger.Errorf("Error writing API call counts: %s", err.Error())
+		}
+	})
+
+	scheduler.Every(config.GetImageGCInterval(), func(ctx context.Context) {
+		backend, err := image.GetBackend(config.GetImageBackend())
+		if err != nil {
+			logger.Errorf("Error removing orphaned images: %s", err.Error())
+			return
+		}
+
+		result, err := image.CollectGarbage(ctx, rfp.Repo(), backend, config.GetImageGCGracePeriod(), false)
+		if err != nil {
+			logger.Errorf("Error removing orphaned images: %s", err.Error())
+			return
+		}
+		if len(result.Images) > 0 || result.Files > 0 {
+			logger.Infof("Removed %d orphaned images and %d orphaned image files", len(result.Images), result.Files)
+		}
+	})
+
+	deliverer := webhook.NewDeliverer()
+	scheduler.Every(config.GetWebhookDeliveryInterval(), func(ctx context.Context) {
+		if err := deliverer.Deliver(ctx, rfp.Repo()); err != nil {
+			logger.Errorf("Error sending webhook deliveries: %s", err.Error())
+		}
+	})
+
+	return scheduler
+}
@@ -0,0 +1,199 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/99designs/gqlgen/graphql/errcode"
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+
+	"github.com/stashapp/stash-box/pkg/models"
+)
+
+const errDepthLimit = "DEPTH_LIMIT_EXCEEDED"
+
+// defaultPerPage is the number of results returned by queries without a
+// per_page, matching the query builder.
+const defaultPerPage = 25
+
+const (
+	defaultPerformerSearchLimit = 5
+	defaultSceneSearchLimit     = 10
+)
+
+// PerPageLimitError is returned when a query requests more results than
+// the configured maximum.
+type PerPageLimitError struct {
+	Limit int
+}
+
+func (e *PerPageLimitError) Error() string {
+	return fmt.Sprintf("per_page must not exceed %d", e.Limit)
+}
+
+// Extensions returns the details of the error for GraphQL clients.
+func (e *PerPageLimitError) Extensions() map[string]interface{} {
+	return map[string]interface{}{
+		"code":  "PER_PAGE_LIMIT_EXCEEDED",
+		"limit": e.Limit,
+	}
+}
+
+// queryLimits rejects operations nested deeper than maxDepth, and queries
+// requesting more than maxPerPage results. Limits of 0 are disabled.
+type queryLimits struct {
+	maxDepth   int
+	maxPerPage int
+}
+
+var _ interface {
+	graphql.HandlerExtension
+	graphql.OperationContextMutator
+	graphql.FieldInterceptor
+} = queryLimits{}
+
+func (queryLimits) ExtensionName() string {
+	return "QueryLimits"
+}
+
+func (queryLimits) Validate(schema graphql.ExecutableSchema) error {
+	return nil
+}
+
+func (l queryLimits) MutateOperationContext(ctx context.Context, rc *graphql.OperationContext) *gqlerror.Error {
+	if l.maxDepth <= 0 {
+		return nil
+	}
+
+	op := rc.Doc.Operations.ForName(rc.OperationName)
+	if depth := selectionDepth(op.SelectionSet); depth > l.maxDepth {
+		err := gqlerror.Errorf("operation has depth %d, which exceeds the limit of %d", depth, l.maxDepth)
+		errcode.Set(err, errDepthLimit)
+		return err
+	}
+
+	return nil
+}
+
+func (l queryLimits) InterceptField(ctx context.Context, next graphql.Resolver) (interface{}, error) {
+	fc := graphql.GetFieldContext(ctx)
+	if l.maxPerPage <= 0 || fc.Object != "Query" {
+		return next(ctx)
+	}
+
+	if filter, ok := fc.Args["filter"].(*models.QuerySpec); ok && filter != nil && filter.PerPage != nil && *filter.PerPage > l.maxPerPage {
+		return nil, &PerPageLimitError{Limit: l.maxPerPage}
+	}
+	if limit, ok := fc.Args["limit"].(*int); ok && limit != nil && *limit > l.maxPerPage {
+		return nil, &PerPageLimitError{Limit: l.maxPerPage}
+	}
+
+	return next(ctx)
+}
+
+// selectionDepth returns the deepest nesting of fields in the selection set.
+// Introspection fields are not counted.
+func selectionDepth(selectionSet ast.SelectionSet) int {
+	ret := 0
+	for _, selection := range selectionSet {
+		var depth int
+		switch selection := selection.(type) {
+		case *ast.Field:
+			if strings.HasPrefix(selection.Name, "__") {
+				continue
+			}
+			depth = 1 + selectionDepth(selection.SelectionSet)
+		case *ast.FragmentSpread:
+			if selection.Definition != nil {
+				depth = selectionDepth(selection.Definition.SelectionSet)
+			}
+		case *ast.InlineFragment:
+			depth = selectionDepth(selection.SelectionSet)
+		}
+
+		if depth > ret {
+			ret = depth
+		}
+	}
+
+	return ret
+}
+
+// newComplexityRoot returns the complexity functions of fields returning a
+// variable number of results, which multiply the complexity of the results
+// by the number requested. Other fields score 1 plus their children.
+func newComplexityRoot(maxPerPage int) models.ComplexityRoot {
+	page := func(childComplexity int, filter *models.QuerySpec) int {
+		perPage := defaultPerPage
+		if filter != nil && filter.PerPage != nil {
+			perPage = *filter.PerPage
+		}
+		return listComplexity(childComplexity, perPage, maxPerPage)
+	}
+	search := func(childComplexity int, limit *int, defaultLimit int) int {
+		if limit != nil {
+			defaultLimit = *limit
+		}
+		return listComplexity(childComplexity, defaultLimit, maxPerPage)
+	}
+
+	var c models.ComplexityRoot
+	c.Query.QueryAuditLog = func(childComplexity int, _ *models.AuditLogFilterType, filter *models.QuerySpec) int {
+		return page(childComplexity, filter)
+	}
+	c.Query.QueryEdits = func(childComplexity int, _ *models.EditFilterType, filter *models.QuerySpec) int {
+		return page(childComplexity, filter)
+	}
+	c.Query.QueryPerformers = func(childComplexity int, _ *models.PerformerFilterType, filter *models.QuerySpec) int {
+		return page(childComplexity, filter)
+	}
+	c.Query.QueryScenes = func(childComplexity int, _ *models.SceneFilterType, filter *models.QuerySpec) int {
+		return page(childComplexity, filter)
+	}
+	c.Query.QueryStudios = func(childComplexity int, _ *models.StudioFilterType, filter *models.QuerySpec) int {
+		return page(childComplexity, filter)
+	}
+	c.Query.QueryTagCategories = func(childComplexity int, filter *models.QuerySpec) int {
+		return page(childComplexity, filter)
+	}
+	c.Query.QueryTags = func(childComplexity int, _ *models.TagFilterType, filter *models.QuerySpec) int {
+		return page(childComplexity, filter)
+	}
+	c.Query.QueryUsers = func(childComplexity int, _ *models.UserFilterType, filter *models.QuerySpec) int {
+		return page(childComplexity, filter)
+	}
+	c.Query.SearchPerformer = func(childComplexity int, _ string, limit *int) int {
+		return search(childComplexity, limit, defaultPerformerSearchLimit)
+	}
+	c.Query.SearchScene = func(childComplexity int, _ string, limit *int) int {
+		return search(childComplexity, limit, defaultSceneSearchLimit)
+	}
+	c.Query.FindScenesByFingerprints = func(childComplexity int, fingerprints []string) int {
+		return listComplexity(childComplexity, len(fingerprints), 0)
+	}
+	c.Query.FindScenesByFullFingerprints = func(childComplexity int, fingerprints []*models.FingerprintQueryInput) int {
+		return listComplexity(childComplexity, len(fingerprints), 0)
+	}
+	c.Query.FindFingerprintMatches = func(childComplexity int, fingerprints []*models.FingerprintQueryInput, _ *int) int {
+		return listComplexity(childComplexity, len(fingerprints), 0)
+	}
+
+	return c
+}
+
+// listComplexity returns the complexity of a field returning n results,
+// with n capped by max if it is positive. Requests over the cap are rejected
+// by queryLimits when the field is resolved.
+func listComplexity(childComplexity int, n int, max int) int {
+	if max > 0 && n > max {
+		n = max
+	}
+	if n < 1 {
+		n = 1
+	}
+
+	return 1 + childComplexity*n
+}
@@ -0,0 +1,129 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	gqlHandler "github.com/99designs/gqlgen/graphql/handler"
+	gqlExtension "github.com/99designs/gqlgen/graphql/handler/extension"
+	gqlTransport "github.com/99designs/gqlgen/graphql/handler/transport"
+
+	"github.com/stashapp/stash-box/pkg/models"
+)
+
+type limitsResponse struct {
+	Errors []struct {
+		Message    string                 `json:"message"`
+		Extensions map[string]interface{} `json:"extensions"`
+	} `json:"errors"`
+}
+
+// postLimitsQuery runs the query against a handler with the limits, which
+// are checked before any resolver is called.
+func postLimitsQuery(t *testing.T, limits queryLimits, maxComplexity int, query string) limitsResponse {
+	t.Helper()
+
+	srv := gqlHandler.New(models.NewExecutableSchema(models.Config{
+		Resolvers:  NewResolver(getRepo),
+		Complexity: newComplexityRoot(limits.maxPerPage),
+	}))
+	srv.SetErrorPresenter(errorPresenter)
+	srv.AddTransport(gqlTransport.POST{})
+	srv.Use(limits)
+	srv.Use(gqlExtension.FixedComplexityLimit(maxComplexity))
+
+	body, err := json.Marshal(map[string]string{"query": query})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r := httptest.NewRequest(http.MethodPost, "/graphql", bytes.NewReader(body))
+	r.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	srv.ServeHTTP(w, r)
+
+	var ret limitsResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &ret); err != nil {
+		t.Fatalf("decoding response %s: %s", w.Body.String(), err.Error())
+	}
+	return ret
+}
+
+func assertLimitError(t *testing.T, name string, resp limitsResponse, code string) {
+	t.Helper()
+
+	if len(resp.Errors) != 1 {
+		t.Errorf("%s: got %d errors want 1", name, len(resp.Errors))
+		return
+	}
+	if got := resp.Errors[0].Extensions["code"]; got != code {
+		t.Errorf("%s: got code %v want %s (%s)", name, got, code, resp.Errors[0].Message)
+	}
+}
+
+func TestDepthLimit(t *testing.T) {
+	const query = `query {
+		findScene(id: "00000000-0000-0000-0000-000000000000") {
+			studio { parent { child_studios { name } } }
+		}
+	}`
+
+	resp := postLimitsQuery(t, queryLimits{maxDepth: 3}, 1000, query)
+	assertLimitError(t, "depth", resp, errDepthLimit)
+
+	const fragmentQuery = `query {
+		findScene(id: "00000000-0000-0000-0000-000000000000") { ...SceneStudio }
+	}
+	fragment SceneStudio on Scene { studio { parent { name } } }`
+
+	resp = postLimitsQuery(t, queryLimits{maxDepth: 3}, 1000, fragmentQuery)
+	assertLimitError(t, "fragment depth", resp, errDepthLimit)
+}
+
+func TestComplexityLimit(t *testing.T) {
+	const query = `query {
+		queryScenes(filter: { per_page: 100 }) { scenes { id title } }
+	}`
+
+	// 1 + (1 + 2 fields) * 100
+	resp := postLimitsQuery(t, queryLimits{}, 300, query)
+	assertLimitError(t, "complexity", resp, "COMPLEXITY_LIMIT_EXCEEDED")
+}
+
+func TestPerPageLimit(t *testing.T) {
+	const query = `query {
+		queryScenes(filter: { per_page: 101 }) { count }
+	}`
+
+	resp := postLimitsQuery(t, queryLimits{maxPerPage: 100}, 1000, query)
+	assertLimitError(t, "per_page", resp, "PER_PAGE_LIMIT_EXCEEDED")
+
+	const searchQuery = `query {
+		searchScene(term: "scene", limit: 101) { id }
+	}`
+
+	resp = postLimitsQuery(t, queryLimits{maxPerPage: 100}, 1000, searchQuery)
+	assertLimitError(t, "search limit", resp, "PER_PAGE_LIMIT_EXCEEDED")
+}
+
+func TestListComplexity(t *testing.T) {
+	tests := []struct {
+		childComplexity int
+		n               int
+		max             int
+		want            int
+	}{
+		{3, 10, 0, 31},
+		{3, 10, 5, 16},
+		{3, 0, 0, 4},
+	}
+
+	for _, tt := range tests {
+		if got := listComplexity(tt.childComplexity, tt.n, tt.max); got != tt.want {
+			t.Errorf("listComplexity(%d, %d, %d) = %d, want %d", tt.childComplexity, tt.n, tt.max, got, tt.want)
+		}
+	}
+}
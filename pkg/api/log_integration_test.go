@@ -0,0 +1,117 @@
+//go:build integration
+// +build integration
+
+package api_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stashapp/stash-box/pkg/api"
+	"github.com/stashapp/stash-box/pkg/logger"
+	"github.com/stashapp/stash-box/pkg/models"
+)
+
+type logTestRunner struct {
+	testRunner
+}
+
+func createLogTestRunner(t *testing.T) *logTestRunner {
+	return &logTestRunner{
+		testRunner: *asAdmin(t),
+	}
+}
+
+func containsLogMessage(items []*logger.LogItem, message string) bool {
+	for _, item := range items {
+		if item.Message == message {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *logTestRunner) testLogs() {
+	message := "test log item " + time.Now().String()
+	logger.Info(message)
+
+	items, err := s.resolver.Query().Logs(s.ctx, nil)
+	if err != nil {
+		s.t.Errorf("Error querying logs: %s", err.Error())
+		return
+	}
+	if !containsLogMessage(items, message) {
+		s.t.Errorf("log item %q was not found", message)
+	}
+
+	level := models.LogLevelEnumError
+	items, err = s.resolver.Query().Logs(s.ctx, &level)
+	if err != nil {
+		s.t.Errorf("Error querying logs: %s", err.Error())
+		return
+	}
+	if containsLogMessage(items, message) {
+		s.t.Errorf("info log item %q was returned for level %s", message, level)
+	}
+}
+
+func (s *logTestRunner) testLogsSubscription() {
+	ctx, cancel := context.WithCancel(s.ctx)
+	defer cancel()
+
+	level := models.LogLevelEnumWarning
+	items, err := s.resolver.Subscription().Logs(ctx, &level)
+	if err != nil {
+		s.t.Errorf("Error subscribing to logs: %s", err.Error())
+		return
+	}
+
+	info := "test subscription info " + time.Now().String()
+	warning := "test subscription warning " + time.Now().String()
+	logger.Info(info)
+	logger.Warn(warning)
+
+	timeout := time.After(5 * time.Second)
+	for {
+		select {
+		case batch := <-items:
+			if containsLogMessage(batch, info) {
+				s.t.Errorf("info log item %q was streamed for level %s", info, level)
+			}
+			if containsLogMessage(batch, warning) {
+				return
+			}
+		case <-timeout:
+			s.t.Errorf("log item %q was not streamed", warning)
+			return
+		}
+	}
+}
+
+func (s *logTestRunner) testUnauthorisedLogs() {
+	if _, err := s.resolver.Query().Logs(s.ctx, nil); err != api.ErrUnauthorized {
+		s.t.Errorf("Logs: got %v want %v", err, api.ErrUnauthorized)
+	}
+
+	if _, err := s.resolver.Subscription().Logs(s.ctx, nil); err != api.ErrUnauthorized {
+		s.t.Errorf("Logs subscription: got %v want %v", err, api.ErrUnauthorized)
+	}
+}
+
+func TestLogs(t *testing.T) {
+	pt := createLogTestRunner(t)
+	pt.testLogs()
+}
+
+func TestLogsSubscription(t *testing.T) {
+	pt := createLogTestRunner(t)
+	pt.testLogsSubscription()
+}
+
+func TestUnauthorisedLogs(t *testing.T) {
+	pt := &logTestRunner{
+		testRunner: *asModify(t),
+	}
+	pt.testUnauthorisedLogs()
+}
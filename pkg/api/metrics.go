@@ -0,0 +1,131 @@
+package api
+
+import (
+	"context"
+	"crypto/subtle"
+	"net/http"
+	"time"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+
+	"github.com/stashapp/stash-box/pkg/logger"
+	"github.com/stashapp/stash-box/pkg/manager/config"
+	"github.com/stashapp/stash-box/pkg/metrics"
+	"github.com/stashapp/stash-box/pkg/models"
+)
+
+// metricsExtension observes the duration and errors of each query and
+// mutation, labelled by the root field name.
+type metricsExtension struct{}
+
+var _ interface {
+	graphql.HandlerExtension
+	graphql.FieldInterceptor
+} = metricsExtension{}
+
+func (metricsExtension) ExtensionName() string {
+	return "Metrics"
+}
+
+func (metricsExtension) Validate(schema graphql.ExecutableSchema) error {
+	return nil
+}
+
+func (metricsExtension) InterceptField(ctx context.Context, next graphql.Resolver) (interface{}, error) {
+	fc := graphql.GetFieldContext(ctx)
+
+	var operationType string
+	switch fc.Object {
+	case "Query":
+		operationType = "query"
+	case "Mutation":
+		operationType = "mutation"
+	default:
+		return next(ctx)
+	}
+
+	start := time.Now()
+	res, err := next(ctx)
+
+	metrics.OperationDuration.WithLabelValues(operationType, fc.Field.Name).Observe(time.Since(start).Seconds())
+	if err != nil {
+		metrics.OperationErrors.WithLabelValues(operationType, fc.Field.Name).Inc()
+	}
+
+	return res, err
+}
+
+// countsCollector collects the number of users and edits when scraped.
+type countsCollector struct {
+	rfp   RepoProvider
+	users *prometheus.Desc
+	edits *prometheus.Desc
+}
+
+func newCountsCollector(rfp RepoProvider) *countsCollector {
+	return &countsCollector{
+		rfp:   rfp,
+		users: prometheus.NewDesc("stashbox_users", "Number of users.", nil, nil),
+		edits: prometheus.NewDesc("stashbox_edits", "Number of edits of each status.", []string{"status"}, nil),
+	}
+}
+
+func (c *countsCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.users
+	ch <- c.edits
+}
+
+func (c *countsCollector) Collect(ch chan<- prometheus.Metric) {
+	var users int
+	var edits map[models.VoteStatusEnum]int
+	fac := c.rfp.Repo()
+	err := fac.WithTxn(func() error {
+		var err error
+		if users, err = fac.User().Count(); err != nil {
+			return err
+		}
+
+		edits, err = fac.Edit().CountByStatus()
+		return err
+	})
+
+	if err != nil {
+		logger.Errorf("Error collecting metrics: %s", err.Error())
+		ch <- prometheus.NewInvalidMetric(c.users, err)
+		ch <- prometheus.NewInvalidMetric(c.edits, err)
+		return
+	}
+
+	ch <- prometheus.MustNewConstMetric(c.users, prometheus.GaugeValue, float64(users))
+	for _, status := range models.AllVoteStatusEnum {
+		ch <- prometheus.MustNewConstMetric(c.edits, prometheus.GaugeValue, float64(edits[status]), status.String())
+	}
+}
+
+// metricsHandler serves the metrics to admin users, and to requests with the
+// configured bearer token.
+func metricsHandler() http.Handler {
+	handler := promhttp.Handler()
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !metricsAuthorised(r) {
+			http.Error(w, ErrUnauthorized.Error(), http.StatusUnauthorized)
+			return
+		}
+
+		handler.ServeHTTP(w, r)
+	})
+}
+
+func metricsAuthorised(r *http.Request) bool {
+	if token := config.GetMetricsToken(); token != "" {
+		authorization := r.Header.Get("Authorization")
+		if subtle.ConstantTimeCompare([]byte(authorization), []byte("Bearer "+token)) == 1 {
+			return true
+		}
+	}
+
+	return validateAdmin(r.Context()) == nil
+}
@@ -0,0 +1,72 @@
+//go:build integration
+// +build integration
+
+package api_test
+
+import (
+	"testing"
+
+	"github.com/stashapp/stash-box/pkg/database/databasetest"
+	"github.com/stashapp/stash-box/pkg/models"
+)
+
+type metricsTestRunner struct {
+	testRunner
+}
+
+func createMetricsTestRunner(t *testing.T) *metricsTestRunner {
+	return &metricsTestRunner{
+		testRunner: *asAdmin(t),
+	}
+}
+
+func (s *metricsTestRunner) countEditsByStatus() map[models.VoteStatusEnum]int {
+	s.t.Helper()
+
+	repo := databasetest.Repo()
+	var counts map[models.VoteStatusEnum]int
+	err := repo.WithTxn(func() error {
+		var err error
+		counts, err = repo.Edit().CountByStatus()
+		return err
+	})
+	if err != nil {
+		s.t.Fatalf("Error counting edits: %s", err.Error())
+	}
+
+	return counts
+}
+
+func (s *metricsTestRunner) testCountEditsByStatus() {
+	before := s.countEditsByStatus()
+
+	name := s.generateTagName()
+	createdEdit, err := s.createTestTagEdit(models.OperationEnumCreate, &models.TagEditDetailsInput{
+		Name: &name,
+	}, nil)
+	if err != nil {
+		return
+	}
+
+	pending := s.countEditsByStatus()
+	if pending[models.VoteStatusEnumPending] != before[models.VoteStatusEnumPending]+1 {
+		s.t.Errorf("pending edits: got %d want %d", pending[models.VoteStatusEnumPending], before[models.VoteStatusEnumPending]+1)
+	}
+
+	if _, err := s.applyEdit(createdEdit.ID.String()); err != nil {
+		return
+	}
+
+	applied := s.countEditsByStatus()
+	if applied[models.VoteStatusEnumPending] != before[models.VoteStatusEnumPending] {
+		s.t.Errorf("pending edits: got %d want %d", applied[models.VoteStatusEnumPending], before[models.VoteStatusEnumPending])
+	}
+	if applied[models.VoteStatusEnumImmediateAccepted] != before[models.VoteStatusEnumImmediateAccepted]+1 {
+		s.t.Errorf("immediately accepted edits: got %d want %d", applied[models.VoteStatusEnumImmediateAccepted], before[models.VoteStatusEnumImmediateAccepted]+1)
+	}
+}
+
+func TestCountEditsByStatus(t *testing.T) {
+	pt := createMetricsTestRunner(t)
+	pt.testCountEditsByStatus()
+}
@@ -0,0 +1,122 @@
+package api
+
+import (
+	"context"
+	"time"
+
+	"github.com/99designs/gqlgen/graphql"
+	gqlExtension "github.com/99designs/gqlgen/graphql/handler/extension"
+	"github.com/99designs/gqlgen/graphql/handler/lru"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+
+	"github.com/stashapp/stash-box/pkg/logger"
+	"github.com/stashapp/stash-box/pkg/models"
+)
+
+// maxPersistedQuerySize is the maximum length of queries stored in the
+// database.
+const maxPersistedQuerySize = 64 * 1024
+
+// persistedQueryCache stores the queries of automatic persisted queries in
+// an LRU cache. If store is set, queries are also stored in the database,
+// so that they are shared between instances and kept across restarts.
+//
+// Queries sent by clients are only cached once they have been validated,
+// so the cache is also used as an extension which runs after the query
+// limits.
+type persistedQueryCache struct {
+	lru       *lru.LRU
+	store     bool
+	storeSize int
+}
+
+var _ interface {
+	graphql.Cache
+	graphql.HandlerExtension
+	graphql.OperationContextMutator
+} = &persistedQueryCache{}
+
+func newPersistedQueryCache(size int, store bool, storeSize int) *persistedQueryCache {
+	return &persistedQueryCache{
+		lru:       lru.New(size),
+		store:     store,
+		storeSize: storeSize,
+	}
+}
+
+func (c *persistedQueryCache) Get(ctx context.Context, hash string) (interface{}, bool) {
+	if query, ok := c.lru.Get(ctx, hash); ok {
+		return query, true
+	}
+
+	if !c.store {
+		return nil, false
+	}
+
+	fac := getRepo(ctx)
+	var query *models.PersistedQuery
+	err := fac.WithTxn(func() error {
+		var err error
+		query, err = fac.PersistedQuery().Find(hash)
+		return err
+	})
+	if err != nil {
+		logger.Errorf("Error finding persisted query: %s", err.Error())
+		return nil, false
+	}
+	if query == nil {
+		return nil, false
+	}
+
+	c.lru.Add(ctx, hash, query.Query)
+	return query.Query, true
+}
+
+// Add is called with queries before they are parsed, so does nothing.
+// Queries are added by MutateOperationContext once they are validated.
+func (c *persistedQueryCache) Add(ctx context.Context, hash string, value interface{}) {}
+
+func (c *persistedQueryCache) ExtensionName() string {
+	return "PersistedQueryCache"
+}
+
+func (c *persistedQueryCache) Validate(schema graphql.ExecutableSchema) error {
+	return nil
+}
+
+// MutateOperationContext adds the query sent with a persisted query hash to
+// the cache, after the query has been validated. Queries are only stored in
+// the database for authenticated users.
+func (c *persistedQueryCache) MutateOperationContext(ctx context.Context, rc *graphql.OperationContext) *gqlerror.Error {
+	stats := gqlExtension.GetApqStats(ctx)
+	if stats == nil || !stats.SentQuery {
+		return nil
+	}
+
+	c.lru.Add(ctx, stats.Hash, rc.RawQuery)
+
+	if !c.store || len(rc.RawQuery) > maxPersistedQuerySize || getCurrentUser(ctx) == nil {
+		return nil
+	}
+
+	fac := getRepo(ctx)
+	now := models.SQLiteTimestamp{Timestamp: time.Now()}
+	err := fac.WithTxn(func() error {
+		qb := fac.PersistedQuery()
+		if err := qb.Create(models.PersistedQuery{
+			Hash:       stats.Hash,
+			Query:      rc.RawQuery,
+			CreatedAt:  now,
+			LastUsedAt: now,
+		}); err != nil {
+			return err
+		}
+
+		return qb.Prune(c.storeSize)
+	})
+	if err != nil {
+		logger.Errorf("Error storing persisted query: %s", err.Error())
+	}
+
+	return nil
+}
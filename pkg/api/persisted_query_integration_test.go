@@ -0,0 +1,148 @@
+//go:build integration
+// +build integration
+
+package api_test
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"testing"
+	"time"
+
+	"github.com/stashapp/stash-box/pkg/database/databasetest"
+	"github.com/stashapp/stash-box/pkg/models"
+)
+
+type persistedQueryTestRunner struct {
+	testRunner
+}
+
+func createPersistedQueryTestRunner(t *testing.T) *persistedQueryTestRunner {
+	return &persistedQueryTestRunner{
+		testRunner: *asRead(t),
+	}
+}
+
+func (s *persistedQueryTestRunner) testStorePersistedQuery() {
+	query := "query { version { version } } # " + s.generateTagName()
+	hash := sha256.Sum256([]byte(query))
+	key := hex.EncodeToString(hash[:])
+
+	repo := databasetest.Repo()
+	var found *models.PersistedQuery
+	err := repo.WithTxn(func() error {
+		qb := repo.PersistedQuery()
+		now := models.SQLiteTimestamp{Timestamp: time.Now()}
+		newQuery := models.PersistedQuery{
+			Hash:       key,
+			Query:      query,
+			CreatedAt:  now,
+			LastUsedAt: now,
+		}
+		if err := qb.Create(newQuery); err != nil {
+			return err
+		}
+
+		// storing an existing query is ignored
+		if err := qb.Create(newQuery); err != nil {
+			return err
+		}
+
+		var err error
+		found, err = qb.Find(key)
+		return err
+	})
+	if err != nil {
+		s.t.Errorf("Error storing persisted query: %s", err.Error())
+		return
+	}
+
+	if found == nil || found.Query != query {
+		s.t.Errorf("persisted query: got %v want %s", found, query)
+	}
+
+	err = repo.WithTxn(func() error {
+		var err error
+		found, err = repo.PersistedQuery().Find("unknown")
+		return err
+	})
+	if err != nil {
+		s.t.Errorf("Error finding persisted query: %s", err.Error())
+		return
+	}
+	if found != nil {
+		s.t.Errorf("unknown persisted query: got %v want nil", found)
+	}
+}
+
+func (s *persistedQueryTestRunner) testPrunePersistedQueries() {
+	repo := databasetest.Repo()
+	createQuery := func(lastUsed time.Time) string {
+		query := "query { version { version } } # " + s.generateTagName()
+		hash := sha256.Sum256([]byte(query))
+		key := hex.EncodeToString(hash[:])
+
+		err := repo.WithTxn(func() error {
+			return repo.PersistedQuery().Create(models.PersistedQuery{
+				Hash:       key,
+				Query:      query,
+				CreatedAt:  models.SQLiteTimestamp{Timestamp: lastUsed},
+				LastUsedAt: models.SQLiteTimestamp{Timestamp: lastUsed},
+			})
+		})
+		if err != nil {
+			s.t.Errorf("Error storing persisted query: %s", err.Error())
+		}
+		return key
+	}
+
+	old := createQuery(time.Now().Add(-2 * time.Hour))
+	used := createQuery(time.Now().Add(-time.Hour))
+	recent := createQuery(time.Now())
+
+	// finding a query marks it as used
+	var found *models.PersistedQuery
+	err := repo.WithTxn(func() error {
+		var err error
+		found, err = repo.PersistedQuery().Find(used)
+		if err != nil {
+			return err
+		}
+		return repo.PersistedQuery().Prune(2)
+	})
+	if err != nil {
+		s.t.Errorf("Error pruning persisted queries: %s", err.Error())
+		return
+	}
+	if found == nil || !found.LastUsedAt.Timestamp.After(found.CreatedAt.Timestamp) {
+		s.t.Errorf("found query was not marked as used: %v", found)
+	}
+
+	for _, tt := range []struct {
+		hash string
+		kept bool
+	}{{old, false}, {used, true}, {recent, true}} {
+		err := repo.WithTxn(func() error {
+			var err error
+			found, err = repo.PersistedQuery().Find(tt.hash)
+			return err
+		})
+		if err != nil {
+			s.t.Errorf("Error finding persisted query: %s", err.Error())
+			return
+		}
+		if (found != nil) != tt.kept {
+			s.t.Errorf("query %s: got kept %v want %v", tt.hash, found != nil, tt.kept)
+		}
+	}
+}
+
+func TestStorePersistedQuery(t *testing.T) {
+	pt := createPersistedQueryTestRunner(t)
+	pt.testStorePersistedQuery()
+}
+
+func TestPrunePersistedQueries(t *testing.T) {
+	pt := createPersistedQueryTestRunner(t)
+	pt.testPrunePersistedQueries()
+}
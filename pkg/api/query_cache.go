@@ -0,0 +1,179 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync/atomic"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/vektah/gqlparser/v2/ast"
+
+	"github.com/stashapp/stash-box/pkg/logger"
+)
+
+type queryCacheKey struct{}
+
+// cacheableQueries are the root fields returning public data which does not
+// depend on the user making the request.
+var cacheableQueries = map[string]bool{
+	"version":                      true,
+	"findPerformer":                true,
+	"queryPerformers":              true,
+	"findStudio":                   true,
+	"queryStudios":                 true,
+	"findTag":                      true,
+	"queryTags":                    true,
+	"findTagCategory":              true,
+	"queryTagCategories":           true,
+	"findScene":                    true,
+	"findSceneByFingerprint":       true,
+	"findScenesByFingerprints":     true,
+	"findScenesByFullFingerprints": true,
+	"findFingerprintMatches":       true,
+	"queryScenes":                  true,
+	"searchPerformer":              true,
+	"searchScene":                  true,
+}
+
+// privateFields are fields of cacheable queries which depend on the user
+// making the request.
+var privateFields = map[string]map[string]bool{
+	"Fingerprint": {"user_submitted": true},
+}
+
+// queryCacheState records whether the response to a request can be cached.
+type queryCacheState struct {
+	cacheable bool
+	// private is set atomically, as fields are resolved concurrently
+	private int32
+}
+
+// queryCacheExtension marks responses to queries which completed without
+// errors as cacheable, if they only select public fields.
+type queryCacheExtension struct{}
+
+var _ interface {
+	graphql.HandlerExtension
+	graphql.FieldInterceptor
+	graphql.ResponseInterceptor
+} = queryCacheExtension{}
+
+func (queryCacheExtension) ExtensionName() string {
+	return "QueryCache"
+}
+
+func (queryCacheExtension) Validate(schema graphql.ExecutableSchema) error {
+	return nil
+}
+
+func (queryCacheExtension) InterceptField(ctx context.Context, next graphql.Resolver) (interface{}, error) {
+	if state, _ := ctx.Value(queryCacheKey{}).(*queryCacheState); state != nil && isPrivateField(graphql.GetFieldContext(ctx)) {
+		atomic.StoreInt32(&state.private, 1)
+	}
+
+	return next(ctx)
+}
+
+// isPrivateField returns true if the field is not a cacheable query, or
+// exposes data of or for a user.
+func isPrivateField(fc *graphql.FieldContext) bool {
+	if fc == nil || strings.HasPrefix(fc.Field.Name, "__") {
+		return false
+	}
+
+	switch fc.Object {
+	case "Query":
+		return !cacheableQueries[fc.Field.Name]
+	case "User":
+		return true
+	}
+
+	return privateFields[fc.Object][fc.Field.Name]
+}
+
+func (queryCacheExtension) InterceptResponse(ctx context.Context, next graphql.ResponseHandler) *graphql.Response {
+	resp := next(ctx)
+
+	state, _ := ctx.Value(queryCacheKey{}).(*queryCacheState)
+	rc := graphql.GetOperationContext(ctx)
+	if state != nil && resp != nil && len(resp.Errors) == 0 && rc.Operation != nil && rc.Operation.Operation == ast.Query && atomic.LoadInt32(&state.private) == 0 {
+		state.cacheable = true
+	}
+
+	return resp
+}
+
+// bufferedResponseWriter holds the response so that headers can be added
+// once the response is complete.
+type bufferedResponseWriter struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+func (w *bufferedResponseWriter) WriteHeader(status int) {
+	w.status = status
+}
+
+func (w *bufferedResponseWriter) Write(b []byte) (int, error) {
+	return w.body.Write(b)
+}
+
+// queryCacheMiddleware adds Cache-Control and ETag headers to the responses
+// of GET requests for public queries which completed without errors, and
+// responds with 304 Not Modified if the request has a matching If-None-Match
+// header. Responses vary by the credentials of the request, and other GET
+// responses are not stored. Caching is disabled if maxAge is not positive.
+func queryCacheMiddleware(maxAge int) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if maxAge <= 0 || r.Method != http.MethodGet || r.Header.Get("Upgrade") != "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			state := &queryCacheState{}
+			buf := &bufferedResponseWriter{ResponseWriter: w, status: http.StatusOK}
+			next.ServeHTTP(buf, r.WithContext(context.WithValue(r.Context(), queryCacheKey{}, state)))
+
+			header := w.Header()
+			if state.cacheable && buf.status == http.StatusOK {
+				hash := sha256.Sum256(buf.body.Bytes())
+				etag := `"` + hex.EncodeToString(hash[:]) + `"`
+
+				header.Set("Cache-Control", "public, max-age="+strconv.Itoa(maxAge))
+				header.Set("ETag", etag)
+				header.Add("Vary", APIKeyHeader+", Cookie")
+
+				if etagMatches(r.Header.Get("If-None-Match"), etag) {
+					w.WriteHeader(http.StatusNotModified)
+					return
+				}
+			} else {
+				header.Set("Cache-Control", "no-store")
+			}
+
+			w.WriteHeader(buf.status)
+			if _, err := w.Write(buf.body.Bytes()); err != nil {
+				logger.Errorf("Error writing response: %s", err.Error())
+			}
+		})
+	}
+}
+
+// etagMatches returns true if the If-None-Match header includes the ETag.
+func etagMatches(ifNoneMatch string, etag string) bool {
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == etag || candidate == "*" {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,238 @@
+package api
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/99designs/gqlgen/graphql"
+	gqlHandler "github.com/99designs/gqlgen/graphql/handler"
+	gqlExtension "github.com/99designs/gqlgen/graphql/handler/extension"
+	gqlTransport "github.com/99designs/gqlgen/graphql/handler/transport"
+	"github.com/vektah/gqlparser/v2/ast"
+
+	"github.com/stashapp/stash-box/pkg/models"
+)
+
+const versionQuery = `query { version { version } }`
+
+func newQueryCacheTestHandler(maxAge int) http.Handler {
+	srv := gqlHandler.New(models.NewExecutableSchema(models.Config{Resolvers: NewResolver(getRepo)}))
+	srv.AddTransport(gqlTransport.GET{})
+	cache := newPersistedQueryCache(10, false, 0)
+	srv.Use(gqlExtension.AutomaticPersistedQuery{Cache: cache})
+	srv.Use(cache)
+	srv.Use(queryCacheExtension{})
+
+	return queryCacheMiddleware(maxAge)(srv)
+}
+
+func getQuery(handler http.Handler, params url.Values, header http.Header) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(http.MethodGet, "/graphql?"+params.Encode(), nil)
+	for k, v := range header {
+		r.Header[k] = v
+	}
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	return w
+}
+
+func TestQueryCacheHeaders(t *testing.T) {
+	handler := newQueryCacheTestHandler(60)
+	params := url.Values{"query": {versionQuery}}
+
+	w := getQuery(handler, params, nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("status: got %d want %d", w.Code, http.StatusOK)
+	}
+	if got := w.Header().Get("Cache-Control"); got != "public, max-age=60" {
+		t.Errorf("Cache-Control: got %q want %q", got, "public, max-age=60")
+	}
+	etag := w.Header().Get("ETag")
+	if etag == "" {
+		t.Fatal("ETag not set")
+	}
+
+	w = getQuery(handler, params, http.Header{"If-None-Match": {etag}})
+	if w.Code != http.StatusNotModified {
+		t.Errorf("matching ETag status: got %d want %d", w.Code, http.StatusNotModified)
+	}
+	if w.Body.Len() != 0 {
+		t.Errorf("matching ETag body: got %q want empty", w.Body.String())
+	}
+
+	w = getQuery(handler, url.Values{"query": {`query { version { invalid } }`}}, nil)
+	if got := w.Header().Get("Cache-Control"); got != "no-store" {
+		t.Errorf("error Cache-Control: got %q want no-store", got)
+	}
+	if got := w.Header().Get("ETag"); got != "" {
+		t.Errorf("error ETag: got %q want empty", got)
+	}
+
+	w = getQuery(newQueryCacheTestHandler(0), params, nil)
+	if got := w.Header().Get("Cache-Control"); got != "" {
+		t.Errorf("disabled Cache-Control: got %q want empty", got)
+	}
+}
+
+func TestQueryCachePrivateQuery(t *testing.T) {
+	handler := newQueryCacheTestHandler(60)
+	user := &models.User{Name: "user", APIKey: "key"}
+	withUser := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		handler.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), ContextUser, user)))
+	})
+
+	queries := []string{
+		`query { me { name api_key } }`,
+		`query { version { version } me { name } }`,
+	}
+	for _, query := range queries {
+		w := getQuery(withUser, url.Values{"query": {query}}, nil)
+		if w.Code != http.StatusOK {
+			t.Fatalf("%s status: got %d want %d", query, w.Code, http.StatusOK)
+		}
+		if got := w.Header().Get("Cache-Control"); got != "no-store" {
+			t.Errorf("%s Cache-Control: got %q want no-store", query, got)
+		}
+		if got := w.Header().Get("ETag"); got != "" {
+			t.Errorf("%s ETag: got %q want empty", query, got)
+		}
+	}
+}
+
+func TestIsPrivateField(t *testing.T) {
+	tests := []struct {
+		object string
+		field  string
+		want   bool
+	}{
+		{"Query", "findScenesByFullFingerprints", false},
+		{"Query", "me", true},
+		{"Query", "queryUsers", true},
+		{"Query", "webhooks", true},
+		{"Query", "__schema", false},
+		{"Scene", "title", false},
+		{"Fingerprint", "hash", false},
+		{"Fingerprint", "user_submitted", true},
+		{"User", "name", true},
+	}
+
+	for _, tt := range tests {
+		fc := &graphql.FieldContext{
+			Object: tt.object,
+			Field:  graphql.CollectedField{Field: &ast.Field{Name: tt.field}},
+		}
+		if got := isPrivateField(fc); got != tt.want {
+			t.Errorf("isPrivateField(%s.%s) = %v, want %v", tt.object, tt.field, got, tt.want)
+		}
+	}
+}
+
+func TestAutomaticPersistedQuery(t *testing.T) {
+	handler := newQueryCacheTestHandler(60)
+
+	hash := sha256.Sum256([]byte(versionQuery))
+	extensions, err := json.Marshal(map[string]interface{}{
+		"persistedQuery": map[string]interface{}{
+			"version":    1,
+			"sha256Hash": hex.EncodeToString(hash[:]),
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	hashOnly := url.Values{"extensions": {string(extensions)}}
+
+	var resp struct {
+		Data   map[string]interface{} `json:"data"`
+		Errors []struct {
+			Message string `json:"message"`
+		} `json:"errors"`
+	}
+
+	w := getQuery(handler, hashOnly, nil)
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.Errors) != 1 || resp.Errors[0].Message != "PersistedQueryNotFound" {
+		t.Errorf("unknown hash: got %s want PersistedQueryNotFound", w.Body.String())
+	}
+	if got := w.Header().Get("Cache-Control"); got != "no-store" {
+		t.Errorf("unknown hash Cache-Control: got %q want no-store", got)
+	}
+
+	w = getQuery(handler, url.Values{"query": {versionQuery}, "extensions": {string(extensions)}}, nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("registering query: got status %d", w.Code)
+	}
+
+	resp.Errors = nil
+	w = getQuery(handler, hashOnly, nil)
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.Errors) != 0 || resp.Data["version"] == nil {
+		t.Errorf("known hash: got %s", w.Body.String())
+	}
+	if w.Header().Get("ETag") == "" {
+		t.Error("known hash: ETag not set")
+	}
+}
+
+func TestAutomaticPersistedQueryInvalid(t *testing.T) {
+	handler := newQueryCacheTestHandler(60)
+
+	query := `query { version { invalid } }`
+	hash := sha256.Sum256([]byte(query))
+	extensions, err := json.Marshal(map[string]interface{}{
+		"persistedQuery": map[string]interface{}{
+			"version":    1,
+			"sha256Hash": hex.EncodeToString(hash[:]),
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// invalid queries are not cached
+	getQuery(handler, url.Values{"query": {query}, "extensions": {string(extensions)}}, nil)
+
+	var resp struct {
+		Errors []struct {
+			Message string `json:"message"`
+		} `json:"errors"`
+	}
+	w := getQuery(handler, url.Values{"extensions": {string(extensions)}}, nil)
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.Errors) != 1 || resp.Errors[0].Message != "PersistedQueryNotFound" {
+		t.Errorf("invalid query hash: got %s want PersistedQueryNotFound", w.Body.String())
+	}
+}
+
+func TestETagMatches(t *testing.T) {
+	tests := []struct {
+		ifNoneMatch string
+		want        bool
+	}{
+		{`"abc"`, true},
+		{`W/"abc"`, true},
+		{`"def", "abc"`, true},
+		{`*`, true},
+		{`"def"`, false},
+		{``, false},
+	}
+
+	for _, tt := range tests {
+		if got := etagMatches(tt.ifNoneMatch, `"abc"`); got != tt.want {
+			t.Errorf("etagMatches(%q) = %v, want %v", tt.ifNoneMatch, got, tt.want)
+		}
+	}
+}
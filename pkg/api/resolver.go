@@ -22,12 +22,21 @@ func NewResolver(repoFunc func(ctx context.Context) models.Repo) *Resolver {
 func (r *Resolver) Mutation() models.MutationResolver {
 	return &mutationResolver{r}
 }
+func (r *Resolver) AuditLog() models.AuditLogResolver {
+	return &auditLogResolver{r}
+}
 func (r *Resolver) Edit() models.EditResolver {
 	return &editResolver{r}
 }
 func (r *Resolver) EditComment() models.EditCommentResolver {
 	return &editCommentResolver{r}
 }
+func (r *Resolver) EditAmendment() models.EditAmendmentResolver {
+	return &editAmendmentResolver{r}
+}
+func (r *Resolver) VoteComment() models.VoteCommentResolver {
+	return &voteCommentResolver{r}
+}
 func (r *Resolver) Performer() models.PerformerResolver {
 	return &performerResolver{r}
 }
@@ -37,6 +46,9 @@ func (r *Resolver) PerformerEdit() models.PerformerEditResolver {
 func (r *Resolver) StudioEdit() models.StudioEditResolver {
 	return &studioEditResolver{r}
 }
+func (r *Resolver) SceneEdit() models.SceneEditResolver {
+	return &sceneEditResolver{r}
+}
 func (r *Resolver) Tag() models.TagResolver {
 	return &tagResolver{r}
 }
@@ -55,9 +67,18 @@ func (r *Resolver) Scene() models.SceneResolver {
 func (r *Resolver) User() models.UserResolver {
 	return &userResolver{r}
 }
+func (r *Resolver) LogItem() models.LogItemResolver {
+	return &logItemResolver{r}
+}
+func (r *Resolver) Webhook() models.WebhookResolver {
+	return &webhookResolver{r}
+}
 func (r *Resolver) Query() models.QueryResolver {
 	return &queryResolver{r}
 }
+func (r *Resolver) Subscription() models.SubscriptionResolver {
+	return &subscriptionResolver{r}
+}
 
 type mutationResolver struct{ *Resolver }
 
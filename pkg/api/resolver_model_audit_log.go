@@ -0,0 +1,67 @@
+package api
+
+import (
+	"context"
+	"time"
+
+	"github.com/stashapp/stash-box/pkg/models"
+	"github.com/stashapp/stash-box/pkg/utils"
+)
+
+type auditLogResolver struct{ *Resolver }
+
+func (r *auditLogResolver) ID(ctx context.Context, obj *models.AuditLog) (string, error) {
+	return obj.ID.String(), nil
+}
+
+func (r *auditLogResolver) User(ctx context.Context, obj *models.AuditLog) (*models.User, error) {
+	if !obj.UserID.Valid {
+		return nil, nil
+	}
+
+	fac := r.getRepoFactory(ctx)
+	return fac.User().Find(obj.UserID.UUID)
+}
+
+func (r *auditLogResolver) Action(ctx context.Context, obj *models.AuditLog) (models.AuditActionEnum, error) {
+	var ret models.AuditActionEnum
+	if !utils.ResolveEnumString(obj.Action, &ret) {
+		return "", nil
+	}
+
+	return ret, nil
+}
+
+func (r *auditLogResolver) TargetType(ctx context.Context, obj *models.AuditLog) (models.AuditTargetTypeEnum, error) {
+	var ret models.AuditTargetTypeEnum
+	if !utils.ResolveEnumString(obj.TargetType, &ret) {
+		return "", nil
+	}
+
+	return ret, nil
+}
+
+func (r *auditLogResolver) TargetID(ctx context.Context, obj *models.AuditLog) (string, error) {
+	return obj.TargetID.String(), nil
+}
+
+func (r *auditLogResolver) OldData(ctx context.Context, obj *models.AuditLog) (*string, error) {
+	return resolveAuditData(obj.OldData), nil
+}
+
+func (r *auditLogResolver) NewData(ctx context.Context, obj *models.AuditLog) (*string, error) {
+	return resolveAuditData(obj.NewData), nil
+}
+
+func (r *auditLogResolver) Created(ctx context.Context, obj *models.AuditLog) (*time.Time, error) {
+	return &obj.CreatedAt.Timestamp, nil
+}
+
+func resolveAuditData(data []byte) *string {
+	if len(data) == 0 || string(data) == "null" {
+		return nil
+	}
+
+	ret := string(data)
+	return &ret
+}
@@ -0,0 +1,41 @@
+package api
+
+import (
+	"context"
+	"time"
+
+	"github.com/stashapp/stash-box/pkg/models"
+)
+
+type editAmendmentResolver struct{ *Resolver }
+
+func (r *editAmendmentResolver) User(ctx context.Context, obj *models.EditAmendment) (*models.User, error) {
+	fac := r.getRepoFactory(ctx)
+	qb := fac.User()
+	user, err := qb.Find(obj.UserID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return user, nil
+}
+
+func (r *editAmendmentResolver) Date(ctx context.Context, obj *models.EditAmendment) (*time.Time, error) {
+	return &obj.CreatedAt.Timestamp, nil
+}
+
+func (r *editAmendmentResolver) Details(ctx context.Context, obj *models.EditAmendment) (models.EditDetails, error) {
+	fac := r.getRepoFactory(ctx)
+	edit, err := fac.Edit().Find(obj.EditID)
+	if err != nil {
+		return nil, err
+	}
+
+	// resolve the previous details using the target type of the edit
+	previous := models.Edit{
+		TargetType: edit.TargetType,
+		Data:       obj.Data,
+	}
+	return r.Edit().Details(ctx, &previous)
+}
@@ -19,6 +19,13 @@ func (r *imageResolver) URL(ctx context.Context, obj *models.Image) (string, err
 		builder := urlbuilders.NewImageURLBuilder(baseURL, obj.Checksum)
 		return builder.GetImageURL(), nil
 	} else if config.GetImageBackend() == config.S3Backend {
+		// without a public base URL, images are served by the image route
+		if config.GetS3Config().BaseURL == "" {
+			baseURL, _ := ctx.Value(BaseURLCtxKey).(string)
+			builder := urlbuilders.NewImageURLBuilder(baseURL, obj.ID.String())
+			return builder.GetImageURL(), nil
+		}
+
 		builder := urlbuilders.NewS3ImageURLBuilder(obj)
 		return builder.GetImageURL(), nil
 	}
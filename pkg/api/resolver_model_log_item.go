@@ -0,0 +1,57 @@
+package api
+
+import (
+	"context"
+
+	"github.com/stashapp/stash-box/pkg/logger"
+	"github.com/stashapp/stash-box/pkg/models"
+)
+
+type logItemResolver struct{ *Resolver }
+
+func (r *logItemResolver) Level(ctx context.Context, obj *logger.LogItem) (models.LogLevelEnum, error) {
+	return logItemLevel(obj), nil
+}
+
+// logLevels orders the log levels by severity.
+var logLevels = map[models.LogLevelEnum]int{
+	models.LogLevelEnumDebug:    0,
+	models.LogLevelEnumInfo:     1,
+	models.LogLevelEnumProgress: 2,
+	models.LogLevelEnumWarning:  3,
+	models.LogLevelEnumError:    4,
+}
+
+func logItemLevel(item *logger.LogItem) models.LogLevelEnum {
+	switch item.Type {
+	case "debug":
+		return models.LogLevelEnumDebug
+	case "progress":
+		return models.LogLevelEnumProgress
+	case "warn":
+		return models.LogLevelEnumWarning
+	case "error":
+		return models.LogLevelEnumError
+	default:
+		return models.LogLevelEnumInfo
+	}
+}
+
+// filterLogItems returns the log items at or above the minimum level, which
+// defaults to info.
+func filterLogItems(items []logger.LogItem, minLevel *models.LogLevelEnum) []*logger.LogItem {
+	min := logLevels[models.LogLevelEnumInfo]
+	if minLevel != nil && minLevel.IsValid() {
+		min = logLevels[*minLevel]
+	}
+
+	var ret []*logger.LogItem
+	for i := range items {
+		item := items[i]
+		if logLevels[logItemLevel(&item)] >= min {
+			ret = append(ret, &item)
+		}
+	}
+
+	return ret
+}
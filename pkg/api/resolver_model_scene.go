@@ -102,7 +102,39 @@ func (r *sceneResolver) Performers(ctx context.Context, obj *models.Scene) ([]*m
 	return ret, nil
 }
 func (r *sceneResolver) Fingerprints(ctx context.Context, obj *models.Scene) ([]*models.Fingerprint, error) {
-	return dataloader.For(ctx).SceneFingerprintsByID.Load(obj.ID)
+	fingerprints, err := dataloader.For(ctx).SceneFingerprintsByID.Load(obj.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	currentUser := getCurrentUser(ctx)
+	if currentUser == nil {
+		return fingerprints, nil
+	}
+
+	hashes, err := dataloader.For(ctx).SceneSubmittedHashesByID.Load(obj.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	submitted := make(map[string]bool)
+	for _, hash := range hashes {
+		submitted[hash] = true
+	}
+
+	// copy the fingerprints, since the dataloader results are cached
+	var ret []*models.Fingerprint
+	for _, fingerprint := range fingerprints {
+		f := *fingerprint
+		f.UserSubmitted = submitted[f.Hash]
+		ret = append(ret, &f)
+	}
+
+	return ret, nil
+}
+
+func (r *sceneResolver) MedianDuration(ctx context.Context, obj *models.Scene) (*int, error) {
+	return dataloader.For(ctx).SceneMedianDurationByID.Load(obj.ID)
 }
 
 func (r *sceneResolver) Urls(ctx context.Context, obj *models.Scene) ([]*models.URL, error) {
@@ -0,0 +1,100 @@
+package api
+
+import (
+	"context"
+
+	"github.com/gofrs/uuid"
+	"github.com/stashapp/stash-box/pkg/dataloader"
+	"github.com/stashapp/stash-box/pkg/models"
+)
+
+type sceneEditResolver struct{ *Resolver }
+
+func (r *sceneEditResolver) resolveAppearances(ctx context.Context, appearances []*models.PerformerAppearanceInput) ([]*models.PerformerAppearance, error) {
+	if len(appearances) == 0 {
+		return nil, nil
+	}
+
+	var uuids []uuid.UUID
+	for _, appearance := range appearances {
+		performerID, _ := uuid.FromString(appearance.PerformerID)
+		uuids = append(uuids, performerID)
+	}
+	performers, errors := dataloader.For(ctx).PerformerByID.LoadAll(uuids)
+	for _, err := range errors {
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var ret []*models.PerformerAppearance
+	for i, performer := range performers {
+		ret = append(ret, &models.PerformerAppearance{
+			Performer: performer,
+			As:        appearances[i].As,
+		})
+	}
+	return ret, nil
+}
+
+func (r *sceneEditResolver) resolveTags(ctx context.Context, ids []string) ([]*models.Tag, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+
+	var uuids []uuid.UUID
+	for _, id := range ids {
+		tagID, _ := uuid.FromString(id)
+		uuids = append(uuids, tagID)
+	}
+	tags, errors := dataloader.For(ctx).TagByID.LoadAll(uuids)
+	for _, err := range errors {
+		if err != nil {
+			return nil, err
+		}
+	}
+	return tags, nil
+}
+
+func (r *sceneEditResolver) resolveImages(ctx context.Context, ids []string) ([]*models.Image, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+
+	var uuids []uuid.UUID
+	for _, id := range ids {
+		imageID, _ := uuid.FromString(id)
+		uuids = append(uuids, imageID)
+	}
+	images, errors := dataloader.For(ctx).ImageByID.LoadAll(uuids)
+	for _, err := range errors {
+		if err != nil {
+			return nil, err
+		}
+	}
+	return images, nil
+}
+
+func (r *sceneEditResolver) AddedPerformers(ctx context.Context, obj *models.SceneEdit) ([]*models.PerformerAppearance, error) {
+	return r.resolveAppearances(ctx, obj.AddedPerformers)
+}
+
+func (r *sceneEditResolver) RemovedPerformers(ctx context.Context, obj *models.SceneEdit) ([]*models.PerformerAppearance, error) {
+	return r.resolveAppearances(ctx, obj.RemovedPerformers)
+}
+
+func (r *sceneEditResolver) AddedTags(ctx context.Context, obj *models.SceneEdit) ([]*models.Tag, error) {
+	return r.resolveTags(ctx, obj.AddedTags)
+}
+
+func (r *sceneEditResolver) RemovedTags(ctx context.Context, obj *models.SceneEdit) ([]*models.Tag, error) {
+	return r.resolveTags(ctx, obj.RemovedTags)
+}
+
+func (r *sceneEditResolver) AddedImages(ctx context.Context, obj *models.SceneEdit) ([]*models.Image, error) {
+	return r.resolveImages(ctx, obj.AddedImages)
+}
+
+func (r *sceneEditResolver) RemovedImages(ctx context.Context, obj *models.SceneEdit) ([]*models.Image, error) {
+	return r.resolveImages(ctx, obj.RemovedImages)
+}
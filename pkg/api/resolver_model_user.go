@@ -3,6 +3,7 @@ package api
 import (
 	"context"
 
+	"github.com/stashapp/stash-box/pkg/dataloader"
 	"github.com/stashapp/stash-box/pkg/manager/config"
 	"github.com/stashapp/stash-box/pkg/models"
 )
@@ -25,23 +26,35 @@ func (r *userResolver) Roles(ctx context.Context, obj *models.User) ([]models.Ro
 }
 
 func (r *userResolver) SuccessfulEdits(ctx context.Context, obj *models.User) (int, error) {
-	// TODO
-	return 0, nil
+	counts, err := dataloader.For(ctx).UserEditCountByID.Load(obj.ID)
+	if err != nil {
+		return 0, err
+	}
+	return counts.SuccessfulEdits, nil
 }
 
 func (r *userResolver) UnsuccessfulEdits(ctx context.Context, obj *models.User) (int, error) {
-	// TODO
-	return 0, nil
+	counts, err := dataloader.For(ctx).UserEditCountByID.Load(obj.ID)
+	if err != nil {
+		return 0, err
+	}
+	return counts.UnsuccessfulEdits, nil
 }
 
 func (r *userResolver) SuccessfulVotes(ctx context.Context, obj *models.User) (int, error) {
-	// TODO
-	return 0, nil
+	counts, err := dataloader.For(ctx).UserEditCountByID.Load(obj.ID)
+	if err != nil {
+		return 0, err
+	}
+	return counts.SuccessfulVotes, nil
 }
 
 func (r *userResolver) UnsuccessfulVotes(ctx context.Context, obj *models.User) (int, error) {
-	// TODO
-	return 0, nil
+	counts, err := dataloader.For(ctx).UserEditCountByID.Load(obj.ID)
+	if err != nil {
+		return 0, err
+	}
+	return counts.UnsuccessfulVotes, nil
 }
 
 func (r *userResolver) InvitedBy(ctx context.Context, obj *models.User) (*models.User, error) {
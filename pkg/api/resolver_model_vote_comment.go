@@ -0,0 +1,41 @@
+package api
+
+import (
+	"context"
+	"time"
+
+	"github.com/stashapp/stash-box/pkg/models"
+	"github.com/stashapp/stash-box/pkg/utils"
+)
+
+type voteCommentResolver struct{ *Resolver }
+
+func (r *voteCommentResolver) User(ctx context.Context, obj *models.VoteComment) (*models.User, error) {
+	fac := r.getRepoFactory(ctx)
+	qb := fac.User()
+	user, err := qb.Find(obj.UserID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return user, nil
+}
+
+func (r *voteCommentResolver) Date(ctx context.Context, obj *models.VoteComment) (*string, error) {
+	date := obj.Date.Timestamp.Format(time.RFC3339)
+	return &date, nil
+}
+
+func (r *voteCommentResolver) Comment(ctx context.Context, obj *models.VoteComment) (*string, error) {
+	return resolveNullString(obj.Comment), nil
+}
+
+func (r *voteCommentResolver) Type(ctx context.Context, obj *models.VoteComment) (*models.VoteTypeEnum, error) {
+	var ret models.VoteTypeEnum
+	if !utils.ResolveEnumString(obj.Type, &ret) {
+		return nil, nil
+	}
+
+	return &ret, nil
+}
@@ -0,0 +1,35 @@
+package api
+
+import (
+	"context"
+	"time"
+
+	"github.com/stashapp/stash-box/pkg/models"
+	"github.com/stashapp/stash-box/pkg/utils"
+)
+
+type webhookResolver struct{ *Resolver }
+
+func (r *webhookResolver) ID(ctx context.Context, obj *models.Webhook) (string, error) {
+	return obj.ID.String(), nil
+}
+
+func (r *webhookResolver) Events(ctx context.Context, obj *models.Webhook) ([]models.WebhookEventEnum, error) {
+	ret := []models.WebhookEventEnum{}
+	for _, event := range obj.Events {
+		var resolved models.WebhookEventEnum
+		if utils.ResolveEnumString(event, &resolved) {
+			ret = append(ret, resolved)
+		}
+	}
+
+	return ret, nil
+}
+
+func (r *webhookResolver) Created(ctx context.Context, obj *models.Webhook) (*time.Time, error) {
+	return &obj.CreatedAt.Timestamp, nil
+}
+
+func (r *webhookResolver) Updated(ctx context.Context, obj *models.Webhook) (*time.Time, error) {
+	return &obj.UpdatedAt.Timestamp, nil
+}
@@ -9,17 +9,102 @@ import (
 	"github.com/stashapp/stash-box/pkg/manager/edit"
 	"github.com/stashapp/stash-box/pkg/models"
 	"github.com/stashapp/stash-box/pkg/utils"
+	"github.com/stashapp/stash-box/pkg/webhook"
 )
 
 func (r *mutationResolver) SceneEdit(ctx context.Context, input models.SceneEditInput) (*models.Edit, error) {
-	panic("not implemented")
+	if err := validateEdit(ctx); err != nil {
+		return nil, err
+	}
+
+	if input.Edit.EditID != nil {
+		return r.amendSceneEdit(ctx, input)
+	}
+
+	UUID, err := uuid.NewV4()
+	if err != nil {
+		return nil, err
+	}
+
+	// create the edit
+	currentUser := getCurrentUser(ctx)
+
+	newEdit := models.NewEdit(UUID, currentUser, models.TargetTypeEnumScene, input.Edit)
+
+	fac := r.getRepoFactory(ctx)
+
+	err = fac.WithTxn(func() error {
+		p := edit.Scene(fac, newEdit)
+		if err := p.Edit(input, wasFieldIncludedFunc(ctx)); err != nil {
+			return err
+		}
+
+		_, err := p.CreateEdit()
+		if err != nil {
+			return err
+		}
+
+		if err := p.CreateJoin(input); err != nil {
+			return err
+		}
+
+		if err := p.CreateComment(currentUser, input.Edit.Comment); err != nil {
+			return err
+		}
+
+		return webhook.Enqueue(fac, models.WebhookEventEnumEditCreated, newEdit)
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return newEdit, nil
 }
+
+func (r *mutationResolver) amendSceneEdit(ctx context.Context, input models.SceneEditInput) (*models.Edit, error) {
+	fac := r.getRepoFactory(ctx)
+	currentUser := getCurrentUser(ctx)
+
+	var updatedEdit *models.Edit
+	err := fac.WithTxn(func() error {
+		existing, err := findAmendableEdit(ctx, fac, models.TargetTypeEnumScene, input.Edit)
+		if err != nil {
+			return err
+		}
+
+		p := edit.Scene(fac, existing)
+		if err := p.Amend(currentUser); err != nil {
+			return err
+		}
+
+		if err := p.Edit(input, wasFieldIncludedFunc(ctx)); err != nil {
+			return err
+		}
+
+		updatedEdit, err = p.UpdateEdit()
+		if err != nil {
+			return err
+		}
+
+		return p.CreateComment(currentUser, input.Edit.Comment)
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return updatedEdit, nil
+}
+
 func (r *mutationResolver) StudioEdit(ctx context.Context, input models.StudioEditInput) (*models.Edit, error) {
 	if err := validateEdit(ctx); err != nil {
 		return nil, err
 	}
 
-	// TODO - handle modification of existing edit
+	if input.Edit.EditID != nil {
+		return r.amendStudioEdit(ctx, input)
+	}
 
 	UUID, err := uuid.NewV4()
 	if err != nil {
@@ -52,7 +137,7 @@ func (r *mutationResolver) StudioEdit(ctx context.Context, input models.StudioEd
 			return err
 		}
 
-		return nil
+		return webhook.Enqueue(fac, models.WebhookEventEnumEditCreated, newEdit)
 	})
 
 	if err != nil {
@@ -62,12 +147,49 @@ func (r *mutationResolver) StudioEdit(ctx context.Context, input models.StudioEd
 	return newEdit, nil
 }
 
+func (r *mutationResolver) amendStudioEdit(ctx context.Context, input models.StudioEditInput) (*models.Edit, error) {
+	fac := r.getRepoFactory(ctx)
+	currentUser := getCurrentUser(ctx)
+
+	var updatedEdit *models.Edit
+	err := fac.WithTxn(func() error {
+		existing, err := findAmendableEdit(ctx, fac, models.TargetTypeEnumStudio, input.Edit)
+		if err != nil {
+			return err
+		}
+
+		p := edit.Studio(fac, existing)
+		if err := p.Amend(currentUser); err != nil {
+			return err
+		}
+
+		if err := p.Edit(input, wasFieldIncludedFunc(ctx)); err != nil {
+			return err
+		}
+
+		updatedEdit, err = p.UpdateEdit()
+		if err != nil {
+			return err
+		}
+
+		return p.CreateComment(currentUser, input.Edit.Comment)
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return updatedEdit, nil
+}
+
 func (r *mutationResolver) TagEdit(ctx context.Context, input models.TagEditInput) (*models.Edit, error) {
 	if err := validateEdit(ctx); err != nil {
 		return nil, err
 	}
 
-	// TODO - handle modification of existing edit
+	if input.Edit.EditID != nil {
+		return r.amendTagEdit(ctx, input)
+	}
 
 	UUID, err := uuid.NewV4()
 	if err != nil {
@@ -100,7 +222,7 @@ func (r *mutationResolver) TagEdit(ctx context.Context, input models.TagEditInpu
 			return err
 		}
 
-		return nil
+		return webhook.Enqueue(fac, models.WebhookEventEnumEditCreated, newEdit)
 	})
 
 	if err != nil {
@@ -110,12 +232,49 @@ func (r *mutationResolver) TagEdit(ctx context.Context, input models.TagEditInpu
 	return newEdit, nil
 }
 
+func (r *mutationResolver) amendTagEdit(ctx context.Context, input models.TagEditInput) (*models.Edit, error) {
+	fac := r.getRepoFactory(ctx)
+	currentUser := getCurrentUser(ctx)
+
+	var updatedEdit *models.Edit
+	err := fac.WithTxn(func() error {
+		existing, err := findAmendableEdit(ctx, fac, models.TargetTypeEnumTag, input.Edit)
+		if err != nil {
+			return err
+		}
+
+		p := edit.Tag(fac, existing)
+		if err := p.Amend(currentUser); err != nil {
+			return err
+		}
+
+		if err := p.Edit(input, wasFieldIncludedFunc(ctx)); err != nil {
+			return err
+		}
+
+		updatedEdit, err = p.UpdateEdit()
+		if err != nil {
+			return err
+		}
+
+		return p.CreateComment(currentUser, input.Edit.Comment)
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return updatedEdit, nil
+}
+
 func (r *mutationResolver) PerformerEdit(ctx context.Context, input models.PerformerEditInput) (*models.Edit, error) {
 	if err := validateEdit(ctx); err != nil {
 		return nil, err
 	}
 
-	// TODO - handle modification of existing edit
+	if input.Edit.EditID != nil {
+		return r.amendPerformerEdit(ctx, input)
+	}
 
 	UUID, err := uuid.NewV4()
 	if err != nil {
@@ -146,7 +305,7 @@ func (r *mutationResolver) PerformerEdit(ctx context.Context, input models.Perfo
 			return err
 		}
 
-		return nil
+		return webhook.Enqueue(fac, models.WebhookEventEnumEditCreated, newEdit)
 	})
 
 	if err != nil {
@@ -156,9 +315,63 @@ func (r *mutationResolver) PerformerEdit(ctx context.Context, input models.Perfo
 	return newEdit, nil
 }
 
+func (r *mutationResolver) amendPerformerEdit(ctx context.Context, input models.PerformerEditInput) (*models.Edit, error) {
+	fac := r.getRepoFactory(ctx)
+	currentUser := getCurrentUser(ctx)
+
+	var updatedEdit *models.Edit
+	err := fac.WithTxn(func() error {
+		existing, err := findAmendableEdit(ctx, fac, models.TargetTypeEnumPerformer, input.Edit)
+		if err != nil {
+			return err
+		}
+
+		p := edit.Performer(fac, existing)
+		if err := p.Amend(currentUser); err != nil {
+			return err
+		}
+
+		if err := p.Edit(input, wasFieldIncludedFunc(ctx)); err != nil {
+			return err
+		}
+
+		updatedEdit, err = p.UpdateEdit()
+		if err != nil {
+			return err
+		}
+
+		return p.CreateComment(currentUser, input.Edit.Comment)
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return updatedEdit, nil
+}
+
 func (r *mutationResolver) EditVote(ctx context.Context, input models.EditVoteInput) (*models.Edit, error) {
-	panic("not implemented")
+	if err := validateEdit(ctx); err != nil {
+		return nil, err
+	}
+
+	if input.Type == models.VoteTypeEnumImmediateAccept || input.Type == models.VoteTypeEnumImmediateReject {
+		if err := validateAdmin(ctx); err != nil {
+			return nil, err
+		}
+	}
+
+	editID, err := uuid.FromString(input.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	fac := r.getRepoFactory(ctx)
+	currentUser := getCurrentUser(ctx)
+
+	return edit.CastVote(fac, currentUser, editID, input.Type, input.Comment)
 }
+
 func (r *mutationResolver) EditComment(ctx context.Context, input models.EditCommentInput) (*models.Edit, error) {
 	if err := validateEdit(ctx); err != nil {
 		return nil, err
@@ -184,7 +397,7 @@ func (r *mutationResolver) EditComment(ctx context.Context, input models.EditCom
 			return err
 		}
 
-		return nil
+		return webhook.Enqueue(fac, models.WebhookEventEnumEditCommented, edit)
 	})
 
 	if err != nil {
@@ -229,7 +442,7 @@ func (r *mutationResolver) CancelEdit(ctx context.Context, input models.CancelEd
 			return err
 		}
 
-		return nil
+		return webhook.Enqueue(fac, models.WebhookEventEnumEditRejected, updatedEdit)
 	})
 
 	if err != nil {
@@ -247,5 +460,41 @@ func (r *mutationResolver) ApplyEdit(ctx context.Context, input models.ApplyEdit
 	editID, _ := uuid.FromString(input.ID)
 	fac := r.getRepoFactory(ctx)
 
-	return edit.ApplyEdit(fac, editID)
+	var ret *models.Edit
+	err := fac.WithTxn(func() error {
+		var err error
+		ret, err = edit.ApplyEdit(fac, editID, true)
+		if err != nil {
+			return err
+		}
+
+		newData := auditEditStatus{Status: ret.Status, Applied: ret.Applied}
+		return auditLog(ctx, fac, models.AuditActionEnumEditApply, models.AuditTargetTypeEnumEdit, editID, nil, newData)
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return ret, nil
+}
+
+// findAmendableEdit returns the edit referenced by the edit input, if the
+// current user is permitted to amend it.
+func findAmendableEdit(ctx context.Context, fac models.Repo, targetType models.TargetTypeEnum, input *models.EditInput) (*models.Edit, error) {
+	editID, err := uuid.FromString(*input.EditID)
+	if err != nil {
+		return nil, err
+	}
+
+	existing, err := edit.FindAmendable(fac, editID, targetType, input)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := validateOwner(ctx, existing.UserID); err != nil {
+		return nil, err
+	}
+
+	return existing, nil
 }
@@ -4,6 +4,7 @@ import (
 	"context"
 
 	"github.com/stashapp/stash-box/pkg/image"
+	"github.com/stashapp/stash-box/pkg/manager/config"
 	"github.com/stashapp/stash-box/pkg/models"
 )
 
@@ -17,11 +18,13 @@ func (r *mutationResolver) ImageCreate(ctx context.Context, input models.ImageCr
 	var ret *models.Image
 	err := fac.WithTxn(func() error {
 		qb := fac.Image()
-		imageService := image.GetService(qb)
-		var txnErr error
-		ret, txnErr = imageService.Create(input)
+		imageService, err := image.GetService(qb)
+		if err != nil {
+			return err
+		}
 
-		return txnErr
+		ret, err = imageService.Create(input)
+		return err
 	})
 
 	if err != nil {
@@ -40,7 +43,11 @@ func (r *mutationResolver) ImageDestroy(ctx context.Context, input models.ImageD
 
 	err := fac.WithTxn(func() error {
 		qb := fac.Image()
-		imageService := image.GetService(qb)
+		imageService, err := image.GetService(qb)
+		if err != nil {
+			return err
+		}
+
 		return imageService.Destroy(input)
 	})
 
@@ -50,3 +57,39 @@ func (r *mutationResolver) ImageDestroy(ctx context.Context, input models.ImageD
 
 	return true, nil
 }
+
+func (r *mutationResolver) ImageMerge(ctx context.Context, input models.ImageMergeInput) (*models.Image, error) {
+	if err := validateAdmin(ctx); err != nil {
+		return nil, err
+	}
+
+	backend, err := image.GetBackend(config.GetImageBackend())
+	if err != nil {
+		return nil, err
+	}
+
+	fac := r.getRepoFactory(ctx)
+	return image.Merge(fac, backend, input)
+}
+
+func (r *mutationResolver) ImageGarbageCollect(ctx context.Context, dryRun *bool) (*models.ImageGarbageCollectionResult, error) {
+	if err := validateAdmin(ctx); err != nil {
+		return nil, err
+	}
+
+	backend, err := image.GetBackend(config.GetImageBackend())
+	if err != nil {
+		return nil, err
+	}
+
+	fac := r.getRepoFactory(ctx)
+	result, err := image.CollectGarbage(ctx, fac, backend, config.GetImageGCGracePeriod(), dryRun != nil && *dryRun)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.ImageGarbageCollectionResult{
+		Images: result.Images,
+		Files:  result.Files,
+	}, nil
+}
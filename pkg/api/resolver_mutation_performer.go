@@ -157,7 +157,10 @@ func (r *mutationResolver) PerformerUpdate(ctx context.Context, input models.Per
 		}
 
 		// remove images that are no longer used
-		imageService := image.GetService(iqb)
+		imageService, err := image.GetService(iqb)
+		if err != nil {
+			return err
+		}
 
 		for _, i := range existingImages {
 			if err := imageService.DestroyUnusedImage(i.ID); err != nil {
@@ -195,6 +198,12 @@ func (r *mutationResolver) PerformerDestroy(ctx context.Context, input models.Pe
 		// references have on delete cascade, so shouldn't be necessary
 		// to remove them explicitly
 
+		// retained for the audit log
+		existing, err := qb.Find(performerID)
+		if err != nil {
+			return err
+		}
+
 		existingImages, err := iqb.FindByPerformerID(performerID)
 		if err != nil {
 			return err
@@ -204,8 +213,15 @@ func (r *mutationResolver) PerformerDestroy(ctx context.Context, input models.Pe
 			return err
 		}
 
+		if err := auditLog(ctx, fac, models.AuditActionEnumPerformerDestroy, models.AuditTargetTypeEnumPerformer, performerID, existing, nil); err != nil {
+			return err
+		}
+
 		// remove images that are no longer used
-		imageService := image.GetService(iqb)
+		imageService, err := image.GetService(iqb)
+		if err != nil {
+			return err
+		}
 
 		for _, i := range existingImages {
 			if err := imageService.DestroyUnusedImage(i.ID); err != nil {
@@ -2,6 +2,7 @@ package api
 
 import (
 	"context"
+	"errors"
 	"time"
 
 	"github.com/gofrs/uuid"
@@ -147,7 +148,10 @@ func (r *mutationResolver) SceneUpdate(ctx context.Context, input models.SceneUp
 		}
 
 		// remove images that are no longer used
-		imageService := image.GetService(iqb)
+		imageService, err := image.GetService(iqb)
+		if err != nil {
+			return err
+		}
 
 		for _, i := range existingImages {
 			if err := imageService.DestroyUnusedImage(i.ID); err != nil {
@@ -193,7 +197,10 @@ func (r *mutationResolver) SceneDestroy(ctx context.Context, input models.SceneD
 		}
 
 		// remove images that are no longer used
-		imageService := image.GetService(iqb)
+		imageService, err := image.GetService(iqb)
+		if err != nil {
+			return err
+		}
 
 		for _, i := range existingImages {
 			if err := imageService.DestroyUnusedImage(i.ID); err != nil {
@@ -211,29 +218,135 @@ func (r *mutationResolver) SceneDestroy(ctx context.Context, input models.SceneD
 }
 
 func (r *mutationResolver) SubmitFingerprint(ctx context.Context, input models.FingerprintSubmission) (bool, error) {
+	results, err := r.SubmitFingerprints(ctx, []*models.FingerprintSubmission{&input})
+	if err != nil {
+		return false, err
+	}
+
+	if results[0].Error != nil {
+		return false, errors.New(*results[0].Error)
+	}
+
+	return true, nil
+}
+
+type fingerprintSubmissionKey struct {
+	sceneID uuid.UUID
+	hash    string
+}
+
+func (r *mutationResolver) SubmitFingerprints(ctx context.Context, input []*models.FingerprintSubmission) ([]*models.FingerprintSubmissionResult, error) {
+	if err := validateRead(ctx); err != nil {
+		return nil, err
+	}
+
+	currentUser := getCurrentUser(ctx)
+	results := make([]*models.FingerprintSubmissionResult, len(input))
+	setError := func(i int, reason string) {
+		results[i].Success = false
+		results[i].Error = &reason
+	}
+
+	var sceneIDs []uuid.UUID
+	inputSceneIDs := make([]uuid.UUID, len(input))
+	for i, submission := range input {
+		results[i] = &models.FingerprintSubmissionResult{
+			SceneID: submission.SceneID,
+			Hash:    submission.Fingerprint.Hash,
+			Success: true,
+		}
+
+		sceneID, err := uuid.FromString(submission.SceneID)
+		if err != nil {
+			setError(i, "Invalid scene ID")
+			continue
+		}
+		inputSceneIDs[i] = sceneID
+		sceneIDs = append(sceneIDs, sceneID)
+	}
+
+	if len(sceneIDs) == 0 {
+		return results, nil
+	}
+
 	fac := r.getRepoFactory(ctx)
 	err := fac.WithTxn(func() error {
 		qb := fac.Scene()
 
-		// find the scene
-		sceneID, _ := uuid.FromString(input.SceneID)
-		scene, err := qb.Find(sceneID)
+		// validate all scenes before submitting anything
+		scenes, errs := qb.FindByIds(sceneIDs)
+		if errs != nil {
+			return errs[0]
+		}
+		sceneMap := make(map[uuid.UUID]*models.Scene)
+		for _, scene := range scenes {
+			if scene != nil {
+				sceneMap[scene.ID] = scene
+			}
+		}
 
+		existing, err := qb.GetFingerprintSubmissions(currentUser.ID, sceneIDs)
 		if err != nil {
 			return err
 		}
+		previous := make(map[fingerprintSubmissionKey]bool)
+		for _, submission := range existing {
+			previous[fingerprintSubmissionKey{submission.SceneID, submission.Hash}] = true
+		}
+
+		// later submissions of the same fingerprint override earlier ones
+		var keys []fingerprintSubmissionKey
+		submitted := make(map[fingerprintSubmissionKey]bool)
+		fingerprints := make(map[fingerprintSubmissionKey]*models.SceneFingerprint)
+		for i, submission := range input {
+			if results[i].Error != nil {
+				continue
+			}
+
+			scene := sceneMap[inputSceneIDs[i]]
+			if scene == nil {
+				setError(i, "Scene not found")
+				continue
+			}
+			if scene.Deleted {
+				setError(i, "Scene is deleted")
+				continue
+			}
+
+			key := fingerprintSubmissionKey{scene.ID, submission.Fingerprint.Hash}
+			if _, found := fingerprints[key]; !found {
+				keys = append(keys, key)
+			}
+			fingerprints[key] = models.CreateSubmittedSceneFingerprints(scene.ID, []*models.FingerprintInput{submission.Fingerprint})[0]
+			submitted[key] = submission.Unmatch == nil || !*submission.Unmatch
+		}
+
+		// each user only counts once towards the submissions of a fingerprint
+		var newFingerprints, unmatchedFingerprints models.SceneFingerprints
+		for _, key := range keys {
+			if submitted[key] && !previous[key] {
+				newFingerprints = append(newFingerprints, fingerprints[key])
+			} else if !submitted[key] && previous[key] {
+				unmatchedFingerprints = append(unmatchedFingerprints, fingerprints[key])
+			}
+		}
+
+		unmatched := models.CreateSceneFingerprintSubmissions(currentUser.ID, unmatchedFingerprints)
+		if err := qb.DestroyFingerprintSubmissions(unmatched); err != nil {
+			return err
+		}
 
-		sceneFingerprint := models.CreateSubmittedSceneFingerprints(scene.ID, []*models.FingerprintInput{input.Fingerprint})
-		if err := qb.CreateFingerprints(sceneFingerprint); err != nil {
+		if err := qb.CreateFingerprints(newFingerprints); err != nil {
 			return err
 		}
 
-		return nil
+		submissions := models.CreateSceneFingerprintSubmissions(currentUser.ID, newFingerprints)
+		return qb.CreateFingerprintSubmissions(submissions)
 	})
 
 	if err != nil {
-		return false, err
+		return nil, err
 	}
 
-	return true, nil
+	return results, nil
 }
@@ -123,7 +123,10 @@ func (r *mutationResolver) StudioUpdate(ctx context.Context, input models.Studio
 		}
 
 		// remove images that are no longer used
-		imageService := image.GetService(iqb)
+		imageService, err := image.GetService(iqb)
+		if err != nil {
+			return err
+		}
 
 		for _, i := range existingImages {
 			if err := imageService.DestroyUnusedImage(i.ID); err != nil {
@@ -169,7 +172,10 @@ func (r *mutationResolver) StudioDestroy(ctx context.Context, input models.Studi
 		}
 
 		// remove images that are no longer used
-		imageService := image.GetService(iqb)
+		imageService, err := image.GetService(iqb)
+		if err != nil {
+			return err
+		}
 
 		for _, i := range existingImages {
 			if err := imageService.DestroyUnusedImage(i.ID); err != nil {
@@ -72,11 +72,24 @@ func (r *mutationResolver) UserUpdate(ctx context.Context, input models.UserUpda
 			}
 		}
 
+		currentRoles, err := qb.GetRoles(userID)
+		if err != nil {
+			return err
+		}
+
 		u, err = user.Update(fac, input)
 		if err != nil {
 			return err
 		}
 
+		if rolesChanged(currentRoles.ToRoles(), input.Roles) {
+			oldData := auditRoles{Roles: currentRoles.ToRoles()}
+			newData := auditRoles{Roles: input.Roles}
+			if err := auditLog(ctx, fac, models.AuditActionEnumUserUpdateRoles, models.AuditTargetTypeEnumUser, userID, oldData, newData); err != nil {
+				return err
+			}
+		}
+
 		return nil
 	})
 
@@ -157,7 +170,9 @@ func (r *mutationResolver) RegenerateAPIKey(ctx context.Context, userID *string)
 			return err
 		}
 
-		return nil
+		// the key itself is not recorded
+		targetID, _ := uuid.FromString(*userID)
+		return auditLog(ctx, fac, models.AuditActionEnumAPIKeyRegenerate, models.AuditTargetTypeEnumUser, targetID, nil, nil)
 	})
 
 	if err != nil {
@@ -366,7 +381,9 @@ func (r *mutationResolver) GrantInvite(ctx context.Context, input models.GrantIn
 		// log the operation
 		logger.Userf(currentUser.Name, "GrantInvite", "+ %d to %s = %d", input.Amount, userID.String(), ret)
 
-		return nil
+		oldData := auditInviteTokens{InviteTokens: ret - input.Amount}
+		newData := auditInviteTokens{InviteTokens: ret}
+		return auditLog(ctx, fac, models.AuditActionEnumInviteGrant, models.AuditTargetTypeEnumUser, userID, oldData, newData)
 	})
 
 	if err != nil {
@@ -0,0 +1,137 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/gofrs/uuid"
+
+	"github.com/stashapp/stash-box/pkg/models"
+	"github.com/stashapp/stash-box/pkg/utils"
+)
+
+func (r *mutationResolver) WebhookCreate(ctx context.Context, input models.WebhookCreateInput) (*models.Webhook, error) {
+	if err := validateAdmin(ctx); err != nil {
+		return nil, err
+	}
+
+	if err := validateWebhookURL(input.URL); err != nil {
+		return nil, err
+	}
+
+	UUID, err := uuid.NewV4()
+	if err != nil {
+		return nil, err
+	}
+
+	currentTime := time.Now()
+	newWebhook := models.Webhook{
+		ID:        UUID,
+		CreatedAt: models.SQLiteTimestamp{Timestamp: currentTime},
+		UpdatedAt: models.SQLiteTimestamp{Timestamp: currentTime},
+	}
+	newWebhook.CopyFromCreateInput(input)
+
+	if newWebhook.Secret == "" {
+		newWebhook.Secret, err = utils.GenerateRandomKey(32)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	fac := r.getRepoFactory(ctx)
+	var webhook *models.Webhook
+	err = fac.WithTxn(func() error {
+		webhook, err = fac.Webhook().Create(newWebhook)
+		return err
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return webhook, nil
+}
+
+func (r *mutationResolver) WebhookUpdate(ctx context.Context, input models.WebhookUpdateInput) (*models.Webhook, error) {
+	if err := validateAdmin(ctx); err != nil {
+		return nil, err
+	}
+
+	if input.URL != nil {
+		if err := validateWebhookURL(*input.URL); err != nil {
+			return nil, err
+		}
+	}
+	if input.Secret != nil && *input.Secret == "" {
+		return nil, errors.New("webhook secret must not be empty")
+	}
+
+	webhookID, err := uuid.FromString(input.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	fac := r.getRepoFactory(ctx)
+	var webhook *models.Webhook
+	err = fac.WithTxn(func() error {
+		qb := fac.Webhook()
+		updatedWebhook, err := qb.Find(webhookID)
+		if err != nil {
+			return err
+		}
+		if updatedWebhook == nil {
+			return errors.New("webhook not found")
+		}
+
+		updatedWebhook.UpdatedAt = models.SQLiteTimestamp{Timestamp: time.Now()}
+		updatedWebhook.CopyFromUpdateInput(input)
+
+		webhook, err = qb.Update(*updatedWebhook)
+		return err
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return webhook, nil
+}
+
+func (r *mutationResolver) WebhookDestroy(ctx context.Context, input models.WebhookDestroyInput) (bool, error) {
+	if err := validateAdmin(ctx); err != nil {
+		return false, err
+	}
+
+	webhookID, err := uuid.FromString(input.ID)
+	if err != nil {
+		return false, err
+	}
+
+	fac := r.getRepoFactory(ctx)
+	err = fac.WithTxn(func() error {
+		return fac.Webhook().Destroy(webhookID)
+	})
+
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// validateWebhookURL returns an error if the URL is not an absolute http or
+// https URL.
+func validateWebhookURL(webhookURL string) error {
+	u, err := url.Parse(webhookURL)
+	if err != nil {
+		return err
+	}
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("invalid webhook URL: %s", webhookURL)
+	}
+
+	return nil
+}
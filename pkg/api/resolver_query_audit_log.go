@@ -0,0 +1,24 @@
+package api
+
+import (
+	"context"
+
+	"github.com/stashapp/stash-box/pkg/models"
+)
+
+func (r *queryResolver) QueryAuditLog(ctx context.Context, auditFilter *models.AuditLogFilterType, filter *models.QuerySpec) (*models.QueryAuditLogResultType, error) {
+	if err := validateAdmin(ctx); err != nil {
+		return nil, err
+	}
+
+	fac := r.getRepoFactory(ctx)
+	entries, count, err := fac.AuditLog().Query(auditFilter, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.QueryAuditLogResultType{
+		Entries: entries,
+		Count:   count,
+	}, nil
+}
@@ -0,0 +1,27 @@
+package api
+
+import (
+	"context"
+
+	"github.com/stashapp/stash-box/pkg/image"
+	"github.com/stashapp/stash-box/pkg/models"
+)
+
+func (r *queryResolver) FindImageDuplicates(ctx context.Context, distance *int) ([][]*models.Image, error) {
+	if err := validateAdmin(ctx); err != nil {
+		return nil, err
+	}
+
+	fac := r.getRepoFactory(ctx)
+	imageService, err := image.GetService(fac.Image())
+	if err != nil {
+		return nil, err
+	}
+
+	d := 0
+	if distance != nil {
+		d = *distance
+	}
+
+	return imageService.FindDuplicates(d)
+}
@@ -3,11 +3,13 @@ package api
 import (
 	"context"
 	"errors"
+	"sort"
 
 	"github.com/gofrs/uuid"
 
 	"github.com/stashapp/stash-box/pkg/manager/config"
 	"github.com/stashapp/stash-box/pkg/models"
+	"github.com/stashapp/stash-box/pkg/utils"
 )
 
 func (r *queryResolver) FindScene(ctx context.Context, id string) (*models.Scene, error) {
@@ -30,7 +32,7 @@ func (r *queryResolver) FindSceneByFingerprint(ctx context.Context, fingerprint
 	fac := r.getRepoFactory(ctx)
 	qb := fac.Scene()
 
-	return qb.FindByFingerprint(fingerprint.Algorithm, fingerprint.Hash)
+	return qb.FindByFingerprint(fingerprint)
 }
 
 func (r *queryResolver) FindScenesByFingerprints(ctx context.Context, fingerprints []string) ([]*models.Scene, error) {
@@ -60,7 +62,7 @@ func (r *queryResolver) FindScenesByFullFingerprints(ctx context.Context, finger
 	fac := r.getRepoFactory(ctx)
 	qb := fac.Scene()
 
-	if config.GetPHashDistance() == 0 {
+	if config.GetPHashDistance() == 0 && !hasDurationTolerance(fingerprints) {
 		var hashes []string
 		for _, fp := range fingerprints {
 			hashes = append(hashes, fp.Hash)
@@ -71,6 +73,114 @@ func (r *queryResolver) FindScenesByFullFingerprints(ctx context.Context, finger
 	return qb.FindByFullFingerprints(fingerprints)
 }
 
+func hasDurationTolerance(fingerprints []*models.FingerprintQueryInput) bool {
+	for _, fp := range fingerprints {
+		if fp.MaxDurationDifference() >= 0 {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *queryResolver) FindFingerprintMatches(ctx context.Context, fingerprints []*models.FingerprintQueryInput, distance *int) ([]*models.FingerprintMatchResult, error) {
+	if err := validateRead(ctx); err != nil {
+		return nil, err
+	}
+
+	if len(fingerprints) > 100 {
+		return nil, errors.New("Too many fingerprints")
+	}
+
+	maxDistance := config.GetPHashDistance()
+	if distance != nil && *distance < maxDistance {
+		maxDistance = *distance
+	}
+	if maxDistance < 0 {
+		maxDistance = 0
+	}
+
+	fac := r.getRepoFactory(ctx)
+	qb := fac.Scene()
+
+	stored, err := qb.FindFingerprintMatches(fingerprints, maxDistance)
+	if err != nil {
+		return nil, err
+	}
+
+	var sceneIDs []uuid.UUID
+	for _, fingerprint := range stored {
+		sceneIDs = append(sceneIDs, fingerprint.SceneID)
+	}
+	sceneMap := make(map[uuid.UUID]*models.Scene)
+	if len(sceneIDs) > 0 {
+		scenes, errs := qb.FindByIds(sceneIDs)
+		if errs != nil {
+			return nil, errs[0]
+		}
+		for _, scene := range scenes {
+			if scene != nil {
+				sceneMap[scene.ID] = scene
+			}
+		}
+	}
+
+	var ret []*models.FingerprintMatchResult
+	for _, fingerprint := range fingerprints {
+		result := &models.FingerprintMatchResult{
+			Hash:      fingerprint.Hash,
+			Algorithm: fingerprint.Algorithm,
+			Matches:   []*models.FingerprintMatch{},
+		}
+
+		for _, s := range stored {
+			matchDistance, matched := fingerprintMatchDistance(fingerprint, s, maxDistance)
+			scene := sceneMap[s.SceneID]
+			if !matched || scene == nil {
+				continue
+			}
+
+			var algorithm models.FingerprintAlgorithm
+			utils.ResolveEnumString(s.Algorithm, &algorithm)
+			result.Matches = append(result.Matches, &models.FingerprintMatch{
+				Scene:     scene,
+				Hash:      s.Hash,
+				Algorithm: algorithm,
+				Distance:  matchDistance,
+			})
+		}
+
+		sort.SliceStable(result.Matches, func(i, j int) bool {
+			return result.Matches[i].Distance < result.Matches[j].Distance
+		})
+		ret = append(ret, result)
+	}
+
+	return ret, nil
+}
+
+// fingerprintMatchDistance returns the distance between the queried and
+// stored fingerprint, and whether the stored fingerprint is a match.
+func fingerprintMatchDistance(fingerprint *models.FingerprintQueryInput, stored *models.SceneFingerprint, maxDistance int) (int, bool) {
+	if !fingerprint.MatchesDuration(stored.Duration) {
+		return 0, false
+	}
+
+	if fingerprint.Hash == stored.Hash {
+		return 0, true
+	}
+
+	if fingerprint.Algorithm != models.FingerprintAlgorithmPhash || stored.Algorithm != models.FingerprintAlgorithmPhash.String() {
+		return 0, false
+	}
+
+	distance, err := utils.PHashDistance(fingerprint.Hash, stored.Hash)
+	if err != nil || distance > maxDistance {
+		return 0, false
+	}
+
+	return distance, true
+}
+
 func (r *queryResolver) QueryScenes(ctx context.Context, sceneFilter *models.SceneFilterType, filter *models.QuerySpec) (*models.QueryScenesResultType, error) {
 	if err := validateRead(ctx); err != nil {
 		return nil, err
@@ -0,0 +1,16 @@
+package api
+
+import (
+	"context"
+
+	"github.com/stashapp/stash-box/pkg/logger"
+	"github.com/stashapp/stash-box/pkg/models"
+)
+
+func (r *queryResolver) Logs(ctx context.Context, minLevel *models.LogLevelEnum) ([]*logger.LogItem, error) {
+	if err := validateAdmin(ctx); err != nil {
+		return nil, err
+	}
+
+	return filterLogItems(logger.GetLogCache(), minLevel), nil
+}
@@ -27,7 +27,7 @@ func (r *queryResolver) SearchPerformer(ctx context.Context, term string, limit
 		return performers, err
 	}
 
-	searchLimit := 5
+	searchLimit := defaultPerformerSearchLimit
 	if limit != nil {
 		searchLimit = *limit
 	}
@@ -54,7 +54,7 @@ func (r *queryResolver) SearchScene(ctx context.Context, term string, limit *int
 		return scenes, err
 	}
 
-	searchLimit := 10
+	searchLimit := defaultSceneSearchLimit
 	if limit != nil {
 		searchLimit = *limit
 	}
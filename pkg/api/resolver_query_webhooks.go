@@ -0,0 +1,16 @@
+package api
+
+import (
+	"context"
+
+	"github.com/stashapp/stash-box/pkg/models"
+)
+
+func (r *queryResolver) Webhooks(ctx context.Context) ([]*models.Webhook, error) {
+	if err := validateAdmin(ctx); err != nil {
+		return nil, err
+	}
+
+	fac := r.getRepoFactory(ctx)
+	return fac.Webhook().FindAll()
+}
@@ -0,0 +1,50 @@
+package api
+
+import (
+	"context"
+
+	"github.com/stashapp/stash-box/pkg/logger"
+	"github.com/stashapp/stash-box/pkg/models"
+)
+
+type subscriptionResolver struct{ *Resolver }
+
+func (r *subscriptionResolver) Logs(ctx context.Context, minLevel *models.LogLevelEnum) (<-chan []*logger.LogItem, error) {
+	if err := validateAdmin(ctx); err != nil {
+		return nil, err
+	}
+
+	stop := make(chan int, 1)
+	items := logger.SubscribeToLog(stop)
+
+	ret := make(chan []*logger.LogItem, 100)
+	go func() {
+		defer close(ret)
+		// unsubscribes once the subscription is closed
+		defer func() { stop <- 0 }()
+
+		for {
+			select {
+			case batch, ok := <-items:
+				if !ok {
+					return
+				}
+
+				filtered := filterLogItems(batch, minLevel)
+				if len(filtered) == 0 {
+					continue
+				}
+
+				select {
+				case ret <- filtered:
+				case <-ctx.Done():
+					return
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return ret, nil
+}
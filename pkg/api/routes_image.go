@@ -1,30 +1,206 @@
 package api
 
 import (
+	"io"
 	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
 
 	"github.com/go-chi/chi"
+	"github.com/gofrs/uuid"
+	"github.com/minio/minio-go/v7"
+
 	"github.com/stashapp/stash-box/pkg/image"
+	"github.com/stashapp/stash-box/pkg/logger"
 	"github.com/stashapp/stash-box/pkg/manager/config"
+	"github.com/stashapp/stash-box/pkg/metrics"
+	"github.com/stashapp/stash-box/pkg/models"
 )
 
+// imageContentSecurityPolicy prevents scripts and external resources in
+// SVG images from being loaded when an image is opened directly.
+const imageContentSecurityPolicy = "default-src 'none'; style-src 'unsafe-inline'"
+
 type imageRoutes struct{}
 
 func (rs imageRoutes) Routes() chi.Router {
 	r := chi.NewRouter()
 
-	r.Get("/{checksum}", rs.Image)
+	r.Get("/{id}", rs.Image)
 
 	return r
 }
 
+// Image serves the image with the provided id, which is either the image ID
+// or the checksum of the image.
 func (rs imageRoutes) Image(w http.ResponseWriter, r *http.Request) {
-	checksum := chi.URLParam(r, "checksum")
+	id := chi.URLParam(r, "id")
+
+	if config.GetImageBackend() == config.S3Backend {
+		rs.serveS3Image(w, r, id)
+		return
+	}
+
+	rs.serveFileImage(w, r, id)
+}
 
+func findImage(r *http.Request, id string) (*models.Image, error) {
+	qb := getRepo(r.Context()).Image()
+
+	if imageID, err := uuid.FromString(id); err == nil {
+		return qb.Find(imageID)
+	}
+
+	return qb.FindByChecksum(id)
+}
+
+func (rs imageRoutes) serveFileImage(w http.ResponseWriter, r *http.Request, id string) {
 	if err := config.ValidateImageLocation(); err != nil {
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
 
-	http.ServeFile(w, r, image.GetImagePath(config.GetImageLocation(), checksum))
+	checksum := id
+	if _, err := uuid.FromString(id); err == nil {
+		img, err := findImage(r, id)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if img == nil || img.Checksum == "" {
+			http.NotFound(w, r)
+			return
+		}
+		checksum = img.Checksum
+	}
+
+	path := image.GetImagePath(config.GetImageLocation(), checksum)
+	if sizeParam := r.URL.Query().Get("size"); sizeParam != "" {
+		size, err := strconv.Atoi(sizeParam)
+		if err != nil || size <= 0 {
+			http.Error(w, "invalid size", http.StatusBadRequest)
+			return
+		}
+
+		path, err = image.GetThumbnail(config.GetImageLocation(), checksum, size)
+		if os.IsNotExist(err) {
+			http.NotFound(w, r)
+			return
+		}
+		if err == image.ErrInvalidChecksum {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	}
+
+	serveImageFile(w, r, path)
+}
+
+func serveImageFile(w http.ResponseWriter, r *http.Request, path string) {
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		http.NotFound(w, r)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	defer func() {
+		_ = f.Close()
+	}()
+
+	info, err := f.Stat()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if info.IsDir() {
+		http.NotFound(w, r)
+		return
+	}
+
+	// the content type is detected from the start of the file, as in
+	// http.DetectContentType
+	head := make([]byte, 512)
+	n, err := io.ReadFull(f, head)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	// stored files are named after their checksum, so the name identifies
+	// the content
+	w.Header().Set("Content-Type", image.DetectContentType(head[:n]))
+	w.Header().Set("Content-Security-Policy", imageContentSecurityPolicy)
+	w.Header().Set("ETag", strconv.Quote(filepath.Base(path)))
+	http.ServeContent(servedBytesWriter{w}, r, "", info.ModTime(), f)
+}
+
+func (rs imageRoutes) serveS3Image(w http.ResponseWriter, r *http.Request, id string) {
+	img, err := findImage(r, id)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if img == nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	backend := &image.S3Backend{}
+	if config.GetS3Config().SignedRedirects {
+		signedURL, err := backend.PresignedURL(img)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		http.Redirect(w, r, signedURL.String(), http.StatusFound)
+		return
+	}
+
+	object, info, err := backend.GetObject(img)
+	if err != nil {
+		if minio.ToErrorResponse(err).Code == "NoSuchKey" {
+			http.NotFound(w, r)
+			return
+		}
+
+		logger.Errorf("Error reading image %s from S3: %s", img.ID.String(), err.Error())
+		http.Error(w, "error reading image", http.StatusBadGateway)
+		return
+	}
+	defer func() {
+		_ = object.Close()
+	}()
+
+	contentType := info.ContentType
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Security-Policy", imageContentSecurityPolicy)
+	w.Header().Set("ETag", strconv.Quote(info.ETag))
+	http.ServeContent(servedBytesWriter{w}, r, "", info.LastModified, object)
+}
+
+// servedBytesWriter counts the bytes written in the image metrics.
+type servedBytesWriter struct {
+	http.ResponseWriter
+}
+
+func (w servedBytesWriter) Write(b []byte) (int, error) {
+	n, err := w.ResponseWriter.Write(b)
+	metrics.ImageBytesServed.Add(float64(n))
+	return n, err
 }
@@ -0,0 +1,66 @@
+package api
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestServeImageFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "images")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	svg := `<svg xmlns="http://www.w3.org/2000/svg" width="10" height="10"><rect width="10" height="10"/></svg>`
+	path := filepath.Join(dir, "checksum")
+	if err := ioutil.WriteFile(path, []byte(svg), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	rr := httptest.NewRecorder()
+	serveImageFile(rr, httptest.NewRequest(http.MethodGet, "/image/checksum", nil), path)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("status: got %d want %d", rr.Code, http.StatusOK)
+	}
+	if got := rr.Header().Get("Content-Type"); got != "image/svg+xml" {
+		t.Errorf("Content-Type: got %q want %q", got, "image/svg+xml")
+	}
+	if got := rr.Header().Get("Content-Security-Policy"); got != imageContentSecurityPolicy {
+		t.Errorf("Content-Security-Policy: got %q want %q", got, imageContentSecurityPolicy)
+	}
+	if got := rr.Body.String(); got != svg {
+		t.Errorf("body: got %q want %q", got, svg)
+	}
+
+	// files are served from the start after detecting the content type
+	large := svg + strings.Repeat(" ", 1024)
+	if err := ioutil.WriteFile(path, []byte(large), 0644); err != nil {
+		t.Fatal(err)
+	}
+	rr = httptest.NewRecorder()
+	serveImageFile(rr, httptest.NewRequest(http.MethodGet, "/image/checksum", nil), path)
+	if got := rr.Body.String(); got != large {
+		t.Errorf("large file body: got %d bytes want %d", len(got), len(large))
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/image/checksum", nil)
+	req.Header.Set("Range", "bytes=0-3")
+	rr = httptest.NewRecorder()
+	serveImageFile(rr, req, path)
+	if rr.Code != http.StatusPartialContent || rr.Body.String() != "<svg" {
+		t.Errorf("range request: got status %d body %q", rr.Code, rr.Body.String())
+	}
+
+	rr = httptest.NewRecorder()
+	serveImageFile(rr, httptest.NewRequest(http.MethodGet, "/image/missing", nil), filepath.Join(dir, "missing"))
+	if rr.Code != http.StatusNotFound {
+		t.Errorf("missing file status: got %d want %d", rr.Code, http.StatusNotFound)
+	}
+}
@@ -0,0 +1,435 @@
+//go:build integration
+// +build integration
+
+package api_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/stashapp/stash-box/pkg/models"
+)
+
+type sceneEditTestRunner struct {
+	testRunner
+}
+
+func createSceneEditTestRunner(t *testing.T) *sceneEditTestRunner {
+	return &sceneEditTestRunner{
+		testRunner: *asAdmin(t),
+	}
+}
+
+func (s *sceneEditTestRunner) testCreateSceneEdit() {
+	studio, err := s.createTestStudio(nil)
+	if err != nil {
+		return
+	}
+	studioID := studio.ID.String()
+	title := "Title"
+	date := "2001-02-03"
+	sceneEditDetailsInput := models.SceneEditDetailsInput{
+		Title:    &title,
+		Date:     &date,
+		StudioID: &studioID,
+		Fingerprints: []*models.FingerprintEditInput{
+			s.generateSceneFingerprint(),
+		},
+	}
+	edit, err := s.createTestSceneEdit(models.OperationEnumCreate, &sceneEditDetailsInput, nil)
+	if err == nil {
+		s.verifyCreatedSceneEdit(sceneEditDetailsInput, edit)
+	}
+}
+
+func (s *sceneEditTestRunner) verifyCreatedSceneEdit(input models.SceneEditDetailsInput, edit *models.Edit) {
+	r := s.resolver.Edit()
+
+	id, _ := r.ID(s.ctx, edit)
+	if id == "" {
+		s.t.Errorf("Expected created edit id to be non-zero")
+	}
+
+	sceneDetails := s.getEditSceneDetails(edit)
+
+	s.verifyEditOperation(models.OperationEnumCreate.String(), edit)
+	s.verifyEditStatus(models.VoteStatusEnumPending.String(), edit)
+	s.verifyEditTargetType(models.TargetTypeEnumScene.String(), edit)
+	s.verifyEditApplication(false, edit)
+
+	// ensure basic attributes are set correctly
+	if *input.Title != *sceneDetails.Title {
+		s.fieldMismatch(*input.Title, *sceneDetails.Title, "Title")
+	}
+
+	if *input.Date != *sceneDetails.Date {
+		s.fieldMismatch(*input.Date, *sceneDetails.Date, "Date")
+	}
+
+	if *input.StudioID != *sceneDetails.StudioID {
+		s.fieldMismatch(*input.StudioID, *sceneDetails.StudioID, "StudioID")
+	}
+
+	if len(sceneDetails.AddedFingerprints) != 1 || sceneDetails.AddedFingerprints[0].Hash != input.Fingerprints[0].Hash {
+		s.fieldMismatch(input.Fingerprints, sceneDetails.AddedFingerprints, "Fingerprints")
+	}
+}
+
+func (s *sceneEditTestRunner) testModifySceneEdit() {
+	existingTitle := "sceneTitle"
+	sceneCreateInput := models.SceneCreateInput{
+		Title: &existingTitle,
+		Fingerprints: []*models.FingerprintEditInput{
+			s.generateSceneFingerprint(),
+		},
+	}
+	createdScene, err := s.createTestScene(&sceneCreateInput)
+	if err != nil {
+		return
+	}
+
+	performer, err := s.createTestPerformer(nil)
+	if err != nil {
+		return
+	}
+	tag, err := s.createTestTag(nil)
+	if err != nil {
+		return
+	}
+
+	newTitle := "newTitle"
+	url := models.URL{
+		URL:  "http://example.org",
+		Type: "STUDIO",
+	}
+	sceneEditDetailsInput := models.SceneEditDetailsInput{
+		Title: &newTitle,
+		Urls:  []*models.URL{&url},
+		Performers: []*models.PerformerAppearanceInput{{
+			PerformerID: performer.ID.String(),
+		}},
+		TagIds: []string{tag.ID.String()},
+	}
+	id := createdScene.ID.String()
+	editInput := models.EditInput{
+		Operation: models.OperationEnumModify,
+		ID:        &id,
+	}
+
+	createdUpdateEdit, err := s.createTestSceneEdit(models.OperationEnumModify, &sceneEditDetailsInput, &editInput)
+	if err != nil {
+		return
+	}
+
+	s.verifyUpdatedSceneEdit(sceneEditDetailsInput, createdUpdateEdit)
+}
+
+func (s *sceneEditTestRunner) verifyUpdatedSceneEdit(input models.SceneEditDetailsInput, edit *models.Edit) {
+	sceneDetails := s.getEditSceneDetails(edit)
+
+	s.verifyEditOperation(models.OperationEnumModify.String(), edit)
+	s.verifyEditStatus(models.VoteStatusEnumPending.String(), edit)
+	s.verifyEditTargetType(models.TargetTypeEnumScene.String(), edit)
+	s.verifyEditApplication(false, edit)
+
+	// ensure basic attributes are set correctly
+	if *input.Title != *sceneDetails.Title {
+		s.fieldMismatch(*input.Title, *sceneDetails.Title, "Title")
+	}
+
+	if !reflect.DeepEqual(sceneDetails.AddedUrls, input.Urls) {
+		s.fieldMismatch(input.Urls, sceneDetails.AddedUrls, "URLs")
+	}
+
+	if !reflect.DeepEqual(sceneDetails.AddedPerformers, input.Performers) {
+		s.fieldMismatch(input.Performers, sceneDetails.AddedPerformers, "Performers")
+	}
+
+	if !reflect.DeepEqual(sceneDetails.AddedTags, input.TagIds) {
+		s.fieldMismatch(input.TagIds, sceneDetails.AddedTags, "Tags")
+	}
+
+	// existing fingerprint was omitted from the input so should be removed
+	if len(sceneDetails.RemovedFingerprints) != 1 {
+		s.fieldMismatch(1, len(sceneDetails.RemovedFingerprints), "Removed fingerprints")
+	}
+}
+
+func (s *sceneEditTestRunner) testDestroySceneEdit() {
+	createdScene, err := s.createTestScene(nil)
+	if err != nil {
+		return
+	}
+
+	sceneID := createdScene.ID.String()
+
+	sceneEditDetailsInput := models.SceneEditDetailsInput{}
+	editInput := models.EditInput{
+		Operation: models.OperationEnumDestroy,
+		ID:        &sceneID,
+	}
+	destroyEdit, err := s.createTestSceneEdit(models.OperationEnumDestroy, &sceneEditDetailsInput, &editInput)
+	if err != nil {
+		return
+	}
+
+	s.verifyDestroySceneEdit(sceneID, destroyEdit)
+}
+
+func (s *sceneEditTestRunner) verifyDestroySceneEdit(sceneID string, edit *models.Edit) {
+	s.verifyEditOperation(models.OperationEnumDestroy.String(), edit)
+	s.verifyEditStatus(models.VoteStatusEnumPending.String(), edit)
+	s.verifyEditTargetType(models.TargetTypeEnumScene.String(), edit)
+	s.verifyEditApplication(false, edit)
+
+	editTarget := s.getEditSceneTarget(edit)
+
+	if sceneID != editTarget.ID.String() {
+		s.fieldMismatch(sceneID, editTarget.ID.String(), "ID")
+	}
+}
+
+func (s *sceneEditTestRunner) testApplyCreateSceneEdit() {
+	title := "Title"
+	performer, err := s.createTestPerformer(nil)
+	if err != nil {
+		return
+	}
+	alias := "alias"
+	sceneEditDetailsInput := models.SceneEditDetailsInput{
+		Title: &title,
+		Performers: []*models.PerformerAppearanceInput{{
+			PerformerID: performer.ID.String(),
+			As:          &alias,
+		}},
+		Fingerprints: []*models.FingerprintEditInput{
+			s.generateSceneFingerprint(),
+		},
+	}
+	edit, err := s.createTestSceneEdit(models.OperationEnumCreate, &sceneEditDetailsInput, nil)
+	if err != nil {
+		return
+	}
+	appliedEdit, err := s.applyEdit(edit.ID.String())
+	if err == nil {
+		s.verifyAppliedSceneCreateEdit(sceneEditDetailsInput, appliedEdit)
+	}
+}
+
+func (s *sceneEditTestRunner) verifyAppliedSceneCreateEdit(input models.SceneEditDetailsInput, edit *models.Edit) {
+	s.verifyEditOperation(models.OperationEnumCreate.String(), edit)
+	s.verifyEditStatus(models.VoteStatusEnumImmediateAccepted.String(), edit)
+	s.verifyEditTargetType(models.TargetTypeEnumScene.String(), edit)
+	s.verifyEditApplication(true, edit)
+
+	scene := s.getEditSceneTarget(edit)
+
+	if *input.Title != scene.Title.String {
+		s.fieldMismatch(*input.Title, scene.Title.String, "Title")
+	}
+
+	performers, _ := s.resolver.Scene().Performers(s.ctx, scene)
+	if len(performers) != 1 || performers[0].Performer.ID.String() != input.Performers[0].PerformerID || *performers[0].As != *input.Performers[0].As {
+		s.fieldMismatch(input.Performers, performers, "Performers")
+	}
+
+	fingerprints, _ := s.resolver.Scene().Fingerprints(s.ctx, scene)
+	if len(fingerprints) != 1 || fingerprints[0].Hash != input.Fingerprints[0].Hash {
+		s.fieldMismatch(input.Fingerprints, fingerprints, "Fingerprints")
+	}
+}
+
+func (s *sceneEditTestRunner) testApplyModifySceneEdit() {
+	existingTitle := "sceneTitle2"
+	sceneCreateInput := models.SceneCreateInput{
+		Title: &existingTitle,
+		Urls: []*models.URL{{
+			URL:  "http://example.org/old",
+			Type: "STUDIO",
+		}},
+		Fingerprints: []*models.FingerprintEditInput{
+			s.generateSceneFingerprint(),
+		},
+	}
+	createdScene, err := s.createTestScene(&sceneCreateInput)
+	if err != nil {
+		return
+	}
+
+	newTitle := "newTitle2"
+	newURL := models.URL{
+		URL:  "http://example.org/new",
+		Type: "STUDIO",
+	}
+	sceneEditDetailsInput := models.SceneEditDetailsInput{
+		Title: &newTitle,
+		Urls:  []*models.URL{&newURL},
+		Fingerprints: []*models.FingerprintEditInput{
+			s.generateSceneFingerprint(),
+		},
+	}
+	id := createdScene.ID.String()
+	editInput := models.EditInput{
+		Operation: models.OperationEnumModify,
+		ID:        &id,
+	}
+
+	createdUpdateEdit, err := s.createTestSceneEdit(models.OperationEnumModify, &sceneEditDetailsInput, &editInput)
+	if err != nil {
+		return
+	}
+	appliedEdit, err := s.applyEdit(createdUpdateEdit.ID.String())
+	if err != nil {
+		return
+	}
+
+	modifiedScene, _ := s.resolver.Query().FindScene(s.ctx, id)
+	s.verifyApplyModifySceneEdit(sceneEditDetailsInput, modifiedScene, appliedEdit)
+}
+
+func (s *sceneEditTestRunner) verifyApplyModifySceneEdit(input models.SceneEditDetailsInput, updatedScene *models.Scene, edit *models.Edit) {
+	s.verifyEditOperation(models.OperationEnumModify.String(), edit)
+	s.verifyEditStatus(models.VoteStatusEnumImmediateAccepted.String(), edit)
+	s.verifyEditTargetType(models.TargetTypeEnumScene.String(), edit)
+	s.verifyEditApplication(true, edit)
+
+	if *input.Title != updatedScene.Title.String {
+		s.fieldMismatch(*input.Title, updatedScene.Title.String, "Title")
+	}
+
+	urls, _ := s.resolver.Scene().Urls(s.ctx, updatedScene)
+	if !reflect.DeepEqual(input.Urls, urls) {
+		s.fieldMismatch(input.Urls, urls, "URLs")
+	}
+
+	fingerprints, _ := s.resolver.Scene().Fingerprints(s.ctx, updatedScene)
+	if len(fingerprints) != 1 || fingerprints[0].Hash != input.Fingerprints[0].Hash {
+		s.fieldMismatch(input.Fingerprints, fingerprints, "Fingerprints")
+	}
+}
+
+func (s *sceneEditTestRunner) testApplyDestroySceneEdit() {
+	createdScene, err := s.createTestScene(nil)
+	if err != nil {
+		return
+	}
+
+	sceneID := createdScene.ID.String()
+	sceneEditDetailsInput := models.SceneEditDetailsInput{}
+	editInput := models.EditInput{
+		Operation: models.OperationEnumDestroy,
+		ID:        &sceneID,
+	}
+	destroyEdit, err := s.createTestSceneEdit(models.OperationEnumDestroy, &sceneEditDetailsInput, &editInput)
+	if err != nil {
+		return
+	}
+	appliedEdit, err := s.applyEdit(destroyEdit.ID.String())
+	if err != nil {
+		return
+	}
+
+	destroyedScene, _ := s.resolver.Query().FindScene(s.ctx, sceneID)
+	s.verifyApplyDestroySceneEdit(destroyedScene, appliedEdit)
+}
+
+func (s *sceneEditTestRunner) verifyApplyDestroySceneEdit(destroyedScene *models.Scene, edit *models.Edit) {
+	s.verifyEditOperation(models.OperationEnumDestroy.String(), edit)
+	s.verifyEditStatus(models.VoteStatusEnumImmediateAccepted.String(), edit)
+	s.verifyEditTargetType(models.TargetTypeEnumScene.String(), edit)
+	s.verifyEditApplication(true, edit)
+
+	if destroyedScene.Deleted != true {
+		s.fieldMismatch(destroyedScene.Deleted, true, "Deleted")
+	}
+}
+
+func (s *sceneEditTestRunner) testApplyMergeSceneEdit() {
+	mergeSource, err := s.createTestScene(nil)
+	if err != nil {
+		return
+	}
+	mergeTarget, err := s.createTestScene(nil)
+	if err != nil {
+		return
+	}
+
+	newTitle := "newTitle3"
+	sceneEditDetailsInput := models.SceneEditDetailsInput{
+		Title: &newTitle,
+	}
+	id := mergeTarget.ID.String()
+	mergeSources := []string{mergeSource.ID.String()}
+	editInput := models.EditInput{
+		Operation:      models.OperationEnumMerge,
+		ID:             &id,
+		MergeSourceIds: mergeSources,
+	}
+
+	mergeEdit, err := s.createTestSceneEdit(models.OperationEnumMerge, &sceneEditDetailsInput, &editInput)
+	if err != nil {
+		return
+	}
+
+	appliedMerge, err := s.applyEdit(mergeEdit.ID.String())
+	if err != nil {
+		return
+	}
+
+	s.verifyAppliedMergeSceneEdit(sceneEditDetailsInput, appliedMerge)
+}
+
+func (s *sceneEditTestRunner) verifyAppliedMergeSceneEdit(input models.SceneEditDetailsInput, edit *models.Edit) {
+	s.verifyEditOperation(models.OperationEnumMerge.String(), edit)
+	s.verifyEditStatus(models.VoteStatusEnumImmediateAccepted.String(), edit)
+	s.verifyEditTargetType(models.TargetTypeEnumScene.String(), edit)
+	s.verifyEditApplication(true, edit)
+
+	sceneDetails := s.getEditSceneDetails(edit)
+	if *input.Title != *sceneDetails.Title {
+		s.fieldMismatch(*input.Title, *sceneDetails.Title, "Title")
+	}
+
+	merges, _ := s.resolver.Edit().MergeSources(s.ctx, edit)
+	for i := range merges {
+		scene := merges[i].(*models.Scene)
+		if scene.Deleted != true {
+			s.fieldMismatch(scene.Deleted, true, "Deleted")
+		}
+	}
+}
+
+func TestCreateSceneEdit(t *testing.T) {
+	pt := createSceneEditTestRunner(t)
+	pt.testCreateSceneEdit()
+}
+
+func TestModifySceneEdit(t *testing.T) {
+	pt := createSceneEditTestRunner(t)
+	pt.testModifySceneEdit()
+}
+
+func TestDestroySceneEdit(t *testing.T) {
+	pt := createSceneEditTestRunner(t)
+	pt.testDestroySceneEdit()
+}
+
+func TestApplyCreateSceneEdit(t *testing.T) {
+	pt := createSceneEditTestRunner(t)
+	pt.testApplyCreateSceneEdit()
+}
+
+func TestApplyModifySceneEdit(t *testing.T) {
+	pt := createSceneEditTestRunner(t)
+	pt.testApplyModifySceneEdit()
+}
+
+func TestApplyDestroySceneEdit(t *testing.T) {
+	pt := createSceneEditTestRunner(t)
+	pt.testApplyDestroySceneEdit()
+}
+
+func TestApplyMergeSceneEdit(t *testing.T) {
+	pt := createSceneEditTestRunner(t)
+	pt.testApplyMergeSceneEdit()
+}
@@ -3,10 +3,14 @@
 package api_test
 
 import (
+	"context"
 	"reflect"
 	"testing"
 
 	"github.com/stashapp/stash-box/pkg/api"
+	"github.com/stashapp/stash-box/pkg/database/databasetest"
+	"github.com/stashapp/stash-box/pkg/dataloader"
+	"github.com/stashapp/stash-box/pkg/manager/config"
 	"github.com/stashapp/stash-box/pkg/models"
 )
 
@@ -274,6 +278,151 @@ func (s *sceneTestRunner) testFindScenesByFingerprints() {
 	}
 }
 
+func (s *sceneTestRunner) testFindSceneByFingerprintDuration() {
+	scene, err := s.createTestScene(nil)
+	if err != nil {
+		return
+	}
+
+	fingerprints, err := s.resolver.Scene().Fingerprints(s.ctx, scene)
+	if err != nil {
+		s.t.Errorf("Error getting scene fingerprints: %s", err.Error())
+		return
+	}
+
+	duration := fingerprints[0].Duration - 100
+	tolerance := 10
+	input := models.FingerprintQueryInput{
+		Hash:              fingerprints[0].Hash,
+		Algorithm:         fingerprints[0].Algorithm,
+		Duration:          &duration,
+		DurationTolerance: &tolerance,
+	}
+
+	scenes, err := s.resolver.Query().FindSceneByFingerprint(s.ctx, input)
+	if err != nil {
+		s.t.Errorf("Error finding scene: %s", err.Error())
+		return
+	}
+	if len(scenes) != 0 {
+		s.t.Errorf("Found scene outside of the duration tolerance")
+	}
+
+	tolerance = 100
+	scenes, err = s.resolver.Query().FindSceneByFingerprint(s.ctx, input)
+	if err != nil {
+		s.t.Errorf("Error finding scene: %s", err.Error())
+		return
+	}
+	if len(scenes) != 1 || scenes[0].ID != scene.ID {
+		s.t.Errorf("Scene within the duration tolerance was not found")
+	}
+}
+
+func (s *sceneTestRunner) testFindScenesByFullFingerprintsPHash() {
+	md5 := s.generateSceneFingerprint()
+	phash := &models.FingerprintEditInput{
+		Algorithm:   models.FingerprintAlgorithmPhash,
+		Hash:        "7e7e7e7e7e7e7e7e",
+		Duration:    1234,
+		Submissions: 1,
+		Created:     md5.Created,
+		Updated:     md5.Updated,
+	}
+	scene, err := s.createTestScene(&models.SceneCreateInput{
+		Fingerprints: []*models.FingerprintEditInput{phash},
+	})
+	if err != nil {
+		return
+	}
+
+	// with phash_distance 0, PHASH fingerprints are matched by hash, without
+	// requiring the bktree extension
+	duration := phash.Duration - 5
+	tolerance := 10
+	input := []*models.FingerprintQueryInput{
+		{Hash: phash.Hash, Algorithm: phash.Algorithm, Duration: &duration, DurationTolerance: &tolerance},
+		{Hash: "7e7e7e7e7e7e7e7f", Algorithm: phash.Algorithm, Duration: &duration, DurationTolerance: &tolerance},
+	}
+
+	scenes, err := s.resolver.Query().FindScenesByFullFingerprints(s.ctx, input)
+	if err != nil {
+		s.t.Errorf("Error finding scenes: %s", err.Error())
+		return
+	}
+	if len(scenes) != 1 || scenes[0].ID != scene.ID {
+		s.t.Errorf("Scene was not matched by PHASH: got %d scenes", len(scenes))
+	}
+}
+
+func (s *sceneTestRunner) testFindFingerprintMatches() {
+	config.C.PHashDistance = 4
+	defer func() {
+		config.C.PHashDistance = 0
+	}()
+
+	md5 := s.generateSceneFingerprint()
+	phash := &models.FingerprintEditInput{
+		Algorithm:   models.FingerprintAlgorithmPhash,
+		Hash:        "8f8f8f8f8f8f8f8f",
+		Duration:    1234,
+		Submissions: 1,
+		Created:     md5.Created,
+		Updated:     md5.Updated,
+	}
+	title := "title"
+	scene, err := s.createTestScene(&models.SceneCreateInput{
+		Title:        &title,
+		Fingerprints: []*models.FingerprintEditInput{md5, phash},
+	})
+	if err != nil {
+		return
+	}
+
+	input := []*models.FingerprintQueryInput{
+		{Hash: md5.Hash, Algorithm: md5.Algorithm},
+		{Hash: "0f8f8f8f8f8f8f0f", Algorithm: models.FingerprintAlgorithmPhash},
+	}
+
+	verifyMatch := func(result *models.FingerprintMatchResult, hash string, distance int) {
+		s.t.Helper()
+		for _, match := range result.Matches {
+			if match.Scene.ID == scene.ID && match.Hash == hash {
+				if match.Distance != distance {
+					s.fieldMismatch(distance, match.Distance, "Distance")
+				}
+				return
+			}
+		}
+		s.t.Errorf("Fingerprint %s did not match %s", result.Hash, hash)
+	}
+
+	results, err := s.resolver.Query().FindFingerprintMatches(s.ctx, input, nil)
+	if err != nil {
+		s.t.Errorf("Error finding fingerprint matches: %s", err.Error())
+		return
+	}
+	if len(results) != len(input) {
+		s.fieldMismatch(len(input), len(results), "Results")
+		return
+	}
+	verifyMatch(results[0], md5.Hash, 0)
+	verifyMatch(results[1], phash.Hash, 2)
+
+	// the requested distance is respected
+	distance := 1
+	results, err = s.resolver.Query().FindFingerprintMatches(s.ctx, input, &distance)
+	if err != nil {
+		s.t.Errorf("Error finding fingerprint matches: %s", err.Error())
+		return
+	}
+	for _, match := range results[1].Matches {
+		if match.Hash == phash.Hash {
+			s.t.Errorf("Fingerprint matched beyond the requested distance")
+		}
+	}
+}
+
 func (s *sceneTestRunner) testUpdateScene() {
 	title := "Title"
 	details := "Details"
@@ -755,6 +904,239 @@ func (s *sceneTestRunner) testQueryScenesByTag() {
 	s.verifyInvalidModifier(filter)
 }
 
+func (s *sceneTestRunner) getSubmittedFingerprint(scene *models.Scene, hash string) *models.Fingerprint {
+	s.t.Helper()
+
+	// use fresh loaders, since the fingerprints are cached
+	user := s.ctx.Value(api.ContextUser).(*models.User)
+	ctx := context.WithValue(s.ctx, dataloader.GetLoadersKey(), dataloader.GetLoaders(databasetest.Repo(), user.ID))
+	fingerprints, err := s.resolver.Scene().Fingerprints(ctx, scene)
+	if err != nil {
+		s.t.Errorf("Error getting scene fingerprints: %s", err.Error())
+		return nil
+	}
+
+	for _, fingerprint := range fingerprints {
+		if fingerprint.Hash == hash {
+			return fingerprint
+		}
+	}
+
+	return nil
+}
+
+func (s *sceneTestRunner) testSubmitFingerprint() {
+	scene, err := s.createTestScene(nil)
+	if err != nil {
+		return
+	}
+
+	unmatch := true
+	input := models.FingerprintSubmission{
+		SceneID: scene.ID.String(),
+		Fingerprint: &models.FingerprintInput{
+			Hash:      "submitted-" + scene.ID.String(),
+			Algorithm: models.FingerprintAlgorithmMd5,
+			Duration:  1234,
+		},
+	}
+
+	// submitting the same fingerprint twice only counts once
+	for i := 0; i < 2; i++ {
+		if _, err := s.resolver.Mutation().SubmitFingerprint(s.ctx, input); err != nil {
+			s.t.Errorf("Error submitting fingerprint: %s", err.Error())
+			return
+		}
+	}
+
+	fingerprint := s.getSubmittedFingerprint(scene, input.Fingerprint.Hash)
+	if fingerprint == nil {
+		s.t.Errorf("Submitted fingerprint not found")
+		return
+	}
+	if fingerprint.Submissions != 1 {
+		s.fieldMismatch(1, fingerprint.Submissions, "Submissions")
+	}
+	if !fingerprint.UserSubmitted {
+		s.fieldMismatch(true, fingerprint.UserSubmitted, "UserSubmitted")
+	}
+
+	input.Unmatch = &unmatch
+	if _, err := s.resolver.Mutation().SubmitFingerprint(s.ctx, input); err != nil {
+		s.t.Errorf("Error unmatching fingerprint: %s", err.Error())
+		return
+	}
+
+	if fingerprint := s.getSubmittedFingerprint(scene, input.Fingerprint.Hash); fingerprint != nil {
+		s.t.Errorf("Unmatched fingerprint was not removed")
+	}
+}
+
+func (s *sceneTestRunner) testSubmitFingerprints() {
+	scene, err := s.createTestScene(nil)
+	if err != nil {
+		return
+	}
+
+	deletedScene, err := s.createTestScene(nil)
+	if err != nil {
+		return
+	}
+	if _, err := s.resolver.Mutation().SceneDestroy(s.ctx, models.SceneDestroyInput{ID: deletedScene.ID.String()}); err != nil {
+		s.t.Errorf("Error destroying scene: %s", err.Error())
+		return
+	}
+
+	fingerprint := &models.FingerprintInput{
+		Hash:      "batch-" + scene.ID.String(),
+		Algorithm: models.FingerprintAlgorithmMd5,
+		Duration:  1234,
+	}
+	input := []*models.FingerprintSubmission{
+		{SceneID: scene.ID.String(), Fingerprint: fingerprint},
+		{SceneID: scene.ID.String(), Fingerprint: fingerprint},
+		{SceneID: "invalid", Fingerprint: fingerprint},
+		{SceneID: "00000000-0000-0000-0000-000000000001", Fingerprint: fingerprint},
+		{SceneID: deletedScene.ID.String(), Fingerprint: fingerprint},
+	}
+
+	results, err := s.resolver.Mutation().SubmitFingerprints(s.ctx, input)
+	if err != nil {
+		s.t.Errorf("Error submitting fingerprints: %s", err.Error())
+		return
+	}
+
+	if len(results) != len(input) {
+		s.fieldMismatch(len(input), len(results), "Results")
+		return
+	}
+	for i, result := range results {
+		success := i < 2
+		if result.Success != success {
+			s.t.Errorf("Submission %d: got success %v want %v", i, result.Success, success)
+		}
+		if (result.Error == nil) != success {
+			s.t.Errorf("Submission %d: unexpected error %v", i, result.Error)
+		}
+	}
+
+	submitted := s.getSubmittedFingerprint(scene, fingerprint.Hash)
+	if submitted == nil {
+		s.t.Errorf("Submitted fingerprint not found")
+		return
+	}
+	if submitted.Submissions != 1 {
+		s.fieldMismatch(1, submitted.Submissions, "Submissions")
+	}
+}
+
+func (s *sceneTestRunner) testResubmitRemovedFingerprint() {
+	scene, err := s.createTestScene(nil)
+	if err != nil {
+		return
+	}
+
+	input := models.FingerprintSubmission{
+		SceneID: scene.ID.String(),
+		Fingerprint: &models.FingerprintInput{
+			Hash:      "removed-" + scene.ID.String(),
+			Algorithm: models.FingerprintAlgorithmMd5,
+			Duration:  1234,
+		},
+	}
+	if _, err := s.resolver.Mutation().SubmitFingerprint(s.ctx, input); err != nil {
+		s.t.Errorf("Error submitting fingerprint: %s", err.Error())
+		return
+	}
+
+	// removing the fingerprint from the scene removes its submissions
+	updateInput := models.SceneUpdateInput{
+		ID:           scene.ID.String(),
+		Fingerprints: []*models.FingerprintEditInput{s.generateSceneFingerprint()},
+	}
+	if _, err := s.resolver.Mutation().SceneUpdate(s.ctx, updateInput); err != nil {
+		s.t.Errorf("Error updating scene: %s", err.Error())
+		return
+	}
+	if fingerprint := s.getSubmittedFingerprint(scene, input.Fingerprint.Hash); fingerprint != nil {
+		s.t.Errorf("Removed fingerprint was not removed")
+		return
+	}
+
+	if _, err := s.resolver.Mutation().SubmitFingerprint(s.ctx, input); err != nil {
+		s.t.Errorf("Error resubmitting fingerprint: %s", err.Error())
+		return
+	}
+
+	fingerprint := s.getSubmittedFingerprint(scene, input.Fingerprint.Hash)
+	if fingerprint == nil {
+		s.t.Errorf("Resubmitted fingerprint not found")
+		return
+	}
+	if fingerprint.Submissions != 1 {
+		s.fieldMismatch(1, fingerprint.Submissions, "Submissions")
+	}
+	if !fingerprint.UserSubmitted {
+		s.fieldMismatch(true, fingerprint.UserSubmitted, "UserSubmitted")
+	}
+}
+
+func (s *sceneTestRunner) testUnmatchLegacyFingerprint() {
+	// fingerprints submitted before user submissions were recorded have a
+	// count without any user submissions
+	legacy := s.generateSceneFingerprint()
+	legacy.Submissions = 3
+	title := "title"
+	scene, err := s.createTestScene(&models.SceneCreateInput{
+		Title:        &title,
+		Fingerprints: []*models.FingerprintEditInput{legacy},
+	})
+	if err != nil {
+		return
+	}
+
+	unmatch := true
+	input := models.FingerprintSubmission{
+		SceneID: scene.ID.String(),
+		Fingerprint: &models.FingerprintInput{
+			Hash:      legacy.Hash,
+			Algorithm: legacy.Algorithm,
+			Duration:  legacy.Duration,
+		},
+	}
+	if _, err := s.resolver.Mutation().SubmitFingerprint(s.ctx, input); err != nil {
+		s.t.Errorf("Error submitting fingerprint: %s", err.Error())
+		return
+	}
+
+	fingerprint := s.getSubmittedFingerprint(scene, legacy.Hash)
+	if fingerprint == nil {
+		s.t.Errorf("Submitted fingerprint not found")
+		return
+	}
+	if fingerprint.Submissions != 4 {
+		s.fieldMismatch(4, fingerprint.Submissions, "Submissions")
+	}
+
+	input.Unmatch = &unmatch
+	if _, err := s.resolver.Mutation().SubmitFingerprint(s.ctx, input); err != nil {
+		s.t.Errorf("Error unmatching fingerprint: %s", err.Error())
+		return
+	}
+
+	fingerprint = s.getSubmittedFingerprint(scene, legacy.Hash)
+	if fingerprint == nil {
+		s.t.Errorf("Fingerprint submitted by other users was removed")
+		return
+	}
+	if fingerprint.Submissions != 3 {
+		s.fieldMismatch(3, fingerprint.Submissions, "Submissions")
+	}
+	if fingerprint.UserSubmitted {
+		s.fieldMismatch(false, fingerprint.UserSubmitted, "UserSubmitted")
+	}
+}
+
 func (s *sceneTestRunner) testUnauthorisedSceneModify() {
 	// test each api interface - all require modify so all should fail
 	_, err := s.resolver.Mutation().SceneCreate(s.ctx, models.SceneCreateInput{})
@@ -806,6 +1188,21 @@ func TestFindScenesByFingerprints(t *testing.T) {
 	pt.testFindScenesByFingerprints()
 }
 
+func TestFindSceneByFingerprintDuration(t *testing.T) {
+	pt := createSceneTestRunner(t)
+	pt.testFindSceneByFingerprintDuration()
+}
+
+func TestFindScenesByFullFingerprintsPHash(t *testing.T) {
+	pt := createSceneTestRunner(t)
+	pt.testFindScenesByFullFingerprintsPHash()
+}
+
+func TestFindFingerprintMatches(t *testing.T) {
+	pt := createSceneTestRunner(t)
+	pt.testFindFingerprintMatches()
+}
+
 func TestUpdateScene(t *testing.T) {
 	pt := createSceneTestRunner(t)
 	pt.testUpdateScene()
@@ -819,6 +1216,26 @@ func TestDestroyScene(t *testing.T) {
 	pt.testDestroyScene()
 }
 
+func TestSubmitFingerprint(t *testing.T) {
+	pt := createSceneTestRunner(t)
+	pt.testSubmitFingerprint()
+}
+
+func TestSubmitFingerprints(t *testing.T) {
+	pt := createSceneTestRunner(t)
+	pt.testSubmitFingerprints()
+}
+
+func TestResubmitRemovedFingerprint(t *testing.T) {
+	pt := createSceneTestRunner(t)
+	pt.testResubmitRemovedFingerprint()
+}
+
+func TestUnmatchLegacyFingerprint(t *testing.T) {
+	pt := createSceneTestRunner(t)
+	pt.testUnmatchLegacyFingerprint()
+}
+
 func TestQueryScenesByStudio(t *testing.T) {
 	pt := createSceneTestRunner(t)
 	pt.testQueryScenesByStudio()
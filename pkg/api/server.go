@@ -8,22 +8,30 @@ import (
 	"fmt"
 	"io/fs"
 	"io/ioutil"
+	"math"
+	"net"
 	"net/http"
 	"path"
 	"runtime/debug"
 	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
 
 	gqlHandler "github.com/99designs/gqlgen/graphql/handler"
 	gqlExtension "github.com/99designs/gqlgen/graphql/handler/extension"
+	gqlLRU "github.com/99designs/gqlgen/graphql/handler/lru"
 	gqlTransport "github.com/99designs/gqlgen/graphql/handler/transport"
 	gqlPlayground "github.com/99designs/gqlgen/graphql/playground"
 	"github.com/go-chi/chi"
 	"github.com/go-chi/chi/middleware"
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/rs/cors"
 	"github.com/stashapp/stash-box/pkg/dataloader"
 	"github.com/stashapp/stash-box/pkg/logger"
 	"github.com/stashapp/stash-box/pkg/manager/config"
+	"github.com/stashapp/stash-box/pkg/manager/cron"
 	"github.com/stashapp/stash-box/pkg/manager/paths"
 	"github.com/stashapp/stash-box/pkg/models"
 	"github.com/stashapp/stash-box/pkg/user"
@@ -35,6 +43,10 @@ var githash string
 
 const APIKeyHeader = "ApiKey"
 
+// databaseRetryAfter is the Retry-After time sent while the database is not
+// available.
+const databaseRetryAfter = 10 * time.Second
+
 func getUserAndRoles(fac models.Repo, userID string) (*models.User, []models.RoleEnum, error) {
 	u, err := user.Get(fac, userID)
 	if err != nil {
@@ -49,7 +61,7 @@ func getUserAndRoles(fac models.Repo, userID string) (*models.User, []models.Rol
 	return u, roles, nil
 }
 
-func authenticateHandler() func(http.Handler) http.Handler {
+func authenticateHandler(apiCalls *user.APICallCounter, rateLimiter *user.RateLimiter) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			ctx := r.Context()
@@ -83,7 +95,18 @@ func authenticateHandler() func(http.Handler) http.Handler {
 				return
 			}
 
-			// TODO - increment api key counters
+			if apiKey != "" && user != nil {
+				allowed, wait := rateLimiter.Allow(user.ID, roles)
+				if !allowed {
+					retryAfter := int(math.Ceil(wait.Seconds()))
+					w.Header().Set("Retry-After", strconv.Itoa(retryAfter))
+					w.WriteHeader(http.StatusTooManyRequests)
+					_, _ = w.Write([]byte("API rate limit exceeded"))
+					return
+				}
+
+				apiCalls.Increment(user.ID)
+			}
 
 			ctx = context.WithValue(ctx, ContextUser, user)
 			ctx = context.WithValue(ctx, ContextRoles, roles)
@@ -103,7 +126,35 @@ func redirect(w http.ResponseWriter, req *http.Request) {
 	http.Redirect(w, req, target, http.StatusPermanentRedirect)
 }
 
-func Start(rfp RepoProvider, ui embed.FS) {
+// Server is a running stash-box server.
+type Server struct {
+	rfp      RepoProvider
+	servers  []*http.Server
+	apiCalls *user.APICallCounter
+	counts   prometheus.Collector
+	errors   chan error
+
+	// ready is set once the database is available. The scheduler is started
+	// then, unless the server has been shut down.
+	ready     int32
+	mutex     sync.Mutex
+	scheduler *cron.Scheduler
+	stopped   bool
+}
+
+// Start starts the server, returning an error if it cannot listen on the
+// configured address. The readiness checks are served by /readyz along with
+// the checks of the image backend and email settings. The API responds with
+// 503 until SetReady is called.
+func Start(rfp RepoProvider, ui embed.FS, checks ...ReadinessCheck) (*Server, error) {
+	apiCalls := user.NewAPICallCounter()
+	s := &Server{
+		rfp:      rfp,
+		apiCalls: apiCalls,
+		counts:   newCountsCollector(rfp),
+		errors:   make(chan error, 2),
+	}
+
 	r := chi.NewRouter()
 
 	var corsConfig *cors.Cors
@@ -119,7 +170,7 @@ func Start(rfp RepoProvider, ui embed.FS) {
 
 	r.Use(corsConfig.Handler)
 	r.Use(repoMiddleware(rfp))
-	r.Use(authenticateHandler())
+	r.Use(authenticateHandler(apiCalls, user.NewRateLimiter()))
 	r.Use(middleware.Recoverer)
 
 	r.Use(middleware.DefaultCompress)
@@ -134,15 +185,42 @@ func Start(rfp RepoProvider, ui embed.FS) {
 		return errors.New(message)
 	}
 
-	gqlSrv := gqlHandler.New(models.NewExecutableSchema(models.Config{Resolvers: NewResolver(getRepo)}))
+	gqlSrv := gqlHandler.New(models.NewExecutableSchema(models.Config{
+		Resolvers:  NewResolver(getRepo),
+		Complexity: newComplexityRoot(config.GetMaxPerPage()),
+	}))
 	gqlSrv.SetRecoverFunc(recoverFunc)
+	gqlSrv.SetErrorPresenter(errorPresenter)
+	gqlSrv.SetQueryCache(gqlLRU.New(1000))
+	gqlSrv.AddTransport(gqlTransport.Websocket{
+		KeepAlivePingInterval: 10 * time.Second,
+	})
 	gqlSrv.AddTransport(gqlTransport.Options{})
 	gqlSrv.AddTransport(gqlTransport.GET{})
 	gqlSrv.AddTransport(gqlTransport.POST{})
 	gqlSrv.AddTransport(gqlTransport.MultipartForm{})
-	gqlSrv.Use(gqlExtension.Introspection{})
+	if config.GetIntrospectionEnabled() {
+		gqlSrv.Use(gqlExtension.Introspection{})
+	}
+	gqlSrv.Use(queryLimits{
+		maxDepth:   config.GetMaxQueryDepth(),
+		maxPerPage: config.GetMaxPerPage(),
+	})
+	if limit := config.GetMaxQueryComplexity(); limit > 0 {
+		gqlSrv.Use(gqlExtension.FixedComplexityLimit(limit))
+	}
+	if size := config.GetPersistedQueryCacheSize(); size > 0 {
+		cache := newPersistedQueryCache(size, config.GetPersistedQueryStore(), config.GetPersistedQueryStoreSize())
+		gqlSrv.Use(gqlExtension.AutomaticPersistedQuery{Cache: cache})
+		// runs after the query limits, caching only valid queries
+		gqlSrv.Use(cache)
+	}
+	gqlSrv.Use(queryCacheExtension{})
+	gqlSrv.Use(metricsExtension{})
+
+	r.With(s.requireReady).Handle("/graphql", queryCacheMiddleware(config.GetQueryCacheMaxAge())(dataloader.Middleware(rfp.Repo(), currentUserID)(gqlSrv)))
 
-	r.Handle("/graphql", dataloader.Middleware(rfp.Repo())(gqlSrv))
+	r.Handle("/metrics", metricsHandler())
 
 	if !config.GetIsProduction() {
 		r.Handle("/playground", gqlPlayground.Handler("GraphQL playground", "/graphql"))
@@ -163,7 +241,7 @@ func Start(rfp RepoProvider, ui embed.FS) {
 	})
 	r.HandleFunc("/logout", handleLogout)
 
-	r.Mount("/image", imageRoutes{}.Routes())
+	r.With(s.requireReady).Mount("/image", imageRoutes{}.Routes())
 
 	// Serve the web app
 	r.HandleFunc("/*", func(w http.ResponseWriter, r *http.Request) {
@@ -187,37 +265,126 @@ func Start(rfp RepoProvider, ui embed.FS) {
 		}
 	})
 
+	// health checks are served without authentication or sessions
+	root := chi.NewRouter()
+	root.Get("/healthz", handleHealth)
+	root.Get("/readyz", readinessHandler(append(checks, defaultReadinessChecks()...)))
+	root.Mount("/", r)
+
+	if err := prometheus.Register(s.counts); err != nil {
+		return nil, err
+	}
+
 	address := config.GetHost() + ":" + strconv.Itoa(config.GetPort())
-	if tlsConfig := makeTLSConfig(); tlsConfig != nil {
-		httpsServer := &http.Server{
-			Addr:      address,
-			Handler:   r,
-			TLSConfig: tlsConfig,
-		}
+	listener, err := net.Listen("tcp", address)
+	if err != nil {
+		prometheus.Unregister(s.counts)
+		return nil, err
+	}
 
+	printVersion()
+	if tlsConfig := makeTLSConfig(); tlsConfig != nil {
 		if config.GetHTTPUpgrade() {
-			go func() {
-				logger.Fatal(http.ListenAndServe(config.GetHost()+":80", http.HandlerFunc(redirect)))
-			}()
+			redirectListener, err := net.Listen("tcp", config.GetHost()+":80")
+			if err != nil {
+				_ = listener.Close()
+				prometheus.Unregister(s.counts)
+				return nil, err
+			}
+
+			s.serve(&http.Server{Handler: http.HandlerFunc(redirect)}, func(server *http.Server) error {
+				return server.Serve(redirectListener)
+			})
 		}
 
-		go func() {
-			printVersion()
-			logger.Infof("stash-box is running on HTTPS at https://" + address + "/")
-			logger.Fatal(httpsServer.ListenAndServeTLS("", ""))
-		}()
+		logger.Infof("stash-box is running on HTTPS at https://" + address + "/")
+		s.serve(&http.Server{Handler: root, TLSConfig: tlsConfig}, func(server *http.Server) error {
+			return server.ServeTLS(listener, "", "")
+		})
 	} else {
-		server := &http.Server{
-			Addr:    address,
-			Handler: r,
+		logger.Infof("stash-box is running on HTTP at http://" + address + "/")
+		s.serve(&http.Server{Handler: root}, func(server *http.Server) error {
+			return server.Serve(listener)
+		})
+	}
+
+	return s, nil
+}
+
+// SetReady marks the database as available, serving the API and starting
+// the background jobs.
+func (s *Server) SetReady() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.stopped || s.scheduler != nil {
+		return
+	}
+
+	s.scheduler = startJobs(s.rfp, s.apiCalls)
+	atomic.StoreInt32(&s.ready, 1)
+}
+
+// requireReady responds with 503 until the database is available.
+func (s *Server) requireReady(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.LoadInt32(&s.ready) == 0 {
+			w.Header().Set("Retry-After", strconv.Itoa(int(databaseRetryAfter.Seconds())))
+			http.Error(w, "stash-box is starting", http.StatusServiceUnavailable)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// serve runs the server in the background, reporting errors other than the
+// server being shut down.
+func (s *Server) serve(server *http.Server, fn func(server *http.Server) error) {
+	s.servers = append(s.servers, server)
+
+	go func() {
+		if err := fn(server); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			s.errors <- err
+		}
+	}()
+}
+
+// Errors returns a channel receiving errors which stopped the server.
+func (s *Server) Errors() <-chan error {
+	return s.errors
+}
+
+// Shutdown stops accepting requests and waits for in-flight requests to
+// finish, until the context is done. Background jobs are then stopped, and
+// the remaining API call counts are written to the database.
+func (s *Server) Shutdown(ctx context.Context) error {
+	var ret error
+	for _, server := range s.servers {
+		if err := server.Shutdown(ctx); err != nil && ret == nil {
+			ret = err
+		}
+	}
+
+	s.mutex.Lock()
+	s.stopped = true
+	scheduler := s.scheduler
+	s.mutex.Unlock()
+
+	// API calls are only counted once the database is available
+	if scheduler != nil {
+		if err := scheduler.Stop(ctx); err != nil && ret == nil {
+			ret = fmt.Errorf("error stopping background jobs: %w", err)
 		}
 
-		go func() {
-			printVersion()
-			logger.Infof("stash-box is running on HTTP at http://" + address + "/")
-			logger.Fatal(server.ListenAndServe())
-		}()
+		if err := s.apiCalls.Flush(s.rfp.Repo(), config.GetAPICallWindow()); err != nil && ret == nil {
+			ret = fmt.Errorf("error writing API call counts: %w", err)
+		}
 	}
+
+	prometheus.Unregister(s.counts)
+
+	return ret
 }
 
 func printVersion() {
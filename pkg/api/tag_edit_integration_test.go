@@ -6,6 +6,7 @@ import (
 	"reflect"
 	"testing"
 
+	"github.com/stashapp/stash-box/pkg/api"
 	"github.com/stashapp/stash-box/pkg/models"
 )
 
@@ -545,6 +546,90 @@ func (s *tagEditTestRunner) verifyAppliedMergeTagEdit(input models.TagEditDetail
 	}
 }
 
+func (s *tagEditTestRunner) testAmendTagEdit() {
+	createdEdit, err := s.createTestTagEdit(models.OperationEnumCreate, nil, nil)
+	if err != nil {
+		return
+	}
+
+	voter := asEdit(s.t)
+	voteInput := models.EditVoteInput{
+		ID:   createdEdit.ID.String(),
+		Type: models.VoteTypeEnumAccept,
+	}
+	if _, err := voter.resolver.Mutation().EditVote(voter.ctx, voteInput); err != nil {
+		s.t.Errorf("Error voting on edit: %s", err.Error())
+		return
+	}
+
+	editID := createdEdit.ID.String()
+	name := s.generateTagName()
+	tagEditDetailsInput := models.TagEditDetailsInput{
+		Name: &name,
+	}
+	editInput := models.EditInput{
+		Operation: models.OperationEnumCreate,
+		EditID:    &editID,
+	}
+	amendedEdit, err := s.createTestTagEdit(models.OperationEnumCreate, &tagEditDetailsInput, &editInput)
+	if err != nil {
+		return
+	}
+
+	if amendedEdit.ID != createdEdit.ID {
+		s.fieldMismatch(createdEdit.ID, amendedEdit.ID, "ID")
+	}
+
+	r := s.resolver.Edit()
+	details, _ := r.Details(s.ctx, amendedEdit)
+	tagDetails := details.(*models.TagEdit)
+	if *tagDetails.Name != name {
+		s.fieldMismatch(name, *tagDetails.Name, "Name")
+	}
+
+	// votes are cleared when the edit is amended
+	if amendedEdit.VoteCount != 0 {
+		s.fieldMismatch(0, amendedEdit.VoteCount, "Vote count")
+	}
+	votes, _ := r.Votes(s.ctx, amendedEdit)
+	if len(votes) != 0 {
+		s.fieldMismatch(0, len(votes), "Votes")
+	}
+
+	amendments, _ := r.Amendments(s.ctx, amendedEdit)
+	if len(amendments) != 1 {
+		s.fieldMismatch(1, len(amendments), "Amendments")
+	} else if amendments[0].VoteCount != 1 {
+		s.fieldMismatch(1, amendments[0].VoteCount, "Amendment vote count")
+	}
+}
+
+func (s *tagEditTestRunner) testUnauthorisedAmendTagEdit() {
+	createdEdit, err := s.createTestTagEdit(models.OperationEnumCreate, nil, nil)
+	if err != nil {
+		return
+	}
+
+	editID := createdEdit.ID.String()
+	name := s.generateTagName()
+	tagEditInput := models.TagEditInput{
+		Edit: &models.EditInput{
+			Operation: models.OperationEnumCreate,
+			EditID:    &editID,
+		},
+		Details: &models.TagEditDetailsInput{
+			Name: &name,
+		},
+	}
+
+	// only the submitter or an admin may amend an edit
+	other := asEdit(s.t)
+	_, err = other.resolver.Mutation().TagEdit(other.ctx, tagEditInput)
+	if err != api.ErrUnauthorized {
+		s.t.Errorf("TagEdit: got %v want %v", err, api.ErrUnauthorized)
+	}
+}
+
 func TestCreateTagEdit(t *testing.T) {
 	pt := createTagEditTestRunner(t)
 	pt.testCreateTagEdit()
@@ -584,3 +669,13 @@ func TestApplyMergeTagEdit(t *testing.T) {
 	pt := createTagEditTestRunner(t)
 	pt.testApplyMergeTagEdit()
 }
+
+func TestAmendTagEdit(t *testing.T) {
+	pt := createTagEditTestRunner(t)
+	pt.testAmendTagEdit()
+}
+
+func TestUnauthorisedAmendTagEdit(t *testing.T) {
+	pt := createTagEditTestRunner(t)
+	pt.testUnauthorisedAmendTagEdit()
+}
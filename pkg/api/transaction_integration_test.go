@@ -0,0 +1,119 @@
+//go:build integration
+// +build integration
+
+package api_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/gofrs/uuid"
+
+	"github.com/stashapp/stash-box/pkg/database/databasetest"
+	"github.com/stashapp/stash-box/pkg/models"
+)
+
+type transactionTestRunner struct {
+	testRunner
+}
+
+func createTransactionTestRunner(t *testing.T) *transactionTestRunner {
+	return &transactionTestRunner{
+		testRunner: *asAdmin(t),
+	}
+}
+
+func (s *transactionTestRunner) newTag() models.Tag {
+	now := models.SQLiteTimestamp{Timestamp: time.Now()}
+	return models.Tag{
+		ID:        uuid.Must(uuid.NewV4()),
+		Name:      s.generateTagName(),
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+}
+
+func (s *transactionTestRunner) tagExists(id uuid.UUID) bool {
+	s.t.Helper()
+
+	repo := databasetest.Repo()
+	var tag *models.Tag
+	err := repo.WithTxn(func() error {
+		var err error
+		tag, err = repo.Tag().Find(id)
+		return err
+	})
+	if err != nil {
+		s.t.Fatalf("Error finding tag: %s", err.Error())
+	}
+
+	return tag != nil
+}
+
+func (s *transactionTestRunner) testRollback() {
+	errFailed := errors.New("failed")
+	repo := databasetest.Repo()
+
+	tag := s.newTag()
+	err := repo.WithTxn(func() error {
+		if _, err := repo.Tag().Create(tag); err != nil {
+			return err
+		}
+		return errFailed
+	})
+	if !errors.Is(err, errFailed) {
+		s.t.Errorf("WithTxn: got error %v want %v", err, errFailed)
+	}
+	if s.tagExists(tag.ID) {
+		s.t.Error("tag created in a failed transaction was committed")
+	}
+
+	// an error in a nested transaction rolls back the outer transaction
+	outer := s.newTag()
+	inner := s.newTag()
+	err = repo.WithTxn(func() error {
+		if _, err := repo.Tag().Create(outer); err != nil {
+			return err
+		}
+		return repo.WithTxn(func() error {
+			if _, err := repo.Tag().Create(inner); err != nil {
+				return err
+			}
+			return errFailed
+		})
+	})
+	if !errors.Is(err, errFailed) {
+		s.t.Errorf("nested WithTxn: got error %v want %v", err, errFailed)
+	}
+	if s.tagExists(outer.ID) || s.tagExists(inner.ID) {
+		s.t.Error("tag created in a failed nested transaction was committed")
+	}
+}
+
+func (s *transactionTestRunner) testCommit() {
+	repo := databasetest.Repo()
+
+	tag := s.newTag()
+	err := repo.WithTxn(func() error {
+		_, err := repo.Tag().Create(tag)
+		return err
+	})
+	if err != nil {
+		s.t.Errorf("Error creating tag: %s", err.Error())
+		return
+	}
+	if !s.tagExists(tag.ID) {
+		s.t.Error("tag created in a successful transaction was not committed")
+	}
+}
+
+func TestTransactionRollback(t *testing.T) {
+	pt := createTransactionTestRunner(t)
+	pt.testRollback()
+}
+
+func TestTransactionCommit(t *testing.T) {
+	pt := createTransactionTestRunner(t)
+	pt.testCommit()
+}
@@ -8,6 +8,8 @@ import (
 	"time"
 
 	"github.com/stashapp/stash-box/pkg/api"
+	dbtest "github.com/stashapp/stash-box/pkg/database/databasetest"
+	"github.com/stashapp/stash-box/pkg/manager/edit"
 	"github.com/stashapp/stash-box/pkg/models"
 )
 
@@ -488,6 +490,204 @@ func (s *userTestRunner) testUserEditQuery() {
 	// TODO: Test edits are returned
 }
 
+func (s *userTestRunner) testUserEditCounts() {
+	submitter, err := s.createTestUser(nil)
+	if err != nil {
+		return
+	}
+	voter, err := s.createTestUser(nil)
+	if err != nil {
+		return
+	}
+
+	roles := []models.RoleEnum{models.RoleEnumEdit}
+	submitterRunner := createTestRunner(s.t, submitter, roles)
+	voterRunner := createTestRunner(s.t, voter, roles)
+
+	acceptedEdit, err := submitterRunner.createTestTagEdit(models.OperationEnumCreate, nil, nil)
+	if err != nil {
+		return
+	}
+	rejectedEdit, err := submitterRunner.createTestTagEdit(models.OperationEnumCreate, nil, nil)
+	if err != nil {
+		return
+	}
+
+	voteInput := models.EditVoteInput{
+		ID:   acceptedEdit.ID.String(),
+		Type: models.VoteTypeEnumAccept,
+	}
+	if _, err := voterRunner.resolver.Mutation().EditVote(voterRunner.ctx, voteInput); err != nil {
+		s.t.Errorf("Error voting on edit: %s", err.Error())
+		return
+	}
+
+	if _, err := s.resolver.Mutation().ApplyEdit(s.ctx, models.ApplyEditInput{ID: acceptedEdit.ID.String()}); err != nil {
+		s.t.Errorf("Error applying edit: %s", err.Error())
+		return
+	}
+	if _, err := submitterRunner.resolver.Mutation().CancelEdit(submitterRunner.ctx, models.CancelEditInput{ID: rejectedEdit.ID.String()}); err != nil {
+		s.t.Errorf("Error cancelling edit: %s", err.Error())
+		return
+	}
+
+	r := s.resolver.User()
+	if count, _ := r.SuccessfulEdits(s.ctx, submitter); count != 1 {
+		s.fieldMismatch(1, count, "SuccessfulEdits")
+	}
+	if count, _ := r.UnsuccessfulEdits(s.ctx, submitter); count != 1 {
+		s.fieldMismatch(1, count, "UnsuccessfulEdits")
+	}
+	if count, _ := r.SuccessfulVotes(s.ctx, voter); count != 1 {
+		s.fieldMismatch(1, count, "SuccessfulVotes")
+	}
+	if count, _ := r.UnsuccessfulVotes(s.ctx, voter); count != 0 {
+		s.fieldMismatch(0, count, "UnsuccessfulVotes")
+	}
+
+	userName := voter.Name
+	userFilter := models.UserFilterType{
+		Name: &userName,
+		SuccessfulVotes: &models.IntCriterionInput{
+			Value:    0,
+			Modifier: models.CriterionModifierGreaterThan,
+		},
+	}
+	result, err := s.resolver.Query().QueryUsers(s.ctx, &userFilter, nil)
+	if err != nil {
+		s.t.Errorf("Error querying users: %s", err.Error())
+		return
+	}
+	if result.Count != 1 {
+		s.t.Errorf("Expected %d users, got %d", 1, result.Count)
+	}
+}
+
+func (s *userTestRunner) testUserVoteCounts() {
+	submitter, err := s.createTestUser(nil)
+	if err != nil {
+		return
+	}
+	voter, err := s.createTestUser(nil)
+	if err != nil {
+		return
+	}
+
+	roles := []models.RoleEnum{models.RoleEnumEdit}
+	submitterRunner := createTestRunner(s.t, submitter, roles)
+	voterRunner := createTestRunner(s.t, voter, roles)
+
+	vote := func(edit *models.Edit, voteType models.VoteTypeEnum) error {
+		voteInput := models.EditVoteInput{
+			ID:   edit.ID.String(),
+			Type: voteType,
+		}
+		_, err := voterRunner.resolver.Mutation().EditVote(voterRunner.ctx, voteInput)
+		if err != nil {
+			s.t.Errorf("Error voting on edit: %s", err.Error())
+		}
+		return err
+	}
+
+	// a reject vote on an accepted edit is unsuccessful
+	acceptedEdit, err := submitterRunner.createTestTagEdit(models.OperationEnumCreate, nil, nil)
+	if err != nil {
+		return
+	}
+	if err := vote(acceptedEdit, models.VoteTypeEnumReject); err != nil {
+		return
+	}
+	if _, err := s.resolver.Mutation().ApplyEdit(s.ctx, models.ApplyEditInput{ID: acceptedEdit.ID.String()}); err != nil {
+		s.t.Errorf("Error applying edit: %s", err.Error())
+		return
+	}
+
+	// a reject vote on a rejected edit is successful
+	rejectedEdit, err := submitterRunner.createTestTagEdit(models.OperationEnumCreate, nil, nil)
+	if err != nil {
+		return
+	}
+	if err := vote(rejectedEdit, models.VoteTypeEnumReject); err != nil {
+		return
+	}
+	if _, err := submitterRunner.resolver.Mutation().CancelEdit(submitterRunner.ctx, models.CancelEditInput{ID: rejectedEdit.ID.String()}); err != nil {
+		s.t.Errorf("Error cancelling edit: %s", err.Error())
+		return
+	}
+
+	r := s.resolver.User()
+	if count, _ := r.SuccessfulVotes(s.ctx, voter); count != 1 {
+		s.fieldMismatch(1, count, "SuccessfulVotes")
+	}
+	if count, _ := r.UnsuccessfulVotes(s.ctx, voter); count != 1 {
+		s.fieldMismatch(1, count, "UnsuccessfulVotes")
+	}
+}
+
+func (s *userTestRunner) testFailedEditCounts() {
+	submitter, err := s.createTestUser(nil)
+	if err != nil {
+		return
+	}
+	voter, err := s.createTestUser(nil)
+	if err != nil {
+		return
+	}
+
+	roles := []models.RoleEnum{models.RoleEnumEdit}
+	submitterRunner := createTestRunner(s.t, submitter, roles)
+	voterRunner := createTestRunner(s.t, voter, roles)
+
+	name := s.generateTagName()
+	failedEdit, err := submitterRunner.createTestTagEdit(models.OperationEnumCreate, &models.TagEditDetailsInput{
+		Name: &name,
+	}, nil)
+	if err != nil {
+		return
+	}
+
+	// the edit fails to apply while a tag with the same name exists
+	tag, err := s.createTestTag(&models.TagCreateInput{Name: name})
+	if err != nil {
+		return
+	}
+	voteInput := models.EditVoteInput{
+		ID:   failedEdit.ID.String(),
+		Type: models.VoteTypeEnumAccept,
+	}
+	if _, err := voterRunner.resolver.Mutation().EditVote(voterRunner.ctx, voteInput); err != nil {
+		s.t.Errorf("Error voting on edit: %s", err.Error())
+		return
+	}
+	if err := edit.ResolveEdits(dbtest.Repo(), 1, time.Hour); err != nil {
+		s.t.Errorf("Error resolving edits: %s", err.Error())
+		return
+	}
+
+	r := s.resolver.User()
+	if count, _ := r.SuccessfulEdits(s.ctx, submitter); count != 0 {
+		s.fieldMismatch(0, count, "SuccessfulEdits")
+	}
+
+	// the failed edit is counted once it is applied
+	newName := s.generateTagName()
+	if _, err := s.resolver.Mutation().TagUpdate(s.ctx, models.TagUpdateInput{ID: tag.ID.String(), Name: &newName}); err != nil {
+		s.t.Errorf("Error updating tag: %s", err.Error())
+		return
+	}
+	if _, err := s.resolver.Mutation().ApplyEdit(s.ctx, models.ApplyEditInput{ID: failedEdit.ID.String()}); err != nil {
+		s.t.Errorf("Error applying edit: %s", err.Error())
+		return
+	}
+
+	if count, _ := r.SuccessfulEdits(s.ctx, submitter); count != 1 {
+		s.fieldMismatch(1, count, "SuccessfulEdits")
+	}
+	if count, _ := r.SuccessfulVotes(s.ctx, voter); count != 1 {
+		s.fieldMismatch(1, count, "SuccessfulVotes")
+	}
+}
+
 func TestCreateUser(t *testing.T) {
 	pt := createUserTestRunner(t)
 	pt.testCreateUser()
@@ -563,3 +763,18 @@ func TestUserEditQuery(t *testing.T) {
 	pt := createUserTestRunner(t)
 	pt.testUserEditQuery()
 }
+
+func TestUserEditCounts(t *testing.T) {
+	pt := createUserTestRunner(t)
+	pt.testUserEditCounts()
+}
+
+func TestUserVoteCounts(t *testing.T) {
+	pt := createUserTestRunner(t)
+	pt.testUserVoteCounts()
+}
+
+func TestFailedEditCounts(t *testing.T) {
+	pt := createUserTestRunner(t)
+	pt.testFailedEditCounts()
+}
@@ -0,0 +1,139 @@
+//go:build integration
+// +build integration
+
+package api_test
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stashapp/stash-box/pkg/database/databasetest"
+	"github.com/stashapp/stash-box/pkg/models"
+	"github.com/stashapp/stash-box/pkg/webhook"
+)
+
+type webhookTestRunner struct {
+	testRunner
+}
+
+func createWebhookTestRunner(t *testing.T) *webhookTestRunner {
+	return &webhookTestRunner{
+		testRunner: *asAdmin(t),
+	}
+}
+
+func (s *webhookTestRunner) testCreateWebhook() {
+	input := models.WebhookCreateInput{
+		URL:    "https://example.com/webhook",
+		Events: []models.WebhookEventEnum{models.WebhookEventEnumEditApplied},
+	}
+
+	created, err := s.resolver.Mutation().WebhookCreate(s.ctx, input)
+	if err != nil {
+		s.t.Errorf("Error creating webhook: %s", err.Error())
+		return
+	}
+	defer s.destroyWebhook(created)
+
+	if created.URL != input.URL {
+		s.t.Errorf("URL: got %s want %s", created.URL, input.URL)
+	}
+	if created.Secret == "" {
+		s.t.Error("Secret not generated")
+	}
+
+	events, _ := s.resolver.Webhook().Events(s.ctx, created)
+	if len(events) != 1 || events[0] != models.WebhookEventEnumEditApplied {
+		s.t.Errorf("Events: got %v want %v", events, input.Events)
+	}
+
+	input.URL = "ftp://example.com/webhook"
+	if _, err := s.resolver.Mutation().WebhookCreate(s.ctx, input); err == nil {
+		s.t.Error("Expected error creating webhook with invalid URL")
+	}
+}
+
+func (s *webhookTestRunner) testDeliverEditEvents() {
+	var mu sync.Mutex
+	var payloads []webhook.Payload
+	var secret string
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+
+		mu.Lock()
+		defer mu.Unlock()
+		if got := r.Header.Get(webhook.SignatureHeader); got != webhook.Sign(secret, body) {
+			s.t.Errorf("signature: got %s want %s", got, webhook.Sign(secret, body))
+		}
+
+		var payload webhook.Payload
+		if err := json.Unmarshal(body, &payload); err != nil {
+			s.t.Errorf("Error decoding payload: %s", err.Error())
+		}
+		payloads = append(payloads, payload)
+	}))
+	defer receiver.Close()
+
+	created, err := s.resolver.Mutation().WebhookCreate(s.ctx, models.WebhookCreateInput{
+		URL:    receiver.URL,
+		Events: []models.WebhookEventEnum{models.WebhookEventEnumEditCreated, models.WebhookEventEnumEditApplied},
+	})
+	if err != nil {
+		s.t.Errorf("Error creating webhook: %s", err.Error())
+		return
+	}
+	defer s.destroyWebhook(created)
+	secret = created.Secret
+
+	createdEdit, err := s.createTestTagEdit(models.OperationEnumCreate, nil, nil)
+	if err != nil {
+		return
+	}
+	if _, err := s.applyEdit(createdEdit.ID.String()); err != nil {
+		return
+	}
+
+	d := &webhook.Deliverer{Client: receiver.Client(), RetryDelay: time.Minute, MaxAttempts: 1}
+	if err := d.Deliver(context.Background(), databasetest.Repo()); err != nil {
+		s.t.Errorf("Error delivering webhooks: %s", err.Error())
+		return
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	// deliveries are not necessarily sent in order
+	events := make(map[models.WebhookEventEnum]int)
+	for _, payload := range payloads {
+		if payload.Edit.ID == createdEdit.ID {
+			events[payload.Event]++
+		}
+	}
+
+	if len(events) != 2 || events[models.WebhookEventEnumEditCreated] != 1 || events[models.WebhookEventEnumEditApplied] != 1 {
+		s.t.Errorf("events: got %v want one each of %s and %s", events, models.WebhookEventEnumEditCreated, models.WebhookEventEnumEditApplied)
+	}
+}
+
+func (s *webhookTestRunner) destroyWebhook(w *models.Webhook) {
+	input := models.WebhookDestroyInput{ID: w.ID.String()}
+	if _, err := s.resolver.Mutation().WebhookDestroy(s.ctx, input); err != nil {
+		s.t.Errorf("Error destroying webhook: %s", err.Error())
+	}
+}
+
+func TestCreateWebhook(t *testing.T) {
+	pt := createWebhookTestRunner(t)
+	pt.testCreateWebhook()
+}
+
+func TestDeliverEditEvents(t *testing.T) {
+	pt := createWebhookTestRunner(t)
+	pt.testDeliverEditEvents()
+}
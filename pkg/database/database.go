@@ -1,25 +1,94 @@
 package database
 
 import (
+	"context"
+	"fmt"
+
 	"github.com/jmoiron/sqlx"
 )
 
-var appSchemaVersion uint = 16
+var appSchemaVersion uint = 31
 var databaseProviders map[string]databaseProvider
 
+// MigrationError is returned by Migrate when the database was reached but
+// could not be migrated, which retrying will not resolve.
+type MigrationError struct {
+	Err error
+}
+
+func (e *MigrationError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *MigrationError) Unwrap() error {
+	return e.Err
+}
+
 type databaseProvider interface {
-	Open(path string) *sqlx.DB
+	Open(path string) (*sqlx.DB, error)
+	Migrate(path string) error
 }
 
-func Initialize(provider string, databasePath string) *sqlx.DB {
+func getProvider(provider string) (databaseProvider, error) {
 	p := databaseProviders[provider]
-
 	if p == nil {
-		panic("No database provider found for " + provider)
+		return nil, fmt.Errorf("no database provider found for %s", provider)
+	}
+
+	return p, nil
+}
+
+// Initialize migrates the database to the schema version of the application
+// and opens it.
+func Initialize(provider string, databasePath string) (*sqlx.DB, error) {
+	if err := Migrate(provider, databasePath); err != nil {
+		return nil, err
+	}
+
+	return Open(provider, databasePath)
+}
+
+// Open returns a handle to the database without connecting to it, so that
+// the database does not need to be reachable.
+func Open(provider string, databasePath string) (*sqlx.DB, error) {
+	p, err := getProvider(provider)
+	if err != nil {
+		return nil, err
+	}
+
+	return p.Open(databasePath)
+}
+
+// Migrate migrates the database to the schema version of the application.
+// Errors other than a *MigrationError mean the database could not be
+// reached.
+func Migrate(provider string, databasePath string) error {
+	p, err := getProvider(provider)
+	if err != nil {
+		return err
+	}
+
+	return p.Migrate(databasePath)
+}
+
+// Check returns an error if the database cannot be reached, or if the schema
+// version of the database does not match the version of the application.
+func Check(ctx context.Context, db *sqlx.DB) error {
+	var version uint
+	var dirty bool
+	row := db.QueryRowContext(ctx, "SELECT version, dirty FROM schema_migrations LIMIT 1")
+	if err := row.Scan(&version, &dirty); err != nil {
+		return err
+	}
+
+	if dirty {
+		return fmt.Errorf("schema version %d is dirty", version)
+	}
+	if version != appSchemaVersion {
+		return fmt.Errorf("schema version %d does not match the application version %d", version, appSchemaVersion)
 	}
 
-	db := p.Open(databasePath)
-	return db
+	return nil
 }
 
 func registerProvider(name string, provider databaseProvider) {
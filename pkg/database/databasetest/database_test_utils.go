@@ -59,7 +59,10 @@ func initPostgres(connString string) func() {
 
 	pgDropAll(conn)
 
-	db = database.Initialize(databaseType, connString)
+	db, err = database.Initialize(databaseType, connString)
+	if err != nil {
+		panic(fmt.Sprintf("Could not initialize postgres database at %s: %s", connString, err.Error()))
+	}
 	txnMgr := sqlxx.NewTxnMgr(db, &postgres.Dialect{})
 	repo = txnMgr.Repo()
 
@@ -0,0 +1,12 @@
+CREATE TABLE "edit_votes" (
+  "edit_id" UUID NOT NULL,
+  "user_id" UUID NOT NULL,
+  "date" TIMESTAMP NOT NULL,
+  "comment" TEXT,
+  "type" VARCHAR(20) NOT NULL,
+  FOREIGN KEY("edit_id") REFERENCES "edits"("id") ON DELETE CASCADE,
+  FOREIGN KEY("user_id") REFERENCES "users"("id") ON DELETE CASCADE,
+  PRIMARY KEY("edit_id", "user_id")
+);
+
+CREATE INDEX "edit_votes_user_id_idx" ON "edit_votes" ("user_id");
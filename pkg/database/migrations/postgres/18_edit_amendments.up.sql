@@ -0,0 +1,12 @@
+CREATE TABLE "edit_amendments" (
+  "id" UUID NOT NULL PRIMARY KEY,
+  "edit_id" UUID NOT NULL,
+  "user_id" UUID,
+  "created_at" TIMESTAMP NOT NULL,
+  "data" JSONB,
+  "votes" INTEGER NOT NULL DEFAULT 0,
+  FOREIGN KEY("edit_id") REFERENCES "edits"("id") ON DELETE CASCADE,
+  FOREIGN KEY("user_id") REFERENCES "users"("id") ON DELETE SET NULL
+);
+
+CREATE INDEX "edit_amendments_edit_id_idx" ON "edit_amendments" ("edit_id");
@@ -0,0 +1,66 @@
+CREATE TABLE "user_edit_counts" (
+  "user_id" UUID NOT NULL PRIMARY KEY,
+  "successful_edits" INTEGER NOT NULL DEFAULT 0,
+  "unsuccessful_edits" INTEGER NOT NULL DEFAULT 0,
+  "successful_votes" INTEGER NOT NULL DEFAULT 0,
+  "unsuccessful_votes" INTEGER NOT NULL DEFAULT 0,
+  FOREIGN KEY("user_id") REFERENCES "users"("id") ON DELETE CASCADE
+);
+
+CREATE INDEX "edits_user_id_idx" ON "edits" ("user_id");
+
+INSERT INTO user_edit_counts (user_id, successful_edits, unsuccessful_edits)
+SELECT
+  E.user_id,
+  COUNT(*) FILTER (WHERE E.status IN ('ACCEPTED', 'IMMEDIATE_ACCEPTED')),
+  COUNT(*) FILTER (WHERE E.status IN ('REJECTED', 'IMMEDIATE_REJECTED', 'CLOSED'))
+FROM edits E
+WHERE E.user_id IS NOT NULL
+GROUP BY E.user_id;
+
+INSERT INTO user_edit_counts (user_id, successful_votes, unsuccessful_votes)
+SELECT
+  V.user_id,
+  COUNT(*) FILTER (WHERE E.status IN ('ACCEPTED', 'IMMEDIATE_ACCEPTED')),
+  COUNT(*) FILTER (WHERE E.status IN ('REJECTED', 'IMMEDIATE_REJECTED', 'CLOSED'))
+FROM edit_votes V
+JOIN edits E ON V.edit_id = E.id
+WHERE V.type != 'COMMENT'
+GROUP BY V.user_id
+ON CONFLICT (user_id) DO UPDATE SET
+  successful_votes = EXCLUDED.successful_votes,
+  unsuccessful_votes = EXCLUDED.unsuccessful_votes;
+
+-- Votes can only be cast on pending edits, so the counts of the submitter and
+-- the voters only need updating once, when the edit leaves the pending state.
+CREATE OR REPLACE FUNCTION update_user_edit_counts() RETURNS TRIGGER AS $$
+DECLARE
+  successful INTEGER := CASE WHEN NEW.status IN ('ACCEPTED', 'IMMEDIATE_ACCEPTED') THEN 1 ELSE 0 END;
+  unsuccessful INTEGER := 1 - successful;
+BEGIN
+IF NEW.user_id IS NOT NULL THEN
+  INSERT INTO user_edit_counts (user_id, successful_edits, unsuccessful_edits)
+  VALUES (NEW.user_id, successful, unsuccessful)
+  ON CONFLICT (user_id) DO UPDATE SET
+    successful_edits = user_edit_counts.successful_edits + EXCLUDED.successful_edits,
+    unsuccessful_edits = user_edit_counts.unsuccessful_edits + EXCLUDED.unsuccessful_edits;
+END IF;
+
+INSERT INTO user_edit_counts (user_id, successful_votes, unsuccessful_votes)
+SELECT V.user_id, successful, unsuccessful
+FROM edit_votes V
+WHERE V.edit_id = NEW.id
+AND V.type != 'COMMENT'
+ON CONFLICT (user_id) DO UPDATE SET
+  successful_votes = user_edit_counts.successful_votes + EXCLUDED.successful_votes,
+  unsuccessful_votes = user_edit_counts.unsuccessful_votes + EXCLUDED.unsuccessful_votes;
+
+RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER update_user_edit_counts
+AFTER UPDATE OF status ON edits
+FOR EACH ROW
+WHEN (OLD.status = 'PENDING' AND NEW.status != 'PENDING')
+EXECUTE PROCEDURE update_user_edit_counts();
@@ -0,0 +1,11 @@
+CREATE TABLE "user_api_calls" (
+  "user_id" UUID NOT NULL,
+  "period" TIMESTAMP NOT NULL,
+  "calls" INTEGER NOT NULL DEFAULT 0,
+  FOREIGN KEY("user_id") REFERENCES "users"("id") ON DELETE CASCADE,
+  PRIMARY KEY("user_id", "period")
+);
+
+CREATE INDEX "user_api_calls_period_idx" ON "user_api_calls" ("period");
+
+UPDATE "users" SET "api_calls" = 0 WHERE "api_calls" IS NULL;
@@ -0,0 +1,11 @@
+CREATE TABLE "scene_fingerprint_submissions" (
+  "scene_id" UUID NOT NULL,
+  "hash" VARCHAR(255) NOT NULL,
+  "user_id" UUID NOT NULL,
+  "created_at" TIMESTAMP NOT NULL,
+  FOREIGN KEY("scene_id") REFERENCES "scenes"("id") ON DELETE CASCADE,
+  FOREIGN KEY("user_id") REFERENCES "users"("id") ON DELETE CASCADE,
+  PRIMARY KEY("scene_id", "hash", "user_id")
+);
+
+CREATE INDEX "scene_fingerprint_submissions_user_id_idx" ON "scene_fingerprint_submissions" ("user_id");
@@ -0,0 +1 @@
+ALTER TABLE "scene_fingerprint_submissions" ADD COLUMN "duration" INTEGER NOT NULL DEFAULT 0;
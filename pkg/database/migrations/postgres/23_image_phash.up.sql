@@ -0,0 +1,25 @@
+ALTER TABLE "images" ADD COLUMN "phash" BIGINT;
+
+-- Use a bktree index for distance matching if available
+DO $$
+DECLARE
+  extension pg_extension%rowtype;
+BEGIN
+
+  SELECT *
+  INTO extension
+  FROM pg_extension
+  WHERE extname='bktree';
+
+  IF found THEN
+    CREATE INDEX images_phash_index
+    ON images
+    USING spgist (phash bktree_ops)
+    WHERE phash IS NOT NULL;
+  ELSE
+    CREATE INDEX images_phash_index
+    ON images (phash)
+    WHERE phash IS NOT NULL;
+  END IF;
+
+END$$;
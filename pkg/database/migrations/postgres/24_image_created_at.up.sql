@@ -0,0 +1,3 @@
+ALTER TABLE "images" ADD COLUMN "created_at" TIMESTAMP NOT NULL DEFAULT NOW();
+
+CREATE INDEX "images_created_at_idx" ON "images" ("created_at");
@@ -0,0 +1,16 @@
+CREATE TABLE "audit_log" (
+  "id" UUID NOT NULL PRIMARY KEY,
+  "user_id" UUID,
+  "user_name" VARCHAR(255) NOT NULL,
+  "action" VARCHAR(255) NOT NULL,
+  "target_type" VARCHAR(255) NOT NULL,
+  "target_id" UUID NOT NULL,
+  "old_data" JSONB,
+  "new_data" JSONB,
+  "created_at" TIMESTAMP NOT NULL,
+  FOREIGN KEY("user_id") REFERENCES "users"("id") ON DELETE SET NULL
+);
+
+CREATE INDEX "audit_log_user_id_idx" ON "audit_log" ("user_id");
+CREATE INDEX "audit_log_target_idx" ON "audit_log" ("target_type", "target_id");
+CREATE INDEX "audit_log_created_at_idx" ON "audit_log" ("created_at");
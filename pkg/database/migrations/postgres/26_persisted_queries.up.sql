@@ -0,0 +1,5 @@
+CREATE TABLE "persisted_queries" (
+  "hash" VARCHAR(64) NOT NULL PRIMARY KEY,
+  "query" TEXT NOT NULL,
+  "created_at" TIMESTAMP NOT NULL
+);
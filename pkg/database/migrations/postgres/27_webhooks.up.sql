@@ -0,0 +1,26 @@
+CREATE TABLE "webhooks" (
+  "id" UUID NOT NULL PRIMARY KEY,
+  "url" TEXT NOT NULL,
+  "secret" VARCHAR(255) NOT NULL,
+  "events" TEXT[] NOT NULL DEFAULT '{}',
+  "created_at" TIMESTAMP NOT NULL,
+  "updated_at" TIMESTAMP NOT NULL
+);
+
+CREATE TABLE "webhook_deliveries" (
+  "id" UUID NOT NULL PRIMARY KEY,
+  "webhook_id" UUID NOT NULL,
+  "event" VARCHAR(255) NOT NULL,
+  "payload" JSONB NOT NULL,
+  "status" VARCHAR(255) NOT NULL,
+  "attempts" INTEGER NOT NULL DEFAULT 0,
+  "last_error" TEXT,
+  "next_attempt_at" TIMESTAMP NOT NULL,
+  "created_at" TIMESTAMP NOT NULL,
+  "updated_at" TIMESTAMP NOT NULL,
+  FOREIGN KEY("webhook_id") REFERENCES "webhooks"("id") ON DELETE CASCADE
+);
+
+CREATE INDEX "webhook_deliveries_webhook_id_idx" ON "webhook_deliveries" ("webhook_id");
+CREATE INDEX "webhook_deliveries_pending_idx" ON "webhook_deliveries" ("next_attempt_at") WHERE "status" = 'PENDING';
+CREATE INDEX "webhook_deliveries_updated_at_idx" ON "webhook_deliveries" ("updated_at") WHERE "status" <> 'PENDING';
@@ -0,0 +1,56 @@
+-- Votes are successful when the vote agrees with the outcome of the edit,
+-- rather than when the edit is accepted.
+CREATE OR REPLACE FUNCTION update_user_edit_counts() RETURNS TRIGGER AS $$
+DECLARE
+  accepted BOOLEAN := NEW.status IN ('ACCEPTED', 'IMMEDIATE_ACCEPTED');
+  rejected BOOLEAN := NEW.status IN ('REJECTED', 'IMMEDIATE_REJECTED', 'CLOSED');
+BEGIN
+IF NOT accepted AND NOT rejected THEN
+  RETURN NULL;
+END IF;
+
+IF NEW.user_id IS NOT NULL THEN
+  INSERT INTO user_edit_counts (user_id, successful_edits, unsuccessful_edits)
+  VALUES (NEW.user_id, accepted::INTEGER, rejected::INTEGER)
+  ON CONFLICT (user_id) DO UPDATE SET
+    successful_edits = user_edit_counts.successful_edits + EXCLUDED.successful_edits,
+    unsuccessful_edits = user_edit_counts.unsuccessful_edits + EXCLUDED.unsuccessful_edits;
+END IF;
+
+INSERT INTO user_edit_counts (user_id, successful_votes, unsuccessful_votes)
+SELECT
+  V.user_id,
+  COUNT(*) FILTER (WHERE (V.type IN ('ACCEPT', 'IMMEDIATE_ACCEPT')) = accepted),
+  COUNT(*) FILTER (WHERE (V.type IN ('ACCEPT', 'IMMEDIATE_ACCEPT')) != accepted)
+FROM edit_votes V
+WHERE V.edit_id = NEW.id
+AND V.type IN ('ACCEPT', 'REJECT', 'IMMEDIATE_ACCEPT', 'IMMEDIATE_REJECT')
+GROUP BY V.user_id
+ON CONFLICT (user_id) DO UPDATE SET
+  successful_votes = user_edit_counts.successful_votes + EXCLUDED.successful_votes,
+  unsuccessful_votes = user_edit_counts.unsuccessful_votes + EXCLUDED.unsuccessful_votes;
+
+RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+UPDATE user_edit_counts SET successful_votes = 0, unsuccessful_votes = 0;
+
+INSERT INTO user_edit_counts (user_id, successful_votes, unsuccessful_votes)
+SELECT
+  V.user_id,
+  COUNT(*) FILTER (WHERE
+    (V.type IN ('ACCEPT', 'IMMEDIATE_ACCEPT') AND E.status IN ('ACCEPTED', 'IMMEDIATE_ACCEPTED'))
+    OR (V.type IN ('REJECT', 'IMMEDIATE_REJECT') AND E.status IN ('REJECTED', 'IMMEDIATE_REJECTED', 'CLOSED'))
+  ),
+  COUNT(*) FILTER (WHERE
+    (V.type IN ('ACCEPT', 'IMMEDIATE_ACCEPT') AND E.status IN ('REJECTED', 'IMMEDIATE_REJECTED', 'CLOSED'))
+    OR (V.type IN ('REJECT', 'IMMEDIATE_REJECT') AND E.status IN ('ACCEPTED', 'IMMEDIATE_ACCEPTED'))
+  )
+FROM edit_votes V
+JOIN edits E ON V.edit_id = E.id
+WHERE V.type IN ('ACCEPT', 'REJECT', 'IMMEDIATE_ACCEPT', 'IMMEDIATE_REJECT')
+GROUP BY V.user_id
+ON CONFLICT (user_id) DO UPDATE SET
+  successful_votes = EXCLUDED.successful_votes,
+  unsuccessful_votes = EXCLUDED.unsuccessful_votes;
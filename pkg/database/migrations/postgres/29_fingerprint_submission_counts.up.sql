@@ -0,0 +1,17 @@
+-- Remove the submissions of fingerprints removed from their scene
+DELETE FROM scene_fingerprint_submissions S
+WHERE NOT EXISTS (
+  SELECT 1 FROM scene_fingerprints F
+  WHERE F.scene_id = S.scene_id AND F.hash = S.hash
+);
+
+-- Count at least the user submissions of each fingerprint. Submissions made
+-- before user submissions were recorded are kept in the count.
+UPDATE scene_fingerprints F SET submissions = GREATEST(F.submissions, (
+  SELECT COUNT(*) FROM scene_fingerprint_submissions S
+  WHERE S.scene_id = F.scene_id AND S.hash = F.hash
+))
+WHERE EXISTS (
+  SELECT 1 FROM scene_fingerprint_submissions S
+  WHERE S.scene_id = F.scene_id AND S.hash = F.hash
+);
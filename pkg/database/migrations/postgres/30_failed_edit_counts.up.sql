@@ -0,0 +1,47 @@
+-- Failed edits can still be applied or closed, so the counts are updated when
+-- an edit leaves the failed state as well as the pending state.
+DROP TRIGGER update_user_edit_counts ON edits;
+
+CREATE TRIGGER update_user_edit_counts
+AFTER UPDATE OF status ON edits
+FOR EACH ROW
+WHEN (OLD.status IN ('PENDING', 'FAILED') AND NEW.status != OLD.status)
+EXECUTE PROCEDURE update_user_edit_counts();
+
+-- Recount edits which were applied after failing.
+UPDATE user_edit_counts SET
+  successful_edits = 0,
+  unsuccessful_edits = 0,
+  successful_votes = 0,
+  unsuccessful_votes = 0;
+
+INSERT INTO user_edit_counts (user_id, successful_edits, unsuccessful_edits)
+SELECT
+  E.user_id,
+  COUNT(*) FILTER (WHERE E.status IN ('ACCEPTED', 'IMMEDIATE_ACCEPTED')),
+  COUNT(*) FILTER (WHERE E.status IN ('REJECTED', 'IMMEDIATE_REJECTED', 'CLOSED'))
+FROM edits E
+WHERE E.user_id IS NOT NULL
+GROUP BY E.user_id
+ON CONFLICT (user_id) DO UPDATE SET
+  successful_edits = EXCLUDED.successful_edits,
+  unsuccessful_edits = EXCLUDED.unsuccessful_edits;
+
+INSERT INTO user_edit_counts (user_id, successful_votes, unsuccessful_votes)
+SELECT
+  V.user_id,
+  COUNT(*) FILTER (WHERE
+    (V.type IN ('ACCEPT', 'IMMEDIATE_ACCEPT') AND E.status IN ('ACCEPTED', 'IMMEDIATE_ACCEPTED'))
+    OR (V.type IN ('REJECT', 'IMMEDIATE_REJECT') AND E.status IN ('REJECTED', 'IMMEDIATE_REJECTED', 'CLOSED'))
+  ),
+  COUNT(*) FILTER (WHERE
+    (V.type IN ('ACCEPT', 'IMMEDIATE_ACCEPT') AND E.status IN ('REJECTED', 'IMMEDIATE_REJECTED', 'CLOSED'))
+    OR (V.type IN ('REJECT', 'IMMEDIATE_REJECT') AND E.status IN ('ACCEPTED', 'IMMEDIATE_ACCEPTED'))
+  )
+FROM edit_votes V
+JOIN edits E ON V.edit_id = E.id
+WHERE V.type IN ('ACCEPT', 'REJECT', 'IMMEDIATE_ACCEPT', 'IMMEDIATE_REJECT')
+GROUP BY V.user_id
+ON CONFLICT (user_id) DO UPDATE SET
+  successful_votes = EXCLUDED.successful_votes,
+  unsuccessful_votes = EXCLUDED.unsuccessful_votes;
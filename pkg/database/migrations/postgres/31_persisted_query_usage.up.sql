@@ -0,0 +1,7 @@
+-- Queries were stored before they were validated, so are removed rather
+-- than kept. Clients send the query again when a hash is not found.
+DELETE FROM "persisted_queries";
+
+ALTER TABLE "persisted_queries" ADD COLUMN "last_used_at" TIMESTAMP NOT NULL;
+
+CREATE INDEX "persisted_queries_last_used_at_idx" ON "persisted_queries" ("last_used_at");
@@ -2,11 +2,11 @@ package database
 
 import (
 	"embed"
+	"errors"
 	"fmt"
 
 	"github.com/golang-migrate/migrate/v4"
 	"github.com/jmoiron/sqlx"
-	"github.com/stashapp/stash-box/pkg/logger"
 
 	// Driver used here only
 	_ "github.com/golang-migrate/migrate/v4/database/postgres"
@@ -25,23 +25,22 @@ func init() {
 
 type PostgresProvider struct{}
 
-func (p *PostgresProvider) Open(databasePath string) *sqlx.DB {
-	p.runMigrations(databasePath)
-
+func (p *PostgresProvider) Open(databasePath string) (*sqlx.DB, error) {
 	conn, err := sqlx.Open(postgresDriver, "postgres://"+databasePath)
-	conn.SetMaxOpenConns(25)
-	conn.SetMaxIdleConns(4)
 	if err != nil {
-		logger.Fatalf("db.Open(): %q\n", err)
+		return nil, fmt.Errorf("db.Open(): %w", err)
 	}
-	return conn
+
+	conn.SetMaxOpenConns(25)
+	conn.SetMaxIdleConns(4)
+	return conn, nil
 }
 
 // Migrate the database
-func (p *PostgresProvider) runMigrations(databasePath string) {
+func (p *PostgresProvider) Migrate(databasePath string) error {
 	migrations, err := iofs.New(fs, "migrations/postgres")
 	if err != nil {
-		panic(err.Error())
+		return err
 	}
 
 	m, err := migrate.NewWithSourceInstance(
@@ -50,17 +49,26 @@ func (p *PostgresProvider) runMigrations(databasePath string) {
 		fmt.Sprintf("%s://%s", postgresDriver, databasePath),
 	)
 	if err != nil {
-		panic(err.Error())
+		return err
+	}
+	defer func() {
+		_, _ = m.Close()
+	}()
+
+	databaseSchemaVersion, dirty, err := m.Version()
+	if err != nil && !errors.Is(err, migrate.ErrNilVersion) {
+		return err
+	}
+	if dirty {
+		return &MigrationError{Err: fmt.Errorf("schema version %d is dirty", databaseSchemaVersion)}
 	}
 
-	databaseSchemaVersion, _, _ := m.Version()
-	stepNumber := appSchemaVersion - databaseSchemaVersion
+	stepNumber := int(appSchemaVersion) - int(databaseSchemaVersion)
 	if stepNumber != 0 {
-		err = m.Steps(int(stepNumber))
-		if err != nil {
-			panic(err.Error())
+		if err := m.Steps(stepNumber); err != nil {
+			return &MigrationError{Err: fmt.Errorf("error migrating database to schema version %d: %w", appSchemaVersion, err)}
 		}
 	}
 
-	_, _ = m.Close()
+	return nil
 }
@@ -16,29 +16,36 @@ const (
 )
 
 type Loaders struct {
-	SceneFingerprintsByID  FingerprintsLoader
-	ImageByID              ImageLoader
-	PerformerByID          PerformerLoader
-	PerformerAliasesByID   StringsLoader
-	PerformerImageIDsByID  UUIDsLoader
-	PerformerMergeIDsByID  UUIDsLoader
-	PerformerPiercingsByID BodyModificationsLoader
-	PerformerTattoosByID   BodyModificationsLoader
-	PerformerUrlsByID      URLLoader
-	SceneImageIDsByID      UUIDsLoader
-	SceneAppearancesByID   SceneAppearancesLoader
-	SceneUrlsByID          URLLoader
-	StudioImageIDsByID     UUIDsLoader
-	StudioUrlsByID         URLLoader
-	SceneTagIDsByID        UUIDsLoader
-	TagByID                TagLoader
-	TagCategoryByID        TagCategoryLoader
+	SceneFingerprintsByID   FingerprintsLoader
+	ImageByID               ImageLoader
+	PerformerByID           PerformerLoader
+	PerformerAliasesByID    StringsLoader
+	PerformerImageIDsByID   UUIDsLoader
+	PerformerMergeIDsByID   UUIDsLoader
+	PerformerPiercingsByID  BodyModificationsLoader
+	PerformerTattoosByID    BodyModificationsLoader
+	PerformerUrlsByID       URLLoader
+	SceneImageIDsByID       UUIDsLoader
+	SceneAppearancesByID    SceneAppearancesLoader
+	SceneUrlsByID           URLLoader
+	StudioImageIDsByID      UUIDsLoader
+	StudioUrlsByID          URLLoader
+	SceneTagIDsByID         UUIDsLoader
+	TagByID                 TagLoader
+	TagCategoryByID         TagCategoryLoader
+	UserEditCountByID       UserEditCountLoader
+	SceneMedianDurationByID IntLoader
+	// SceneSubmittedHashesByID loads the fingerprints submitted by the
+	// current user
+	SceneSubmittedHashesByID StringsLoader
 }
 
-func Middleware(fac models.Repo) func(next http.Handler) http.Handler {
+// Middleware adds the loaders to the request context. currentUserID returns
+// the ID of the user making the request, or uuid.Nil if there is none.
+func Middleware(fac models.Repo, currentUserID func(ctx context.Context) uuid.UUID) func(next http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			ctx := context.WithValue(r.Context(), loadersKey, GetLoaders(fac))
+			ctx := context.WithValue(r.Context(), loadersKey, GetLoaders(fac, currentUserID(r.Context())))
 			r = r.WithContext(ctx)
 			next.ServeHTTP(w, r)
 		})
@@ -52,7 +59,7 @@ func For(ctx context.Context) *Loaders {
 func GetLoadersKey() contextKey {
 	return loadersKey
 }
-func GetLoaders(fac models.Repo) *Loaders {
+func GetLoaders(fac models.Repo, userID uuid.UUID) *Loaders {
 	return &Loaders{
 		SceneFingerprintsByID: FingerprintsLoader{
 			maxBatch: 100,
@@ -190,5 +197,29 @@ func GetLoaders(fac models.Repo) *Loaders {
 				return qb.FindByIds(ids)
 			},
 		},
+		SceneSubmittedHashesByID: StringsLoader{
+			maxBatch: 100,
+			wait:     1 * time.Millisecond,
+			fetch: func(ids []uuid.UUID) ([][]string, []error) {
+				qb := fac.Scene()
+				return qb.GetAllSubmittedHashes(userID, ids)
+			},
+		},
+		UserEditCountByID: UserEditCountLoader{
+			maxBatch: 100,
+			wait:     1 * time.Millisecond,
+			fetch: func(ids []uuid.UUID) ([]*models.UserEditCount, []error) {
+				qb := fac.User()
+				return qb.GetEditCounts(ids)
+			},
+		},
+		SceneMedianDurationByID: IntLoader{
+			maxBatch: 100,
+			wait:     1 * time.Millisecond,
+			fetch: func(ids []uuid.UUID) ([]*int, []error) {
+				qb := fac.Scene()
+				return qb.GetMedianDurations(ids)
+			},
+		},
 	}
 }
@@ -0,0 +1,183 @@
+package image
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/gofrs/uuid"
+
+	"github.com/stashapp/stash-box/pkg/logger"
+	"github.com/stashapp/stash-box/pkg/models"
+)
+
+// MaxDuplicateDistance is the largest perceptual hash distance accepted when
+// finding duplicate images. Larger distances match unrelated images.
+const MaxDuplicateDistance = 16
+
+// FindDuplicates returns clusters of images whose perceptual hashes are
+// within the provided distance of each other. Clusters are transitive, so
+// the distance between two images in a cluster may exceed the provided
+// distance.
+func (s *Service) FindDuplicates(distance int) ([][]*models.Image, error) {
+	if distance < 0 || distance > MaxDuplicateDistance {
+		return nil, fmt.Errorf("distance must be between 0 and %d", MaxDuplicateDistance)
+	}
+
+	duplicates, err := s.Repository.FindDuplicatePHashes(distance)
+	if err != nil {
+		return nil, err
+	}
+
+	var ret [][]*models.Image
+	for _, cluster := range clusterDuplicates(duplicates) {
+		images, errs := s.Repository.FindByIds(cluster)
+		for _, err := range errs {
+			if err != nil {
+				return nil, err
+			}
+		}
+		ret = append(ret, images)
+	}
+
+	return ret, nil
+}
+
+// clusterDuplicates groups the duplicate pairs into connected clusters of
+// image IDs. Clusters and their IDs are sorted to give a stable order.
+func clusterDuplicates(duplicates []*models.ImageDuplicate) [][]uuid.UUID {
+	parents := make(map[uuid.UUID]uuid.UUID)
+
+	var find func(id uuid.UUID) uuid.UUID
+	find = func(id uuid.UUID) uuid.UUID {
+		parent, found := parents[id]
+		if !found {
+			parents[id] = id
+			return id
+		}
+		if parent == id {
+			return id
+		}
+
+		root := find(parent)
+		parents[id] = root
+		return root
+	}
+
+	for _, d := range duplicates {
+		a := find(d.ImageID)
+		b := find(d.DuplicateID)
+		if a != b {
+			parents[b] = a
+		}
+	}
+
+	clusters := make(map[uuid.UUID][]uuid.UUID)
+	for id := range parents {
+		root := find(id)
+		clusters[root] = append(clusters[root], id)
+	}
+
+	var ret [][]uuid.UUID
+	for _, cluster := range clusters {
+		sort.Slice(cluster, func(i, j int) bool {
+			return cluster[i].String() < cluster[j].String()
+		})
+		ret = append(ret, cluster)
+	}
+	sort.Slice(ret, func(i, j int) bool {
+		return ret[i][0].String() < ret[j][0].String()
+	})
+
+	return ret
+}
+
+// Merge replaces the source images with the destination image for all
+// scenes, performers and studios, and destroys the source images. The files
+// of the source images are removed once the merge is committed. Sources
+// added or removed by a pending edit cannot be merged, since applying the
+// edit would restore them.
+func Merge(fac models.Repo, backend ImageBackend, input models.ImageMergeInput) (*models.Image, error) {
+	destinationID, err := uuid.FromString(input.DestinationID)
+	if err != nil {
+		return nil, err
+	}
+
+	var sourceIDs []uuid.UUID
+	for _, id := range input.SourceIds {
+		sourceID, err := uuid.FromString(id)
+		if err != nil {
+			return nil, err
+		}
+		if sourceID == destinationID {
+			return nil, errors.New("Destination image cannot be a source")
+		}
+		sourceIDs = append(sourceIDs, sourceID)
+	}
+
+	if len(sourceIDs) == 0 {
+		return nil, errors.New("No source images provided")
+	}
+
+	var destination *models.Image
+	var sources []*models.Image
+	err = fac.WithTxn(func() error {
+		qb := fac.Image()
+
+		var err error
+		destination, err = qb.Find(destinationID)
+		if err != nil {
+			return err
+		}
+		if destination == nil {
+			return errors.New("Destination image not found")
+		}
+
+		for _, sourceID := range sourceIDs {
+			source, err := qb.Find(sourceID)
+			if err != nil {
+				return err
+			}
+			if source == nil {
+				return fmt.Errorf("Source image not found: %s", sourceID)
+			}
+
+			pending, err := qb.IsInPendingEdit(sourceID)
+			if err != nil {
+				return err
+			}
+			if pending {
+				return fmt.Errorf("Source image is used by a pending edit: %s", sourceID)
+			}
+
+			sources = append(sources, source)
+		}
+
+		if err := qb.MergeInto(sourceIDs, destinationID); err != nil {
+			return err
+		}
+
+		for _, source := range sources {
+			if err := qb.Destroy(source.ID); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// files are removed once the images are committed as destroyed
+	for _, source := range sources {
+		if source.Checksum == "" {
+			continue
+		}
+		if err := backend.DestroyFile(source); err != nil && !os.IsNotExist(err) {
+			logger.Errorf("Error removing file of image %s: %s", source.ID.String(), err.Error())
+		}
+	}
+
+	return destination, nil
+}
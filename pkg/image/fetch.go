@@ -0,0 +1,215 @@
+package image
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"mime"
+	"net"
+	"net/http"
+	"net/url"
+	"syscall"
+	"time"
+
+	"github.com/stashapp/stash-box/pkg/manager/config"
+)
+
+var ErrImageTooLarge = errors.New("image exceeds the maximum download size")
+
+var ErrForbiddenAddress = errors.New("image URL resolves to a forbidden address")
+
+// maxFetchRedirects is the number of redirects followed when fetching images
+const maxFetchRedirects = 10
+
+// forbiddenNetworks are the non-public networks images cannot be fetched
+// from, in addition to loopback, link-local, multicast and unspecified
+// addresses.
+var forbiddenNetworks = parseNetworks(
+	"0.0.0.0/8",
+	"10.0.0.0/8",
+	"100.64.0.0/10",
+	"172.16.0.0/12",
+	"192.168.0.0/16",
+	"198.18.0.0/15",
+	"fc00::/7",
+)
+
+func parseNetworks(cidrs ...string) []*net.IPNet {
+	var ret []*net.IPNet
+	for _, cidr := range cidrs {
+		_, network, err := net.ParseCIDR(cidr)
+		if err != nil {
+			panic(err)
+		}
+		ret = append(ret, network)
+	}
+	return ret
+}
+
+// isForbiddenIP returns true if the address is not a public unicast address.
+func isForbiddenIP(ip net.IP) bool {
+	if ip.IsLoopback() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() || ip.IsMulticast() || ip.IsUnspecified() {
+		return true
+	}
+
+	for _, network := range forbiddenNetworks {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// validateRedirectURL returns an error if the URL is not an http or https
+// URL, or its host resolves to a forbidden address.
+func validateRedirectURL(u *url.URL) error {
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return fmt.Errorf("unsupported image URL scheme: %s", u.Scheme)
+	}
+
+	addrs, err := net.DefaultResolver.LookupIPAddr(context.Background(), u.Hostname())
+	if err != nil {
+		return err
+	}
+	for _, addr := range addrs {
+		if isForbiddenIP(addr.IP) {
+			return ErrForbiddenAddress
+		}
+	}
+
+	return nil
+}
+
+// checkFetchRedirect validates each URL the image fetch is redirected to.
+func checkFetchRedirect(req *http.Request, via []*http.Request) error {
+	if len(via) >= maxFetchRedirects {
+		return errors.New("too many redirects")
+	}
+	return validateRedirectURL(req.URL)
+}
+
+// dialControl rejects connections to forbidden addresses. It runs after
+// the host has been resolved, so cannot be bypassed by DNS rebinding.
+func dialControl(network, address string, c syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+
+	ip := net.ParseIP(host)
+	if ip == nil || isForbiddenIP(ip) {
+		return ErrForbiddenAddress
+	}
+
+	return nil
+}
+
+// newFetchClient returns a client which only connects to public addresses,
+// including when following redirects. Proxies are not used, since
+// connections to them would not be checked.
+func newFetchClient(timeout time.Duration) *http.Client {
+	dialer := &net.Dialer{
+		Timeout:   30 * time.Second,
+		KeepAlive: 30 * time.Second,
+		Control:   dialControl,
+	}
+
+	return &http.Client{
+		Timeout: timeout,
+		Transport: &http.Transport{
+			DialContext:           dialer.DialContext,
+			ForceAttemptHTTP2:     true,
+			MaxIdleConns:          100,
+			IdleConnTimeout:       90 * time.Second,
+			TLSHandshakeTimeout:   10 * time.Second,
+			ExpectContinueTimeout: 1 * time.Second,
+		},
+		CheckRedirect: checkFetchRedirect,
+	}
+}
+
+// Fetcher downloads remote images, limiting the size and content type of
+// the response.
+type Fetcher struct {
+	Client       *http.Client
+	MaxSize      int64
+	ContentTypes []string
+}
+
+// NewFetcher returns a Fetcher using the image_fetch settings, which only
+// fetches images from public addresses.
+func NewFetcher() *Fetcher {
+	return &Fetcher{
+		Client:       newFetchClient(config.GetImageFetchTimeout()),
+		MaxSize:      config.GetImageFetchMaxSize(),
+		ContentTypes: config.GetImageFetchContentTypes(),
+	}
+}
+
+// Fetch downloads the image at the provided URL and returns its contents.
+func (f *Fetcher) Fetch(imageURL string) ([]byte, error) {
+	u, err := url.Parse(imageURL)
+	if err != nil {
+		return nil, err
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, fmt.Errorf("unsupported image URL scheme: %s", u.Scheme)
+	}
+
+	resp, err := f.Client.Get(u.String())
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("error downloading image: %s", resp.Status)
+	}
+
+	if f.MaxSize > 0 && resp.ContentLength > f.MaxSize {
+		return nil, ErrImageTooLarge
+	}
+
+	var body io.Reader = resp.Body
+	if f.MaxSize > 0 {
+		body = io.LimitReader(resp.Body, f.MaxSize+1)
+	}
+
+	data, err := ioutil.ReadAll(body)
+	if err != nil {
+		return nil, err
+	}
+	if f.MaxSize > 0 && int64(len(data)) > f.MaxSize {
+		return nil, ErrImageTooLarge
+	}
+
+	// both the declared and the detected content type must be allowed
+	contentType := resp.Header.Get("Content-Type")
+	if contentType != "" && !f.allowedContentType(contentType) {
+		return nil, fmt.Errorf("unsupported image content type: %s", contentType)
+	}
+	if contentType = DetectContentType(data); !f.allowedContentType(contentType) {
+		return nil, fmt.Errorf("unsupported image content type: %s", contentType)
+	}
+
+	return data, nil
+}
+
+func (f *Fetcher) allowedContentType(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+
+	for _, t := range f.ContentTypes {
+		if t == mediaType {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,305 @@
+package image
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/png"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gofrs/uuid"
+
+	"github.com/stashapp/stash-box/pkg/manager/config"
+	"github.com/stashapp/stash-box/pkg/models"
+)
+
+type fakeImageRepo struct {
+	models.ImageRepo
+	images  []models.Image
+	used    map[uuid.UUID]bool
+	pending map[uuid.UUID]bool
+	merged  []uuid.UUID
+}
+
+func (r *fakeImageRepo) FindByChecksum(checksum string) (*models.Image, error) {
+	for _, i := range r.images {
+		if i.Checksum == checksum {
+			ret := i
+			return &ret, nil
+		}
+	}
+	return nil, nil
+}
+
+func (r *fakeImageRepo) Create(newImage models.Image) (*models.Image, error) {
+	r.images = append(r.images, newImage)
+	return &newImage, nil
+}
+
+func encodeTestPNG(t *testing.T, width, height int) []byte {
+	t.Helper()
+
+	buf := new(bytes.Buffer)
+	if err := png.Encode(buf, image.NewRGBA(image.Rect(0, 0, width, height))); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func newTestFetcher(server *httptest.Server) *Fetcher {
+	return &Fetcher{
+		Client:       server.Client(),
+		MaxSize:      1024,
+		ContentTypes: []string{"image/png"},
+	}
+}
+
+func TestFetch(t *testing.T) {
+	data := encodeTestPNG(t, 4, 3)
+	large := encodeTestPNG(t, 400, 300)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/image.png", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "image/png")
+		_, _ = w.Write(data)
+	})
+	mux.HandleFunc("/untyped", func(w http.ResponseWriter, r *http.Request) {
+		w.Header()["Content-Type"] = nil
+		_, _ = w.Write(data)
+	})
+	mux.HandleFunc("/large.png", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "image/png")
+		_, _ = w.Write(large)
+	})
+	mux.HandleFunc("/chunked.png", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "image/png")
+		for i := 0; i < len(large); i += 100 {
+			end := i + 100
+			if end > len(large) {
+				end = len(large)
+			}
+			_, _ = w.Write(large[i:end])
+			w.(http.Flusher).Flush()
+		}
+	})
+	mux.HandleFunc("/page.html", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		_, _ = w.Write([]byte("<html><body></body></html>"))
+	})
+	mux.HandleFunc("/disguised.png", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "image/png")
+		_, _ = w.Write([]byte("<html><body></body></html>"))
+	})
+
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	fetcher := newTestFetcher(server)
+
+	got, err := fetcher.Fetch(server.URL + "/image.png")
+	if err != nil {
+		t.Fatalf("Fetch: %v", err)
+	}
+	if !bytes.Equal(got, data) {
+		t.Error("Fetch returned incorrect data")
+	}
+
+	if _, err := fetcher.Fetch(server.URL + "/untyped"); err != nil {
+		t.Errorf("Fetch without content type: %v", err)
+	}
+
+	for _, path := range []string{"/large.png", "/chunked.png"} {
+		if _, err := fetcher.Fetch(server.URL + path); !errors.Is(err, ErrImageTooLarge) {
+			t.Errorf("Fetch %s: got error %v want %v", path, err, ErrImageTooLarge)
+		}
+	}
+
+	for _, path := range []string{"/page.html", "/disguised.png", "/missing.png"} {
+		if _, err := fetcher.Fetch(server.URL + path); err == nil {
+			t.Errorf("Fetch %s: expected error", path)
+		}
+	}
+
+	if _, err := fetcher.Fetch("file:///etc/passwd"); err == nil {
+		t.Error("Fetch of file URL: expected error")
+	}
+}
+
+func TestFetchTimeout(t *testing.T) {
+	done := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-done
+	}))
+	defer server.Close()
+	defer close(done)
+
+	fetcher := newTestFetcher(server)
+	fetcher.Client.Timeout = 50 * time.Millisecond
+
+	if _, err := fetcher.Fetch(server.URL); err == nil {
+		t.Error("expected timeout error")
+	}
+}
+
+func TestFetchForbiddenAddress(t *testing.T) {
+	data := encodeTestPNG(t, 4, 3)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "image/png")
+		_, _ = w.Write(data)
+	}))
+	defer server.Close()
+
+	fetcher := newTestFetcher(server)
+	fetcher.Client = newFetchClient(time.Second)
+
+	_, port, _ := net.SplitHostPort(server.Listener.Addr().String())
+	for _, imageURL := range []string{server.URL, "http://localhost:" + port + "/image.png"} {
+		if _, err := fetcher.Fetch(imageURL); !errors.Is(err, ErrForbiddenAddress) {
+			t.Errorf("Fetch %s: got error %v want %v", imageURL, err, ErrForbiddenAddress)
+		}
+	}
+}
+
+func TestFetchForbiddenRedirect(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/loopback", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "http://localhost/image.png", http.StatusFound)
+	})
+	mux.HandleFunc("/metadata", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "http://169.254.169.254/latest/meta-data/", http.StatusFound)
+	})
+	mux.HandleFunc("/file", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "file:///etc/passwd", http.StatusFound)
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	// the test server is on a loopback address, so only the redirect check
+	// of the fetch client is used
+	fetcher := newTestFetcher(server)
+	fetcher.Client.CheckRedirect = newFetchClient(time.Second).CheckRedirect
+
+	for _, path := range []string{"/loopback", "/metadata"} {
+		if _, err := fetcher.Fetch(server.URL + path); !errors.Is(err, ErrForbiddenAddress) {
+			t.Errorf("Fetch %s: got error %v want %v", path, err, ErrForbiddenAddress)
+		}
+	}
+	if _, err := fetcher.Fetch(server.URL + "/file"); err == nil {
+		t.Error("Fetch redirected to file URL: expected error")
+	}
+}
+
+func TestIsForbiddenIP(t *testing.T) {
+	tests := []struct {
+		ip   string
+		want bool
+	}{
+		{"127.0.0.1", true},
+		{"::1", true},
+		{"10.1.2.3", true},
+		{"172.16.0.1", true},
+		{"192.168.1.1", true},
+		{"169.254.169.254", true},
+		{"fe80::1", true},
+		{"fd00::1", true},
+		{"0.0.0.0", true},
+		{"::", true},
+		{"::ffff:127.0.0.1", true},
+		{"93.184.216.34", false},
+		{"2606:2800:220:1::", false},
+	}
+
+	for _, tt := range tests {
+		if got := isForbiddenIP(net.ParseIP(tt.ip)); got != tt.want {
+			t.Errorf("isForbiddenIP(%s) = %v, want %v", tt.ip, got, tt.want)
+		}
+	}
+}
+
+func TestCreateFetchedImage(t *testing.T) {
+	data := encodeTestPNG(t, 4, 3)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "image/png")
+		_, _ = w.Write(data)
+	}))
+	defer server.Close()
+
+	imageFetch := config.C.ImageFetch
+	defer func() {
+		config.C.ImageFetch = imageFetch
+	}()
+	config.C.ImageFetch = true
+
+	repo := &fakeImageRepo{}
+	backend := newMemoryBackend()
+	service := &Service{
+		Repository: repo,
+		Backend:    backend,
+		Fetcher:    newTestFetcher(server),
+		Validator:  newTestValidator(),
+	}
+
+	url := server.URL + "/image.png"
+	fetch := true
+	created, err := service.Create(models.ImageCreateInput{
+		URL:   &url,
+		Fetch: &fetch,
+	})
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+
+	if created.RemoteURL.String != url {
+		t.Errorf("RemoteURL: got %q want %q", created.RemoteURL.String, url)
+	}
+	if created.Checksum == "" || created.Width != 4 || created.Height != 3 {
+		t.Errorf("Create: got checksum %q, dimensions %dx%d", created.Checksum, created.Width, created.Height)
+	}
+	if !bytes.Equal(backend.files[created.ID], data) {
+		t.Error("fetched image was not written to the backend")
+	}
+
+	// an image with the same contents is deduplicated
+	otherURL := server.URL + "/other.png"
+	existing, err := service.Create(models.ImageCreateInput{
+		URL:   &otherURL,
+		Fetch: &fetch,
+	})
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if existing.ID != created.ID || len(repo.images) != 1 {
+		t.Error("fetched image was not deduplicated by checksum")
+	}
+
+	// images are not fetched when not requested
+	fetch = false
+	if _, err := service.Create(models.ImageCreateInput{
+		URL:   &otherURL,
+		Fetch: &fetch,
+	}); err == nil {
+		t.Error("expected error creating image from URL without fetching")
+	}
+	if backend.writes != 1 {
+		t.Error("image was fetched when not requested")
+	}
+
+	// requests cannot fetch images when fetching is disabled
+	config.C.ImageFetch = false
+	fetch = true
+	newURL := server.URL + "/new.png"
+	if _, err := service.Create(models.ImageCreateInput{
+		URL:   &newURL,
+		Fetch: &fetch,
+	}); err == nil {
+		t.Error("expected error fetching image when fetching is disabled")
+	}
+	if backend.writes != 1 {
+		t.Error("image was fetched when fetching is disabled")
+	}
+}
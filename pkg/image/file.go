@@ -2,8 +2,12 @@ package image
 
 import (
 	"bytes"
+	"context"
+	"io"
 	"io/ioutil"
 	"os"
+	"strings"
+	"time"
 
 	"github.com/stashapp/stash-box/pkg/manager/config"
 	"github.com/stashapp/stash-box/pkg/models"
@@ -34,9 +38,82 @@ func (s *FileBackend) WriteFile(file *bytes.Reader, image *models.Image) error {
 		return err
 	}
 
+	// pre-generate the resized copies. Failures are not fatal, since
+	// missing copies are generated when first requested.
+	_ = writeThumbnails(fileDir, image.Checksum, buf.Bytes(), int(image.Width), int(image.Height))
+
 	return nil
 }
 
+func (s *FileBackend) ReadFile(image *models.Image) (io.ReadCloser, error) {
+	return os.Open(GetImagePath(config.GetImageLocation(), image.Checksum))
+}
+
 func (s *FileBackend) DestroyFile(image *models.Image) error {
-	return os.Remove(GetImagePath(config.GetImageLocation(), image.Checksum))
+	fileDir := config.GetImageLocation()
+	if err := destroyThumbnails(fileDir, image.Checksum); err != nil {
+		return err
+	}
+
+	return os.Remove(GetImagePath(fileDir, image.Checksum))
+}
+
+// CheckHealth returns an error if a file cannot be written to the image
+// location.
+func (s *FileBackend) CheckHealth(ctx context.Context) error {
+	if err := config.ValidateImageLocation(); err != nil {
+		return err
+	}
+
+	f, err := ioutil.TempFile(config.GetImageLocation(), ".health-")
+	if err != nil {
+		return err
+	}
+
+	_ = f.Close()
+	return os.Remove(f.Name())
+}
+
+// ListFiles calls fn for each image stored in the image location. Resized
+// copies are included in the modification time of the image.
+func (s *FileBackend) ListFiles(fn func(image *models.Image, modified time.Time) error) error {
+	fileDir := config.GetImageLocation()
+	if fileDir == "" {
+		return nil
+	}
+
+	files, err := ioutil.ReadDir(fileDir)
+	if err != nil {
+		return err
+	}
+
+	modified := make(map[string]time.Time)
+	var checksums []string
+	for _, file := range files {
+		if file.IsDir() {
+			continue
+		}
+
+		// resized copies are named after the checksum of the original
+		checksum := strings.SplitN(file.Name(), "-", 2)[0]
+		if !checksumRegex.MatchString(checksum) {
+			continue
+		}
+
+		previous, found := modified[checksum]
+		if !found {
+			checksums = append(checksums, checksum)
+		}
+		if !found || file.ModTime().After(previous) {
+			modified[checksum] = file.ModTime()
+		}
+	}
+
+	for _, checksum := range checksums {
+		if err := fn(&models.Image{Checksum: checksum}, modified[checksum]); err != nil {
+			return err
+		}
+	}
+
+	return nil
 }
@@ -0,0 +1,51 @@
+package image
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stashapp/stash-box/pkg/manager/config"
+)
+
+func TestFileBackendCheckHealth(t *testing.T) {
+	dir, err := ioutil.TempDir("", "image-health")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		_ = os.RemoveAll(dir)
+	}()
+
+	location := config.C.ImageLocation
+	defer func() {
+		config.C.ImageLocation = location
+	}()
+
+	backend := &FileBackend{}
+
+	config.C.ImageLocation = dir
+	if err := backend.CheckHealth(context.Background()); err != nil {
+		t.Errorf("CheckHealth: %s", err.Error())
+	}
+
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 0 {
+		t.Errorf("CheckHealth left %d files in the image location", len(files))
+	}
+
+	config.C.ImageLocation = filepath.Join(dir, "missing")
+	if err := backend.CheckHealth(context.Background()); err == nil {
+		t.Error("expected error for missing image location")
+	}
+
+	config.C.ImageLocation = ""
+	if err := backend.CheckHealth(context.Background()); err == nil {
+		t.Error("expected error for unset image location")
+	}
+}
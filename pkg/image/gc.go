@@ -0,0 +1,118 @@
+package image
+
+import (
+	"context"
+	"os"
+	"time"
+
+	"github.com/gofrs/uuid"
+
+	"github.com/stashapp/stash-box/pkg/logger"
+	"github.com/stashapp/stash-box/pkg/models"
+)
+
+// GarbageCollectionResult contains the orphaned images and files found by a
+// garbage collection.
+type GarbageCollectionResult struct {
+	Images []*models.Image
+	Files  int
+}
+
+// CollectGarbage removes images older than the grace period which are not
+// used by a scene, performer or studio, or referenced by a pending edit or
+// an edit updated within the grace period. Stored files older than the grace
+// period without an image are also removed, if the backend can list its
+// files. Nothing is removed for a dry run. Collection stops when the context
+// is done, keeping what was removed so far.
+func CollectGarbage(ctx context.Context, fac models.Repo, backend ImageBackend, gracePeriod time.Duration, dryRun bool) (*GarbageCollectionResult, error) {
+	cutoff := time.Now().Add(-gracePeriod)
+	result := &GarbageCollectionResult{}
+
+	after := uuid.Nil
+	for {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		var orphans []*models.Image
+		err := fac.WithTxn(func() error {
+			qb := fac.Image()
+
+			var err error
+			orphans, err = qb.FindOrphans(cutoff, cutoff, after, migrationBatchSize)
+			if err != nil || dryRun {
+				return err
+			}
+
+			for _, image := range orphans {
+				if err := qb.Destroy(image.ID); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		if len(orphans) == 0 {
+			break
+		}
+
+		// files are removed once the images are committed as destroyed
+		for _, image := range orphans {
+			after = image.ID
+			if dryRun || image.Checksum == "" {
+				continue
+			}
+			if err := backend.DestroyFile(image); err != nil && !os.IsNotExist(err) {
+				logger.Errorf("Error removing file of image %s: %s", image.ID.String(), err.Error())
+			}
+		}
+
+		result.Images = append(result.Images, orphans...)
+	}
+
+	lister, ok := backend.(FileLister)
+	if !ok {
+		return result, nil
+	}
+
+	err := lister.ListFiles(func(image *models.Image, modified time.Time) error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if modified.After(cutoff) {
+			return nil
+		}
+
+		var existing *models.Image
+		err := fac.WithTxn(func() error {
+			var err error
+			if image.ID != uuid.Nil {
+				existing, err = fac.Image().Find(image.ID)
+			} else {
+				existing, err = fac.Image().FindByChecksum(image.Checksum)
+			}
+			return err
+		})
+		if err != nil || existing != nil {
+			return err
+		}
+
+		result.Files++
+		if dryRun {
+			return nil
+		}
+
+		if err := backend.DestroyFile(image); err != nil && !os.IsNotExist(err) {
+			logger.Errorf("Error removing orphaned image file: %s", err.Error())
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
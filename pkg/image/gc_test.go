@@ -0,0 +1,184 @@
+package image
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/gofrs/uuid"
+
+	"github.com/stashapp/stash-box/pkg/manager/config"
+	"github.com/stashapp/stash-box/pkg/models"
+)
+
+type fakeRepo struct {
+	models.Repo
+	images *fakeImageRepo
+	// onCommit is called when a transaction succeeds
+	onCommit func()
+}
+
+func (r *fakeRepo) WithTxn(fn func() error) error {
+	if err := fn(); err != nil {
+		return err
+	}
+	if r.onCommit != nil {
+		r.onCommit()
+	}
+	return nil
+}
+
+func (r *fakeRepo) Image() models.ImageRepo {
+	return r.images
+}
+
+func (r *fakeImageRepo) Find(id uuid.UUID) (*models.Image, error) {
+	for _, i := range r.images {
+		if i.ID == id {
+			ret := i
+			return &ret, nil
+		}
+	}
+	return nil, nil
+}
+
+func (r *fakeImageRepo) Destroy(id uuid.UUID) error {
+	for index, i := range r.images {
+		if i.ID == id {
+			r.images = append(r.images[:index], r.images[index+1:]...)
+			return nil
+		}
+	}
+	return nil
+}
+
+func (r *fakeImageRepo) FindOrphans(createdBefore time.Time, editsUpdatedAfter time.Time, after uuid.UUID, limit int) ([]*models.Image, error) {
+	var ret []*models.Image
+	for _, i := range r.images {
+		if !r.used[i.ID] && i.CreatedAt.Timestamp.Before(createdBefore) && i.ID.String() > after.String() {
+			image := i
+			ret = append(ret, &image)
+		}
+	}
+
+	sort.Slice(ret, func(a, b int) bool {
+		return ret[a].ID.String() < ret[b].ID.String()
+	})
+	if len(ret) > limit {
+		ret = ret[:limit]
+	}
+	return ret, nil
+}
+
+func setModTime(t *testing.T, path string, modified time.Time) {
+	t.Helper()
+	if err := os.Chtimes(path, modified, modified); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCollectGarbage(t *testing.T) {
+	dir, err := ioutil.TempDir("", "images")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = os.RemoveAll(dir) }()
+
+	config.C.ImageLocation = dir
+	defer func() { config.C.ImageLocation = "" }()
+
+	gracePeriod := time.Hour
+	old := time.Now().Add(-2 * gracePeriod)
+	recent := time.Now()
+
+	newImage := func(checksum string, created time.Time) models.Image {
+		return models.Image{
+			ID:        uuid.Must(uuid.NewV4()),
+			Checksum:  checksum,
+			CreatedAt: models.SQLiteTimestamp{Timestamp: created},
+		}
+	}
+
+	used := newImage("00000000000000000000000000000001", old)
+	orphan := newImage("00000000000000000000000000000002", old)
+	recentOrphan := newImage("00000000000000000000000000000003", recent)
+	repo := &fakeImageRepo{
+		images: []models.Image{used, orphan, recentOrphan},
+		used:   map[uuid.UUID]bool{used.ID: true},
+	}
+
+	files := map[string]time.Time{
+		used.Checksum:                          old,
+		orphan.Checksum:                        old,
+		orphan.Checksum + "-320":               old,
+		recentOrphan.Checksum:                  recent,
+		"00000000000000000000000000000004":     old,
+		"00000000000000000000000000000004-320": old,
+		"00000000000000000000000000000005":     recent,
+		"00000000000000000000000000000006-320": old,
+		"not-an-image":                         old,
+	}
+	for name, modified := range files {
+		path := filepath.Join(dir, name)
+		if err := ioutil.WriteFile(path, []byte(name), 0644); err != nil {
+			t.Fatal(err)
+		}
+		setModTime(t, path, modified)
+	}
+
+	fac := &fakeRepo{images: repo}
+	backend := &FileBackend{}
+
+	// dry runs do not remove anything
+	result, err := CollectGarbage(context.Background(), fac, backend, gracePeriod, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Images) != 1 || result.Images[0].ID != orphan.ID {
+		t.Errorf("dry run images: got %v want [%s]", result.Images, orphan.ID)
+	}
+	if result.Files != 2 {
+		t.Errorf("dry run files: got %d want 2", result.Files)
+	}
+	if len(repo.images) != 3 {
+		t.Error("dry run destroyed images")
+	}
+	for name := range files {
+		if _, err := os.Stat(filepath.Join(dir, name)); err != nil {
+			t.Errorf("dry run removed %s", name)
+		}
+	}
+
+	result, err = CollectGarbage(context.Background(), fac, backend, gracePeriod, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Images) != 1 || result.Files != 2 {
+		t.Errorf("result: got %d images and %d files want 1 and 2", len(result.Images), result.Files)
+	}
+	if len(repo.images) != 2 {
+		t.Errorf("images: got %d want 2", len(repo.images))
+	}
+
+	removed := []string{
+		orphan.Checksum,
+		orphan.Checksum + "-320",
+		"00000000000000000000000000000004",
+		"00000000000000000000000000000004-320",
+		"00000000000000000000000000000006-320",
+	}
+	for _, name := range removed {
+		if _, err := os.Stat(filepath.Join(dir, name)); !os.IsNotExist(err) {
+			t.Errorf("%s was not removed", name)
+		}
+	}
+	for _, name := range []string{used.Checksum, recentOrphan.Checksum, "00000000000000000000000000000005", "not-an-image"} {
+		if _, err := os.Stat(filepath.Join(dir, name)); err != nil {
+			t.Errorf("%s was removed", name)
+		}
+	}
+}
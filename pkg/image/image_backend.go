@@ -2,11 +2,65 @@ package image
 
 import (
 	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"time"
 
+	"github.com/stashapp/stash-box/pkg/manager/config"
 	"github.com/stashapp/stash-box/pkg/models"
 )
 
 type ImageBackend interface {
 	WriteFile(file *bytes.Reader, image *models.Image) error
+	// ReadFile returns the original stored file of the image. The returned
+	// reader must be closed by the caller.
+	ReadFile(image *models.Image) (io.ReadCloser, error)
 	DestroyFile(image *models.Image) error
 }
+
+// FileLister is implemented by image backends which can list their stored
+// files, so that files without an image can be removed.
+type FileLister interface {
+	// ListFiles calls fn for each stored image with an image identifying
+	// the stored files, with either the ID or the checksum set depending on
+	// the backend, and the time the files were last modified.
+	ListFiles(fn func(image *models.Image, modified time.Time) error) error
+}
+
+// HealthChecker is implemented by image backends which can check whether
+// their storage is reachable.
+type HealthChecker interface {
+	// CheckHealth returns an error if files cannot be stored.
+	CheckHealth(ctx context.Context) error
+}
+
+// BackendFactory returns a new instance of an image backend.
+type BackendFactory func() ImageBackend
+
+var backends = make(map[config.ImageBackendType]BackendFactory)
+
+// RegisterBackend makes an image backend available under the provided
+// backend type.
+func RegisterBackend(backendType config.ImageBackendType, factory BackendFactory) {
+	backends[backendType] = factory
+}
+
+// GetBackend returns a new image backend of the provided type.
+func GetBackend(backendType config.ImageBackendType) (ImageBackend, error) {
+	factory, found := backends[backendType]
+	if !found {
+		return nil, fmt.Errorf("unknown image backend: %s", backendType)
+	}
+
+	return factory(), nil
+}
+
+func init() {
+	RegisterBackend(config.FileBackend, func() ImageBackend {
+		return &FileBackend{}
+	})
+	RegisterBackend(config.S3Backend, func() ImageBackend {
+		return &S3Backend{}
+	})
+}
@@ -11,20 +11,20 @@ type ImageService interface {
 	Destroy(input models.ImageDestroyInput) error
 	DestroyUnusedImages() error
 	DestroyUnusedImage(imageID uuid.UUID) error
+	FindDuplicates(distance int) ([][]*models.Image, error)
 }
 
-func GetService(repo models.ImageRepo) ImageService {
-	imageBackend := config.GetImageBackend()
-
-	var backend ImageBackend
-	if imageBackend == config.FileBackend {
-		backend = &FileBackend{}
-	} else if imageBackend == config.S3Backend {
-		backend = &S3Backend{}
+// GetService returns an image service using the configured image backend.
+func GetService(repo models.ImageRepo) (ImageService, error) {
+	backend, err := GetBackend(config.GetImageBackend())
+	if err != nil {
+		return nil, err
 	}
 
 	return &Service{
 		Repository: repo,
 		Backend:    backend,
-	}
+		Fetcher:    NewFetcher(),
+		Validator:  NewValidator(),
+	}, nil
 }
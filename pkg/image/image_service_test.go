@@ -0,0 +1,25 @@
+package image
+
+import (
+	"testing"
+
+	"github.com/stashapp/stash-box/pkg/manager/config"
+)
+
+func TestGetService(t *testing.T) {
+	defer func() { config.C.ImageBackend = "" }()
+
+	config.C.ImageBackend = string(config.FileBackend)
+	service, err := GetService(nil)
+	if err != nil {
+		t.Fatalf("GetService: %v", err)
+	}
+	if _, ok := service.(*Service).Backend.(*FileBackend); !ok {
+		t.Errorf("backend: got %T want *FileBackend", service.(*Service).Backend)
+	}
+
+	config.C.ImageBackend = "unknown"
+	if _, err := GetService(nil); err == nil {
+		t.Error("GetService with unknown backend: expected error")
+	}
+}
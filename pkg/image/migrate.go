@@ -0,0 +1,131 @@
+package image
+
+import (
+	"bytes"
+	"errors"
+	"io/ioutil"
+
+	"github.com/gofrs/uuid"
+
+	"github.com/stashapp/stash-box/pkg/logger"
+	"github.com/stashapp/stash-box/pkg/models"
+)
+
+const migrationBatchSize = 100
+
+// MigrationResult contains the number of images processed by a migration.
+type MigrationResult struct {
+	Migrated int
+	Skipped  int
+	Failed   int
+}
+
+// MigrateImages copies the stored files of all images from the source to
+// the destination backend, verifying the checksum of each copy. Images
+// already stored in the destination with a matching checksum are skipped,
+// so an interrupted migration is resumed by running it again. Images that
+// fail to migrate are logged and counted, and do not stop the migration.
+func MigrateImages(fac models.Repo, source ImageBackend, destination ImageBackend) (*MigrationResult, error) {
+	var total int
+	err := fac.WithTxn(func() error {
+		var err error
+		total, err = fac.Image().CountWithChecksum()
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	result := &MigrationResult{}
+	after := uuid.Nil
+	for {
+		var images []*models.Image
+		err := fac.WithTxn(func() error {
+			var err error
+			images, err = fac.Image().FindWithChecksum(after, migrationBatchSize)
+			return err
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		if len(images) == 0 {
+			break
+		}
+
+		for _, image := range images {
+			migrated, err := migrateImage(image, source, destination)
+			switch {
+			case err != nil:
+				logger.Errorf("Error migrating image %s: %s", image.ID.String(), err.Error())
+				result.Failed++
+			case migrated:
+				result.Migrated++
+			default:
+				result.Skipped++
+			}
+
+			after = image.ID
+		}
+
+		processed := result.Migrated + result.Skipped + result.Failed
+		logger.Progressf("Migrated images: %d of %d processed (%d copied, %d skipped, %d failed)", processed, total, result.Migrated, result.Skipped, result.Failed)
+	}
+
+	return result, nil
+}
+
+func storedChecksum(backend ImageBackend, image *models.Image) (string, error) {
+	file, err := backend.ReadFile(image)
+	if err != nil {
+		return "", err
+	}
+	defer func() {
+		_ = file.Close()
+	}()
+
+	return calculateChecksum(file)
+}
+
+// migrateImage copies the image to the destination backend, returning false
+// if it was already stored there.
+func migrateImage(image *models.Image, source ImageBackend, destination ImageBackend) (bool, error) {
+	if checksum, err := storedChecksum(destination, image); err == nil && checksum == image.Checksum {
+		return false, nil
+	}
+
+	file, err := source.ReadFile(image)
+	if err != nil {
+		return false, err
+	}
+	data, err := ioutil.ReadAll(file)
+	_ = file.Close()
+	if err != nil {
+		return false, err
+	}
+
+	checksum, err := calculateChecksum(bytes.NewReader(data))
+	if err != nil {
+		return false, err
+	}
+	if checksum != image.Checksum {
+		return false, errors.New("source file does not match the image checksum")
+	}
+
+	// remove incomplete copies, since existing files are not overwritten
+	_ = destination.DestroyFile(image)
+
+	if err := destination.WriteFile(bytes.NewReader(data), image); err != nil {
+		return false, err
+	}
+
+	checksum, err = storedChecksum(destination, image)
+	if err != nil {
+		return false, err
+	}
+	if checksum != image.Checksum {
+		return false, errors.New("copied file does not match the image checksum")
+	}
+
+	return true, nil
+}
@@ -0,0 +1,128 @@
+package image
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"io/ioutil"
+	"testing"
+
+	"github.com/gofrs/uuid"
+
+	"github.com/stashapp/stash-box/pkg/manager/config"
+	"github.com/stashapp/stash-box/pkg/models"
+)
+
+type memoryBackend struct {
+	files  map[uuid.UUID][]byte
+	writes int
+}
+
+func newMemoryBackend() *memoryBackend {
+	return &memoryBackend{files: make(map[uuid.UUID][]byte)}
+}
+
+func (b *memoryBackend) WriteFile(file *bytes.Reader, image *models.Image) error {
+	data, err := ioutil.ReadAll(file)
+	if err != nil {
+		return err
+	}
+	b.files[image.ID] = data
+	b.writes++
+	return nil
+}
+
+func (b *memoryBackend) ReadFile(image *models.Image) (io.ReadCloser, error) {
+	data, found := b.files[image.ID]
+	if !found {
+		return nil, errors.New("not found")
+	}
+	return ioutil.NopCloser(bytes.NewReader(data)), nil
+}
+
+func (b *memoryBackend) DestroyFile(image *models.Image) error {
+	delete(b.files, image.ID)
+	return nil
+}
+
+func newTestImage(t *testing.T, data []byte) *models.Image {
+	t.Helper()
+
+	checksum, err := calculateChecksum(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return &models.Image{
+		ID:       uuid.Must(uuid.NewV4()),
+		Checksum: checksum,
+	}
+}
+
+func TestMigrateImage(t *testing.T) {
+	source := newMemoryBackend()
+	destination := newMemoryBackend()
+
+	data := []byte("image data")
+	image := newTestImage(t, data)
+	source.files[image.ID] = data
+
+	migrated, err := migrateImage(image, source, destination)
+	if err != nil || !migrated {
+		t.Fatalf("migrateImage: got (%v, %v) want (true, nil)", migrated, err)
+	}
+	if !bytes.Equal(destination.files[image.ID], data) {
+		t.Error("image was not copied to the destination")
+	}
+
+	// images already in the destination are skipped when resuming
+	migrated, err = migrateImage(image, source, destination)
+	if err != nil || migrated {
+		t.Errorf("migrateImage of copied image: got (%v, %v) want (false, nil)", migrated, err)
+	}
+	if destination.writes != 1 {
+		t.Errorf("destination writes: got %d want 1", destination.writes)
+	}
+
+	// incomplete copies are replaced
+	destination.files[image.ID] = data[:3]
+	migrated, err = migrateImage(image, source, destination)
+	if err != nil || !migrated {
+		t.Errorf("migrateImage of incomplete copy: got (%v, %v) want (true, nil)", migrated, err)
+	}
+	if !bytes.Equal(destination.files[image.ID], data) {
+		t.Error("incomplete copy was not replaced")
+	}
+}
+
+func TestMigrateImageChecksumMismatch(t *testing.T) {
+	source := newMemoryBackend()
+	destination := newMemoryBackend()
+
+	image := newTestImage(t, []byte("image data"))
+	source.files[image.ID] = []byte("corrupted data")
+
+	if _, err := migrateImage(image, source, destination); err == nil {
+		t.Error("expected error migrating image with mismatched checksum")
+	}
+	if _, found := destination.files[image.ID]; found {
+		t.Error("image with mismatched checksum was copied")
+	}
+
+	missing := newTestImage(t, []byte("missing"))
+	if _, err := migrateImage(missing, source, destination); err == nil {
+		t.Error("expected error migrating missing image")
+	}
+}
+
+func TestGetBackend(t *testing.T) {
+	if backend, err := GetBackend(config.FileBackend); err != nil || backend == nil {
+		t.Errorf("GetBackend(file): got (%v, %v)", backend, err)
+	}
+	if backend, err := GetBackend(config.S3Backend); err != nil || backend == nil {
+		t.Errorf("GetBackend(s3): got (%v, %v)", backend, err)
+	}
+	if _, err := GetBackend("unknown"); err == nil {
+		t.Error("expected error getting unknown backend")
+	}
+}
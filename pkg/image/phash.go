@@ -0,0 +1,155 @@
+package image
+
+import (
+	"bytes"
+	"database/sql"
+	"image"
+	"io/ioutil"
+	"math"
+	"sort"
+
+	"github.com/disintegration/imaging"
+	"github.com/gofrs/uuid"
+
+	"github.com/stashapp/stash-box/pkg/logger"
+	"github.com/stashapp/stash-box/pkg/models"
+)
+
+const (
+	phashSampleSize = 64
+	phashSize       = 8
+)
+
+// calculatePHash returns the DCT-based perceptual hash of the image. The hash
+// is built from the lowest frequencies of the downscaled greyscale image, so
+// that resized and re-encoded copies have hashes within a small Hamming
+// distance of each other.
+func calculatePHash(img image.Image) uint64 {
+	sample := imaging.Grayscale(imaging.Resize(img, phashSampleSize, phashSampleSize, imaging.Box))
+
+	var cosines [phashSize][phashSampleSize]float64
+	for u := 0; u < phashSize; u++ {
+		for x := 0; x < phashSampleSize; x++ {
+			cosines[u][x] = math.Cos(float64(2*x+1) * float64(u) * math.Pi / (2 * phashSampleSize))
+		}
+	}
+
+	// only the low frequency coefficients of the DCT are calculated
+	var rows [phashSampleSize][phashSize]float64
+	for y := 0; y < phashSampleSize; y++ {
+		for u := 0; u < phashSize; u++ {
+			for x := 0; x < phashSampleSize; x++ {
+				rows[y][u] += float64(sample.Pix[y*sample.Stride+x*4]) * cosines[u][x]
+			}
+		}
+	}
+
+	coefficients := make([]float64, 0, phashSize*phashSize)
+	for v := 0; v < phashSize; v++ {
+		for u := 0; u < phashSize; u++ {
+			var c float64
+			for y := 0; y < phashSampleSize; y++ {
+				c += rows[y][u] * cosines[v][y]
+			}
+			coefficients = append(coefficients, c)
+		}
+	}
+
+	sorted := make([]float64, len(coefficients))
+	copy(sorted, coefficients)
+	sort.Float64s(sorted)
+	median := (sorted[len(sorted)/2-1] + sorted[len(sorted)/2]) / 2
+
+	var hash uint64
+	for i, c := range coefficients {
+		if c > median {
+			hash |= 1 << uint(len(coefficients)-1-i)
+		}
+	}
+
+	return hash
+}
+
+// populateImagePHash sets the perceptual hash of the image. SVG images are
+// not hashed.
+func populateImagePHash(imgReader *bytes.Reader, dest *models.Image) error {
+	if dest.Width < 0 {
+		return nil
+	}
+
+	img, _, err := image.Decode(imgReader)
+	if err != nil {
+		return err
+	}
+
+	dest.PHash = sql.NullInt64{
+		// Postgres only supports signed integers, so the hash is stored
+		// as the int64 with the same bits.
+		Int64: int64(calculatePHash(img)),
+		Valid: true,
+	}
+
+	return nil
+}
+
+// CalculateMissingPHashes calculates the perceptual hashes of stored images
+// created before hashes were calculated on upload, returning the number of
+// images updated. Images that fail to hash are logged and skipped.
+func CalculateMissingPHashes(fac models.Repo, backend ImageBackend) (int, error) {
+	updated := 0
+	after := uuid.Nil
+	for {
+		var images []*models.Image
+		err := fac.WithTxn(func() error {
+			var err error
+			images, err = fac.Image().FindWithChecksum(after, migrationBatchSize)
+			return err
+		})
+		if err != nil {
+			return updated, err
+		}
+
+		if len(images) == 0 {
+			break
+		}
+
+		for _, image := range images {
+			after = image.ID
+			if image.PHash.Valid || image.Width < 0 {
+				continue
+			}
+
+			if err := storedPHash(backend, image); err != nil {
+				logger.Errorf("Error calculating phash of image %s: %s", image.ID.String(), err.Error())
+				continue
+			}
+
+			err := fac.WithTxn(func() error {
+				_, err := fac.Image().Update(*image)
+				return err
+			})
+			if err != nil {
+				return updated, err
+			}
+			updated++
+		}
+
+		logger.Progressf("Calculated image phashes: %d updated", updated)
+	}
+
+	return updated, nil
+}
+
+func storedPHash(backend ImageBackend, image *models.Image) error {
+	file, err := backend.ReadFile(image)
+	if err != nil {
+		return err
+	}
+	data, err := ioutil.ReadAll(file)
+	_ = file.Close()
+	if err != nil {
+		return err
+	}
+
+	return populateImagePHash(bytes.NewReader(data), image)
+}
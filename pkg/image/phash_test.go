@@ -0,0 +1,194 @@
+package image
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"io/ioutil"
+	"math/bits"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/disintegration/imaging"
+	"github.com/gofrs/uuid"
+
+	"github.com/stashapp/stash-box/pkg/manager/config"
+	"github.com/stashapp/stash-box/pkg/models"
+)
+
+// newPatternImage returns a blurred image of random blocks.
+func newPatternImage(width, height int, seed int64) image.Image {
+	r := rand.New(rand.NewSource(seed))
+	img := image.NewNRGBA(image.Rect(0, 0, width, height))
+	blocks := make([]uint8, 16*16)
+	for i := range blocks {
+		blocks[i] = uint8(r.Intn(256))
+	}
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			v := blocks[(y*16/height)*16+x*16/width]
+			img.Set(x, y, color.NRGBA{R: v, G: v, B: 255 - v, A: 255})
+		}
+	}
+	return imaging.Blur(img, 4)
+}
+
+func TestCalculatePHash(t *testing.T) {
+	original := newPatternImage(400, 300, 1)
+	hash := calculatePHash(original)
+
+	// resized and re-encoded copies have similar hashes
+	buf := new(bytes.Buffer)
+	if err := jpeg.Encode(buf, imaging.Resize(original, 200, 0, imaging.Lanczos), &jpeg.Options{Quality: 60}); err != nil {
+		t.Fatal(err)
+	}
+	copied, _, err := image.Decode(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if distance := bits.OnesCount64(hash ^ calculatePHash(copied)); distance > 4 {
+		t.Errorf("distance between resized copy: got %d want <= 4", distance)
+	}
+
+	different := newPatternImage(400, 300, 2)
+	if distance := bits.OnesCount64(hash ^ calculatePHash(different)); distance < 16 {
+		t.Errorf("distance between different images: got %d want >= 16", distance)
+	}
+}
+
+func TestPopulateImagePHash(t *testing.T) {
+	data := encodeTestPNG(t, 4, 3)
+
+	img := &models.Image{Width: 4, Height: 3}
+	if err := populateImagePHash(bytes.NewReader(data), img); err != nil {
+		t.Fatal(err)
+	}
+	if !img.PHash.Valid {
+		t.Error("phash was not set")
+	}
+
+	svg := &models.Image{Width: -1, Height: -1}
+	if err := populateImagePHash(bytes.NewReader([]byte("<svg></svg>")), svg); err != nil {
+		t.Fatal(err)
+	}
+	if svg.PHash.Valid {
+		t.Error("phash was set for SVG image")
+	}
+}
+
+func TestClusterDuplicates(t *testing.T) {
+	ids := make([]uuid.UUID, 6)
+	for i := range ids {
+		ids[i] = uuid.FromStringOrNil("00000000-0000-0000-0000-00000000000" + string(rune('0'+i)))
+	}
+
+	clusters := clusterDuplicates([]*models.ImageDuplicate{
+		{ImageID: ids[0], DuplicateID: ids[1]},
+		{ImageID: ids[1], DuplicateID: ids[2]},
+		{ImageID: ids[4], DuplicateID: ids[5]},
+		{ImageID: ids[3], DuplicateID: ids[5]},
+	})
+
+	want := [][]uuid.UUID{
+		{ids[0], ids[1], ids[2]},
+		{ids[3], ids[4], ids[5]},
+	}
+
+	if len(clusters) != len(want) {
+		t.Fatalf("clusters: got %v want %v", clusters, want)
+	}
+	for i := range want {
+		if len(clusters[i]) != len(want[i]) {
+			t.Fatalf("cluster %d: got %v want %v", i, clusters[i], want[i])
+		}
+		for j := range want[i] {
+			if clusters[i][j] != want[i][j] {
+				t.Errorf("cluster %d: got %v want %v", i, clusters[i], want[i])
+			}
+		}
+	}
+
+	if clusters := clusterDuplicates(nil); len(clusters) != 0 {
+		t.Errorf("clusters of no duplicates: got %v", clusters)
+	}
+}
+
+func (r *fakeImageRepo) IsInPendingEdit(imageID uuid.UUID) (bool, error) {
+	return r.pending[imageID], nil
+}
+
+func (r *fakeImageRepo) MergeInto(sourceIDs []uuid.UUID, targetID uuid.UUID) error {
+	r.merged = append(r.merged, sourceIDs...)
+	return nil
+}
+
+func TestMerge(t *testing.T) {
+	dir, err := ioutil.TempDir("", "images")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = os.RemoveAll(dir) }()
+
+	config.C.ImageLocation = dir
+	defer func() { config.C.ImageLocation = "" }()
+
+	destination := models.Image{ID: uuid.Must(uuid.NewV4()), Checksum: "00000000000000000000000000000001"}
+	source := models.Image{ID: uuid.Must(uuid.NewV4()), Checksum: "00000000000000000000000000000002"}
+	pending := models.Image{ID: uuid.Must(uuid.NewV4()), Checksum: "00000000000000000000000000000003"}
+	for _, img := range []models.Image{destination, source, pending} {
+		if err := ioutil.WriteFile(filepath.Join(dir, img.Checksum), []byte(img.Checksum), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	repo := &fakeImageRepo{
+		images:  []models.Image{destination, source, pending},
+		pending: map[uuid.UUID]bool{pending.ID: true},
+	}
+	sourcePath := filepath.Join(dir, source.Checksum)
+	fac := &fakeRepo{images: repo, onCommit: func() {
+		if _, err := os.Stat(sourcePath); err != nil {
+			t.Error("source file was removed before the merge was committed")
+		}
+	}}
+
+	// sources used by pending edits are not merged
+	if _, err := Merge(fac, &FileBackend{}, models.ImageMergeInput{
+		SourceIds:     []string{source.ID.String(), pending.ID.String()},
+		DestinationID: destination.ID.String(),
+	}); err == nil {
+		t.Error("expected error merging image used by a pending edit")
+	}
+	if len(repo.merged) != 0 || len(repo.images) != 3 {
+		t.Errorf("failed merge changed images: merged %v", repo.merged)
+	}
+
+	merged, err := Merge(fac, &FileBackend{}, models.ImageMergeInput{
+		SourceIds:     []string{source.ID.String()},
+		DestinationID: destination.ID.String(),
+	})
+	if err != nil {
+		t.Fatalf("Merge: %v", err)
+	}
+	if merged.ID != destination.ID {
+		t.Errorf("merged image: got %s want %s", merged.ID, destination.ID)
+	}
+	if len(repo.merged) != 1 || repo.merged[0] != source.ID {
+		t.Errorf("merged sources: got %v want [%s]", repo.merged, source.ID)
+	}
+	if img, _ := repo.Find(source.ID); img != nil {
+		t.Error("source image was not destroyed")
+	}
+	if _, err := os.Stat(sourcePath); !os.IsNotExist(err) {
+		t.Error("source file was not removed")
+	}
+	for _, img := range []models.Image{destination, pending} {
+		if _, err := os.Stat(filepath.Join(dir, img.Checksum)); err != nil {
+			t.Errorf("file of image %s was removed", img.ID)
+		}
+	}
+}
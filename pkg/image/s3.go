@@ -5,22 +5,51 @@ import (
 	"context"
 	"crypto/md5"
 	"encoding/hex"
-	"net/http"
+	"fmt"
+	"io"
+	"net/url"
+	"path"
+	"time"
 
+	"github.com/gofrs/uuid"
 	"github.com/minio/minio-go/v7"
 	"github.com/minio/minio-go/v7/pkg/credentials"
 	"github.com/stashapp/stash-box/pkg/manager/config"
 	"github.com/stashapp/stash-box/pkg/models"
 )
 
+// signedURLExpiry is the validity period of presigned image URLs.
+const signedURLExpiry = time.Hour
+
 type S3Backend struct{}
 
-func (s *S3Backend) WriteFile(file *bytes.Reader, image *models.Image) error {
-	s3config := config.GetS3Config()
-	minioClient, err := minio.New(s3config.Endpoint, &minio.Options{
+func newS3Client(s3config *config.S3Config) (*minio.Client, error) {
+	return minio.New(s3config.Endpoint, &minio.Options{
 		Creds:  credentials.NewStaticV4(s3config.AccessKey, s3config.Secret, ""),
-		Secure: true,
+		Secure: !s3config.DisableSSL,
 	})
+}
+
+func s3Path(id string) string {
+	return id[0:2] + "/" + id[2:4] + "/" + id
+}
+
+// s3ObjectPath returns the path of the stored object served for the image,
+// which is the resized copy if the image exceeds the maximum dimension.
+func s3ObjectPath(image *models.Image) string {
+	s3config := config.GetS3Config()
+	id := image.ID.String()
+	if s3config.MaxDimension != 0 && (image.Width > s3config.MaxDimension || image.Height > s3config.MaxDimension) {
+		hash := md5.Sum([]byte(id + "-resized"))
+		id = hex.EncodeToString(hash[:])
+	}
+
+	return s3Path(id)
+}
+
+func (s *S3Backend) WriteFile(file *bytes.Reader, image *models.Image) error {
+	s3config := config.GetS3Config()
+	minioClient, err := newS3Client(s3config)
 	if err != nil {
 		return err
 	}
@@ -52,19 +81,71 @@ func (s *S3Backend) WriteFile(file *bytes.Reader, image *models.Image) error {
 	return nil
 }
 
+func (s *S3Backend) ReadFile(image *models.Image) (io.ReadCloser, error) {
+	s3config := config.GetS3Config()
+	minioClient, err := newS3Client(s3config)
+	if err != nil {
+		return nil, err
+	}
+
+	object, err := minioClient.GetObject(context.TODO(), s3config.Bucket, s3Path(image.ID.String()), minio.GetObjectOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	// the request is only made when the object is first accessed
+	if _, err := object.Stat(); err != nil {
+		_ = object.Close()
+		return nil, err
+	}
+
+	return object, nil
+}
+
+// GetObject returns the stored object served for the image, along with its
+// metadata. The object must be closed by the caller.
+func (s *S3Backend) GetObject(image *models.Image) (*minio.Object, *minio.ObjectInfo, error) {
+	s3config := config.GetS3Config()
+	minioClient, err := newS3Client(s3config)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	object, err := minioClient.GetObject(context.TODO(), s3config.Bucket, s3ObjectPath(image), minio.GetObjectOptions{})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	info, err := object.Stat()
+	if err != nil {
+		_ = object.Close()
+		return nil, nil, err
+	}
+
+	return object, &info, nil
+}
+
+// PresignedURL returns a temporary URL for the stored object served for
+// the image.
+func (s *S3Backend) PresignedURL(image *models.Image) (*url.URL, error) {
+	s3config := config.GetS3Config()
+	minioClient, err := newS3Client(s3config)
+	if err != nil {
+		return nil, err
+	}
+
+	return minioClient.PresignedGetObject(context.TODO(), s3config.Bucket, s3ObjectPath(image), signedURLExpiry, nil)
+}
+
 func (s *S3Backend) DestroyFile(image *models.Image) error {
 	s3config := config.GetS3Config()
-	minioClient, err := minio.New(s3config.Endpoint, &minio.Options{
-		Creds:  credentials.NewStaticV4(s3config.AccessKey, s3config.Secret, ""),
-		Secure: true,
-	})
+	minioClient, err := newS3Client(s3config)
 	if err != nil {
 		return err
 	}
 
 	id := image.ID.String()
-	path := id[0:2] + "/" + id[2:4] + "/" + id
-	err = minioClient.RemoveObject(context.TODO(), s3config.Bucket, path, minio.RemoveObjectOptions{})
+	err = minioClient.RemoveObject(context.TODO(), s3config.Bucket, s3Path(id), minio.RemoveObjectOptions{})
 
 	if err != nil {
 		return err
@@ -72,27 +153,71 @@ func (s *S3Backend) DestroyFile(image *models.Image) error {
 
 	hash := md5.Sum([]byte(id + "-resized"))
 	resizedID := hex.EncodeToString(hash[:])
-	path = resizedID[0:2] + "/" + resizedID[2:4] + "/" + resizedID
 	// Resized versions may or may not exist, so we attempt to delete and ignore the results
-	_ = minioClient.RemoveObject(context.TODO(), s3config.Bucket, path, minio.RemoveObjectOptions{})
+	_ = minioClient.RemoveObject(context.TODO(), s3config.Bucket, s3Path(resizedID), minio.RemoveObjectOptions{})
 
 	return nil
 }
 
-func uploadS3File(client minio.Client, file []byte, bucket string, id string) error {
-	ctx := context.TODO()
+// ListFiles calls fn for each image stored in the bucket. Resized copies are
+// not listed, and are removed along with the original.
+func (s *S3Backend) ListFiles(fn func(image *models.Image, modified time.Time) error) error {
+	s3config := config.GetS3Config()
+	minioClient, err := newS3Client(s3config)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	for object := range minioClient.ListObjects(ctx, s3config.Bucket, minio.ListObjectsOptions{Recursive: true}) {
+		if object.Err != nil {
+			return object.Err
+		}
+
+		// resized copies are keyed by a hash of the image ID
+		id, err := uuid.FromString(path.Base(object.Key))
+		if err != nil {
+			continue
+		}
+
+		if err := fn(&models.Image{ID: id}, object.LastModified); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// CheckHealth returns an error if the bucket does not exist or cannot be
+// reached.
+func (s *S3Backend) CheckHealth(ctx context.Context) error {
+	s3config := config.GetS3Config()
+	minioClient, err := newS3Client(s3config)
+	if err != nil {
+		return err
+	}
 
-	// SVG is not correctly detected so we set it manually if the file is xml
-	contentType := http.DetectContentType(file)
-	if contentType == "text/xml; charset=utf-8" || contentType == "text/plain; charset=utf-8" {
-		contentType = "image/svg+xml"
+	exists, err := minioClient.BucketExists(ctx, s3config.Bucket)
+	if err != nil {
+		return err
 	}
+	if !exists {
+		return fmt.Errorf("bucket %s does not exist", s3config.Bucket)
+	}
+
+	return nil
+}
+
+func uploadS3File(client minio.Client, file []byte, bucket string, id string) error {
+	ctx := context.TODO()
 
-	path := id[0:2] + "/" + id[2:4] + "/" + id
+	contentType := DetectContentType(file)
 	_, err := client.PutObject(
 		ctx,
 		bucket,
-		path,
+		s3Path(id),
 		bytes.NewReader(file),
 		int64(len(file)),
 		minio.PutObjectOptions{
@@ -0,0 +1,142 @@
+package image
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gofrs/uuid"
+	"github.com/minio/minio-go/v7"
+
+	"github.com/stashapp/stash-box/pkg/manager/config"
+	"github.com/stashapp/stash-box/pkg/models"
+)
+
+const testBucket = "images"
+
+// newTestS3Server returns a minimal S3-compatible server storing the
+// provided objects, keyed by bucket path.
+func newTestS3Server(objects map[string][]byte) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, ok := r.URL.Query()["location"]; ok {
+			w.Header().Set("Content-Type", "application/xml")
+			_, _ = w.Write([]byte(`<?xml version="1.0" encoding="UTF-8"?><LocationConstraint xmlns="http://s3.amazonaws.com/doc/2006-03-01/">us-east-1</LocationConstraint>`))
+			return
+		}
+
+		data, found := objects[strings.TrimPrefix(r.URL.Path, "/"+testBucket+"/")]
+		if !found {
+			w.Header().Set("Content-Type", "application/xml")
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`<?xml version="1.0" encoding="UTF-8"?><Error><Code>NoSuchKey</Code><Message>The specified key does not exist.</Message></Error>`))
+			return
+		}
+
+		w.Header().Set("Content-Type", DetectContentType(data))
+		w.Header().Set("ETag", `"etag"`)
+		http.ServeContent(w, r, "", time.Now(), bytes.NewReader(data))
+	}))
+}
+
+func setTestS3Config(t *testing.T, server *httptest.Server) {
+	t.Helper()
+
+	config.C.S3.S3Config = config.S3Config{
+		Endpoint:   strings.TrimPrefix(server.URL, "http://"),
+		Bucket:     testBucket,
+		AccessKey:  "access",
+		Secret:     "secret",
+		DisableSSL: true,
+	}
+}
+
+func TestS3GetObject(t *testing.T) {
+	id := uuid.Must(uuid.NewV4())
+	data := []byte(`<svg xmlns="http://www.w3.org/2000/svg"></svg>`)
+	path := id.String()[0:2] + "/" + id.String()[2:4] + "/" + id.String()
+
+	server := newTestS3Server(map[string][]byte{path: data})
+	defer server.Close()
+	setTestS3Config(t, server)
+	defer func() {
+		config.C.S3.S3Config = config.S3Config{}
+	}()
+
+	backend := &S3Backend{}
+	object, info, err := backend.GetObject(&models.Image{ID: id})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		_ = object.Close()
+	}()
+
+	if info.ContentType != "image/svg+xml" {
+		t.Errorf("content type: got %s want image/svg+xml", info.ContentType)
+	}
+	if info.ETag != "etag" {
+		t.Errorf("etag: got %s want etag", info.ETag)
+	}
+
+	read, err := ioutil.ReadAll(object)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(read, data) {
+		t.Errorf("content: got %s want %s", read, data)
+	}
+
+	_, _, err = backend.GetObject(&models.Image{ID: uuid.Must(uuid.NewV4())})
+	if code := minio.ToErrorResponse(err).Code; code != "NoSuchKey" {
+		t.Errorf("missing object: got error code %q want NoSuchKey", code)
+	}
+}
+
+func TestS3PresignedURL(t *testing.T) {
+	server := newTestS3Server(nil)
+	defer server.Close()
+	setTestS3Config(t, server)
+	defer func() {
+		config.C.S3.S3Config = config.S3Config{}
+	}()
+
+	id := uuid.Must(uuid.NewV4())
+	signedURL, err := (&S3Backend{}).PresignedURL(&models.Image{ID: id})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.HasSuffix(signedURL.Path, "/"+id.String()) {
+		t.Errorf("unexpected presigned path: %s", signedURL.Path)
+	}
+	if signedURL.Query().Get("X-Amz-Signature") == "" {
+		t.Error("presigned URL is not signed")
+	}
+}
+
+func TestS3CheckHealth(t *testing.T) {
+	// the bucket exists if its root can be read
+	server := newTestS3Server(map[string][]byte{"": {}})
+	defer server.Close()
+	setTestS3Config(t, server)
+	defer func() {
+		config.C.S3.S3Config = config.S3Config{}
+	}()
+
+	if err := (&S3Backend{}).CheckHealth(context.Background()); err != nil {
+		t.Errorf("CheckHealth: %s", err.Error())
+	}
+
+	missing := newTestS3Server(nil)
+	defer missing.Close()
+	setTestS3Config(t, missing)
+
+	if err := (&S3Backend{}).CheckHealth(context.Background()); err == nil {
+		t.Error("expected error for missing bucket")
+	}
+}
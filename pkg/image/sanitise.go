@@ -0,0 +1,604 @@
+package image
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/xml"
+	"fmt"
+	"html"
+	"image"
+	"image/jpeg"
+	"io"
+	"io/ioutil"
+	"regexp"
+	"strings"
+	"unicode"
+
+	"github.com/disintegration/imaging"
+	issvg "github.com/h2non/go-is-svg"
+
+	"github.com/stashapp/stash-box/pkg/manager/config"
+)
+
+// ValidationErrorCode identifies the reason an image was rejected.
+type ValidationErrorCode string
+
+const (
+	ValidationErrorFileTooLarge       ValidationErrorCode = "FILE_TOO_LARGE"
+	ValidationErrorDimensionsTooLarge ValidationErrorCode = "DIMENSIONS_TOO_LARGE"
+	ValidationErrorUnsupportedFormat  ValidationErrorCode = "UNSUPPORTED_FORMAT"
+	ValidationErrorInvalidImage       ValidationErrorCode = "INVALID_IMAGE"
+)
+
+// ValidationError is returned when an image is rejected. The code and the
+// exceeded limit are included in the extensions of the GraphQL error.
+type ValidationError struct {
+	Code    ValidationErrorCode
+	Message string
+	Limit   int64
+}
+
+func (e *ValidationError) Error() string {
+	return e.Message
+}
+
+// Extensions returns the details of the error for GraphQL clients.
+func (e *ValidationError) Extensions() map[string]interface{} {
+	ret := map[string]interface{}{
+		"code": e.Code,
+	}
+	if e.Limit > 0 {
+		ret["limit"] = e.Limit
+	}
+	return ret
+}
+
+func invalidImageError(message string) *ValidationError {
+	return &ValidationError{
+		Code:    ValidationErrorInvalidImage,
+		Message: message,
+	}
+}
+
+// Validator checks images against the configured limits, and removes
+// metadata and active content from accepted images.
+type Validator struct {
+	MaxSize      int64
+	MaxDimension int
+	Formats      []string
+}
+
+// NewValidator returns a Validator using the image validation settings.
+func NewValidator() *Validator {
+	return &Validator{
+		MaxSize:      config.GetImageMaxSize(),
+		MaxDimension: config.GetImageMaxDimension(),
+		Formats:      config.GetImageFormats(),
+	}
+}
+
+func (v *Validator) fileTooLargeError() *ValidationError {
+	return &ValidationError{
+		Code:    ValidationErrorFileTooLarge,
+		Message: fmt.Sprintf("image exceeds the maximum size of %d bytes", v.MaxSize),
+		Limit:   v.MaxSize,
+	}
+}
+
+// Read reads the image file, without reading past the maximum size.
+func (v *Validator) Read(r io.Reader) ([]byte, error) {
+	if v.MaxSize > 0 {
+		r = io.LimitReader(r, v.MaxSize+1)
+	}
+
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	if v.MaxSize > 0 && int64(len(data)) > v.MaxSize {
+		return nil, v.fileTooLargeError()
+	}
+
+	return data, nil
+}
+
+// Process validates the image, returning a *ValidationError if it is
+// rejected. It returns the image with metadata removed from JPEG and PNG
+// images, and scripts and external references removed from SVG images.
+func (v *Validator) Process(data []byte) ([]byte, error) {
+	if v.MaxSize > 0 && int64(len(data)) > v.MaxSize {
+		return nil, v.fileTooLargeError()
+	}
+
+	// the dimensions are checked before decoding the full image
+	imageConfig, format, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		if !issvg.IsSVG(data) {
+			return nil, invalidImageError("file is not a supported image")
+		}
+		format = "svg"
+	}
+
+	if !v.allowedFormat(format) {
+		return nil, &ValidationError{
+			Code:    ValidationErrorUnsupportedFormat,
+			Message: fmt.Sprintf("unsupported image format: %s", format),
+		}
+	}
+
+	if format == "svg" {
+		return sanitiseSVG(data)
+	}
+
+	if v.MaxDimension > 0 && (imageConfig.Width > v.MaxDimension || imageConfig.Height > v.MaxDimension) {
+		return nil, &ValidationError{
+			Code:    ValidationErrorDimensionsTooLarge,
+			Message: fmt.Sprintf("image dimensions exceed the maximum of %d pixels", v.MaxDimension),
+			Limit:   int64(v.MaxDimension),
+		}
+	}
+
+	switch format {
+	case "jpeg":
+		return stripJPEGMetadata(data)
+	case "png":
+		return stripPNGMetadata(data)
+	}
+
+	return data, nil
+}
+
+func (v *Validator) allowedFormat(format string) bool {
+	for _, f := range v.Formats {
+		if strings.EqualFold(f, format) {
+			return true
+		}
+	}
+	return false
+}
+
+const (
+	jpegMarkerSOS   = 0xda
+	jpegMarkerAPP1  = 0xe1
+	jpegMarkerAPP13 = 0xed
+	jpegMarkerCOM   = 0xfe
+)
+
+// stripJPEGMetadata removes the EXIF, XMP and IPTC segments and comments
+// from the JPEG image. Images with an EXIF orientation are re-encoded with
+// the orientation applied, since it is lost when the EXIF segment is
+// removed.
+func stripJPEGMetadata(data []byte) ([]byte, error) {
+	if len(data) < 2 || data[0] != 0xff || data[1] != 0xd8 {
+		return nil, invalidImageError("invalid JPEG image")
+	}
+
+	out := bytes.NewBuffer(make([]byte, 0, len(data)))
+	out.Write(data[:2])
+
+	i := 2
+	for {
+		if i+1 >= len(data) || data[i] != 0xff {
+			return nil, invalidImageError("invalid JPEG image")
+		}
+
+		marker := data[i+1]
+		if marker == 0xff {
+			// fill byte
+			i++
+			continue
+		}
+
+		// the entropy-coded data follows the start of scan segment
+		if marker == jpegMarkerSOS {
+			out.Write(data[i:])
+			break
+		}
+
+		// markers without a segment
+		if marker == 0x01 || (marker >= 0xd0 && marker <= 0xd7) {
+			out.Write(data[i : i+2])
+			i += 2
+			continue
+		}
+
+		if i+4 > len(data) {
+			return nil, invalidImageError("invalid JPEG image")
+		}
+		end := i + 2 + int(binary.BigEndian.Uint16(data[i+2:i+4]))
+		if end > len(data) {
+			return nil, invalidImageError("invalid JPEG image")
+		}
+
+		switch marker {
+		case jpegMarkerAPP1:
+			if exifOrientation(data[i+4:end]) > 1 {
+				return reencodeJPEG(data)
+			}
+		case jpegMarkerAPP13, jpegMarkerCOM:
+		default:
+			out.Write(data[i:end])
+		}
+
+		i = end
+	}
+
+	return out.Bytes(), nil
+}
+
+// exifOrientation returns the orientation tag of the APP1 segment payload,
+// or 0 if it is not an EXIF segment or has no orientation.
+func exifOrientation(payload []byte) int {
+	const exifHeader = "Exif\x00\x00"
+	if !bytes.HasPrefix(payload, []byte(exifHeader)) {
+		return 0
+	}
+	tiff := payload[len(exifHeader):]
+	if len(tiff) < 8 {
+		return 0
+	}
+
+	var order binary.ByteOrder
+	switch string(tiff[:2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		return 0
+	}
+
+	ifd := int(order.Uint32(tiff[4:8]))
+	if ifd+2 > len(tiff) {
+		return 0
+	}
+
+	entries := int(order.Uint16(tiff[ifd : ifd+2]))
+	for e := 0; e < entries; e++ {
+		entry := ifd + 2 + e*12
+		if entry+12 > len(tiff) {
+			return 0
+		}
+		if order.Uint16(tiff[entry:entry+2]) == 0x0112 {
+			return int(order.Uint16(tiff[entry+8 : entry+10]))
+		}
+	}
+
+	return 0
+}
+
+func reencodeJPEG(data []byte) ([]byte, error) {
+	img, err := imaging.Decode(bytes.NewReader(data), imaging.AutoOrientation(true))
+	if err != nil {
+		return nil, invalidImageError("invalid JPEG image")
+	}
+
+	buf := new(bytes.Buffer)
+	if err := jpeg.Encode(buf, img, &jpeg.Options{Quality: 95}); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+var pngSignature = []byte("\x89PNG\r\n\x1a\n")
+
+// pngMetadataChunks are the ancillary PNG chunks removed from images.
+var pngMetadataChunks = map[string]bool{
+	"eXIf": true,
+	"tEXt": true,
+	"zTXt": true,
+	"iTXt": true,
+	"tIME": true,
+}
+
+// stripPNGMetadata removes the EXIF, text and time chunks from the PNG
+// image.
+func stripPNGMetadata(data []byte) ([]byte, error) {
+	if !bytes.HasPrefix(data, pngSignature) {
+		return nil, invalidImageError("invalid PNG image")
+	}
+
+	out := bytes.NewBuffer(make([]byte, 0, len(data)))
+	out.Write(pngSignature)
+
+	i := len(pngSignature)
+	for i < len(data) {
+		if i+8 > len(data) {
+			return nil, invalidImageError("invalid PNG image")
+		}
+
+		// length, type, data and crc
+		end := i + 12 + int(binary.BigEndian.Uint32(data[i:i+4]))
+		if end > len(data) || end < i {
+			return nil, invalidImageError("invalid PNG image")
+		}
+
+		chunkType := string(data[i+4 : i+8])
+		if !pngMetadataChunks[chunkType] {
+			out.Write(data[i:end])
+		}
+
+		i = end
+		if chunkType == "IEND" {
+			break
+		}
+	}
+
+	return out.Bytes(), nil
+}
+
+// svgAllowedElements are the elements kept in SVG images. Any other element
+// is removed along with its content.
+var svgAllowedElements = map[string]bool{
+	"svg": true, "g": true, "defs": true, "symbol": true, "use": true,
+	"title": true, "desc": true, "switch": true, "view": true, "a": true,
+	"image": true, "style": true,
+
+	"path": true, "rect": true, "circle": true, "ellipse": true,
+	"line": true, "polyline": true, "polygon": true,
+
+	"text": true, "tspan": true, "textpath": true,
+
+	"lineargradient": true, "radialgradient": true, "stop": true,
+	"pattern": true, "clippath": true, "mask": true, "marker": true,
+
+	"filter": true, "feblend": true, "fecolormatrix": true,
+	"fecomponenttransfer": true, "fecomposite": true,
+	"feconvolvematrix": true, "fediffuselighting": true,
+	"fedisplacementmap": true, "fedistantlight": true,
+	"fedropshadow": true, "feflood": true, "fefunca": true, "fefuncb": true,
+	"fefuncg": true, "fefuncr": true, "fegaussianblur": true,
+	"feimage": true, "femerge": true, "femergenode": true,
+	"femorphology": true, "feoffset": true, "fepointlight": true,
+	"fespecularlighting": true, "fespotlight": true, "fetile": true,
+	"feturbulence": true,
+
+	"animate": true, "animatemotion": true, "animatetransform": true,
+	"set": true, "mpath": true,
+}
+
+// svgAnimationElements are the elements that can change the value of
+// another attribute.
+var svgAnimationElements = map[string]bool{
+	"animate":          true,
+	"animatemotion":    true,
+	"animatetransform": true,
+	"set":              true,
+}
+
+// svgAllowedAttributes are the attributes without a namespace prefix kept
+// in SVG images.
+var svgAllowedAttributes = map[string]bool{
+	"xmlns": true, "id": true, "class": true, "style": true, "lang": true,
+	"href": true, "version": true, "baseprofile": true, "viewbox": true,
+	"preserveaspectratio": true, "transform": true,
+	"systemlanguage": true, "requiredfeatures": true,
+	"requiredextensions": true,
+
+	"x": true, "y": true, "x1": true, "y1": true, "x2": true, "y2": true,
+	"cx": true, "cy": true, "r": true, "rx": true, "ry": true, "fx": true,
+	"fy": true, "fr": true, "d": true, "points": true, "width": true,
+	"height": true, "pathlength": true,
+
+	"fill": true, "fill-opacity": true, "fill-rule": true, "stroke": true,
+	"stroke-width": true, "stroke-opacity": true, "stroke-linecap": true,
+	"stroke-linejoin": true, "stroke-miterlimit": true,
+	"stroke-dasharray": true, "stroke-dashoffset": true, "opacity": true,
+	"color": true, "display": true, "visibility": true, "overflow": true,
+	"clip-path": true, "clip-rule": true, "mask": true, "filter": true,
+	"marker-start": true, "marker-mid": true, "marker-end": true,
+	"paint-order": true, "vector-effect": true, "mix-blend-mode": true,
+	"isolation": true, "color-interpolation": true,
+	"color-interpolation-filters": true, "color-rendering": true,
+	"shape-rendering": true, "text-rendering": true,
+	"image-rendering": true, "enable-background": true,
+
+	"font-family": true, "font-size": true, "font-weight": true,
+	"font-style": true, "font-variant": true, "font-stretch": true,
+	"text-anchor": true, "text-decoration": true,
+	"dominant-baseline": true, "alignment-baseline": true,
+	"baseline-shift": true, "letter-spacing": true, "word-spacing": true,
+	"writing-mode": true, "direction": true, "dx": true, "dy": true,
+	"rotate": true, "textlength": true, "lengthadjust": true,
+	"startoffset": true, "method": true, "spacing": true, "side": true,
+	"path": true,
+
+	"offset": true, "stop-color": true, "stop-opacity": true,
+	"gradientunits": true, "gradienttransform": true,
+	"spreadmethod": true, "patternunits": true,
+	"patterncontentunits": true, "patterntransform": true,
+	"clippathunits": true, "maskunits": true, "maskcontentunits": true,
+	"markerunits": true, "markerwidth": true, "markerheight": true,
+	"refx": true, "refy": true, "orient": true,
+
+	"filterunits": true, "primitiveunits": true, "in": true, "in2": true,
+	"result": true, "stddeviation": true, "mode": true, "type": true,
+	"values": true, "tablevalues": true, "slope": true, "intercept": true,
+	"amplitude": true, "exponent": true, "k1": true, "k2": true,
+	"k3": true, "k4": true, "operator": true, "radius": true,
+	"scale": true, "xchannelselector": true, "ychannelselector": true,
+	"basefrequency": true, "numoctaves": true, "seed": true,
+	"stitchtiles": true, "flood-color": true, "flood-opacity": true,
+	"lighting-color": true, "surfacescale": true,
+	"diffuseconstant": true, "specularconstant": true,
+	"specularexponent": true, "kernelmatrix": true, "order": true,
+	"divisor": true, "bias": true, "targetx": true, "targety": true,
+	"edgemode": true, "kernelunitlength": true, "preservealpha": true,
+	"azimuth": true, "elevation": true, "pointsatx": true,
+	"pointsaty": true, "pointsatz": true, "z": true,
+	"limitingconeangle": true,
+
+	"attributename": true, "attributetype": true, "begin": true,
+	"dur": true, "end": true, "repeatcount": true, "repeatdur": true,
+	"from": true, "to": true, "by": true, "calcmode": true,
+	"keytimes": true, "keysplines": true, "keypoints": true,
+	"additive": true, "accumulate": true, "restart": true, "min": true,
+	"max": true,
+}
+
+var (
+	svgExternalCSSRegex = regexp.MustCompile(`(?i)@import|url\(\s*['"]?\s*[^'"#\s)]`)
+	svgDataImageRegex   = regexp.MustCompile(`^data:image/(png|jpeg|gif|webp);`)
+	svgScriptURLRegex   = regexp.MustCompile(`(javascript|vbscript):`)
+)
+
+// sanitiseSVG removes everything but known SVG elements and attributes from
+// the SVG image, along with scripts and references to external resources.
+// Comments and document type declarations are also removed.
+func sanitiseSVG(data []byte) ([]byte, error) {
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	decoder.Strict = true
+
+	out := new(bytes.Buffer)
+	skipDepth := 0
+	inStyle := false
+	// RawToken does not check that elements are closed correctly
+	var open []xml.Name
+	for {
+		token, err := decoder.RawToken()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, invalidImageError("invalid SVG image")
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			open = append(open, t.Name)
+			if skipDepth > 0 || !allowedSVGElement(t) {
+				skipDepth++
+				continue
+			}
+			inStyle = strings.EqualFold(t.Name.Local, "style")
+			writeSVGStartElement(out, t)
+		case xml.EndElement:
+			if len(open) == 0 || open[len(open)-1] != t.Name {
+				return nil, invalidImageError("invalid SVG image")
+			}
+			open = open[:len(open)-1]
+
+			if skipDepth > 0 {
+				skipDepth--
+				continue
+			}
+			inStyle = false
+			out.WriteString("</" + svgName(t.Name) + ">")
+		case xml.CharData:
+			// style sheets with external references are removed
+			if skipDepth > 0 || (inStyle && svgExternalCSSRegex.Match(t)) {
+				continue
+			}
+			out.WriteString(html.EscapeString(string(t)))
+		case xml.ProcInst:
+			if t.Target == "xml" {
+				out.WriteString("<?xml " + string(t.Inst) + "?>")
+			}
+		}
+	}
+
+	if len(open) > 0 || !issvg.IsSVG(out.Bytes()) {
+		return nil, invalidImageError("invalid SVG image")
+	}
+
+	return out.Bytes(), nil
+}
+
+func svgName(name xml.Name) string {
+	if name.Space != "" {
+		return name.Space + ":" + name.Local
+	}
+	return name.Local
+}
+
+// allowedSVGElement returns true if the element is a known SVG element.
+// Animations of links and event handlers are not allowed, since they can
+// change a link to a script after it has been sanitised.
+func allowedSVGElement(element xml.StartElement) bool {
+	if element.Name.Space != "" && element.Name.Space != "svg" {
+		return false
+	}
+
+	name := strings.ToLower(element.Name.Local)
+	if !svgAllowedElements[name] {
+		return false
+	}
+
+	if svgAnimationElements[name] {
+		for _, attr := range element.Attr {
+			if strings.ToLower(attr.Name.Local) != "attributename" {
+				continue
+			}
+
+			// the attribute name may have a namespace prefix
+			target := normaliseSVGValue(attr.Value)
+			if i := strings.LastIndex(target, ":"); i >= 0 {
+				target = target[i+1:]
+			}
+			if target == "href" || strings.HasPrefix(target, "on") {
+				return false
+			}
+		}
+	}
+
+	return true
+}
+
+func writeSVGStartElement(out *bytes.Buffer, element xml.StartElement) {
+	name := svgName(element.Name)
+	out.WriteString("<" + name)
+	for _, attr := range element.Attr {
+		if !allowedSVGAttribute(attr) {
+			continue
+		}
+		out.WriteString(" " + svgName(attr.Name) + `="` + html.EscapeString(attr.Value) + `"`)
+	}
+	out.WriteString(">")
+}
+
+func allowedSVGAttribute(attr xml.Attr) bool {
+	name := strings.ToLower(attr.Name.Local)
+	value := normaliseSVGValue(attr.Value)
+
+	switch strings.ToLower(attr.Name.Space) {
+	case "":
+		if !svgAllowedAttributes[name] {
+			return false
+		}
+	case "xmlns":
+		// namespace declarations
+		return true
+	case "xlink":
+		if name != "href" {
+			return false
+		}
+	case "xml":
+		if name != "space" && name != "lang" {
+			return false
+		}
+	default:
+		return false
+	}
+
+	switch {
+	case svgScriptURLRegex.MatchString(value):
+		return false
+	case name == "href":
+		return strings.HasPrefix(value, "#") || svgDataImageRegex.MatchString(value)
+	}
+
+	// style sheets and presentation attributes may reference external
+	// resources with url()
+	return !svgExternalCSSRegex.MatchString(attr.Value)
+}
+
+// normaliseSVGValue returns the lower case value with whitespace and
+// control characters removed, which browsers ignore in URLs.
+func normaliseSVGValue(value string) string {
+	return strings.Map(func(r rune) rune {
+		if r <= ' ' || r == 0x7f {
+			return -1
+		}
+		return unicode.ToLower(r)
+	}, value)
+}
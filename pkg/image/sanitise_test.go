@@ -0,0 +1,249 @@
+package image
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"image"
+	"image/jpeg"
+	"strings"
+	"testing"
+)
+
+func newTestValidator() *Validator {
+	return &Validator{
+		MaxSize:      1024 * 1024,
+		MaxDimension: 100,
+		Formats:      []string{"jpeg", "png", "svg"},
+	}
+}
+
+func encodeTestJPEG(t *testing.T, width, height int) []byte {
+	t.Helper()
+
+	buf := new(bytes.Buffer)
+	if err := jpeg.Encode(buf, image.NewRGBA(image.Rect(0, 0, width, height)), nil); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+// jpegSegment returns a JPEG segment with the provided marker and payload.
+func jpegSegment(marker byte, payload []byte) []byte {
+	segment := []byte{0xff, marker, 0, 0}
+	binary.BigEndian.PutUint16(segment[2:], uint16(len(payload)+2))
+	return append(segment, payload...)
+}
+
+// exifPayload returns an EXIF APP1 payload with the provided orientation.
+func exifPayload(orientation uint16) []byte {
+	tiff := []byte("MM\x00\x2a\x00\x00\x00\x08")
+	tiff = append(tiff, 0, 1)
+	entry := make([]byte, 12)
+	binary.BigEndian.PutUint16(entry[0:], 0x0112)
+	binary.BigEndian.PutUint16(entry[2:], 3)
+	binary.BigEndian.PutUint32(entry[4:], 1)
+	binary.BigEndian.PutUint16(entry[8:], orientation)
+	tiff = append(tiff, entry...)
+	tiff = append(tiff, 0, 0, 0, 0)
+	return append([]byte("Exif\x00\x00"), tiff...)
+}
+
+// insertJPEGSegments inserts the segments after the start of image marker.
+func insertJPEGSegments(data []byte, segments ...[]byte) []byte {
+	ret := append([]byte{}, data[:2]...)
+	for _, s := range segments {
+		ret = append(ret, s...)
+	}
+	return append(ret, data[2:]...)
+}
+
+func pngChunk(chunkType string, data []byte) []byte {
+	chunk := make([]byte, 4)
+	binary.BigEndian.PutUint32(chunk, uint32(len(data)))
+	chunk = append(chunk, chunkType...)
+	chunk = append(chunk, data...)
+	crc := make([]byte, 4)
+	binary.BigEndian.PutUint32(crc, crc32.ChecksumIEEE(append([]byte(chunkType), data...)))
+	return append(chunk, crc...)
+}
+
+func validationErrorCode(err error) ValidationErrorCode {
+	var validationErr *ValidationError
+	if errors.As(err, &validationErr) {
+		return validationErr.Code
+	}
+	return ""
+}
+
+func TestValidatorLimits(t *testing.T) {
+	v := newTestValidator()
+
+	tests := []struct {
+		name string
+		data []byte
+		want ValidationErrorCode
+	}{
+		{"valid", encodeTestJPEG(t, 100, 50), ""},
+		{"too large", make([]byte, v.MaxSize+1), ValidationErrorFileTooLarge},
+		{"too wide", encodeTestJPEG(t, 101, 50), ValidationErrorDimensionsTooLarge},
+		{"too high", encodeTestPNG(t, 50, 101), ValidationErrorDimensionsTooLarge},
+		{"unsupported format", []byte("GIF89a\x01\x00\x01\x00\x00\x00\x00;"), ValidationErrorUnsupportedFormat},
+		{"invalid", []byte("not an image"), ValidationErrorInvalidImage},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := v.Process(tt.data)
+			if got := validationErrorCode(err); got != tt.want {
+				t.Errorf("Process: got code %q (%v) want %q", got, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidatorRead(t *testing.T) {
+	v := newTestValidator()
+	v.MaxSize = 10
+
+	if _, err := v.Read(strings.NewReader("0123456789")); err != nil {
+		t.Errorf("Read: %v", err)
+	}
+	if _, err := v.Read(strings.NewReader("0123456789a")); validationErrorCode(err) != ValidationErrorFileTooLarge {
+		t.Errorf("Read: got %v want %s", err, ValidationErrorFileTooLarge)
+	}
+}
+
+func TestValidationErrorExtensions(t *testing.T) {
+	err := &ValidationError{Code: ValidationErrorFileTooLarge, Limit: 10}
+	extensions := err.Extensions()
+	if extensions["code"] != ValidationErrorFileTooLarge || extensions["limit"] != int64(10) {
+		t.Errorf("Extensions: got %v", extensions)
+	}
+}
+
+func TestStripJPEGMetadata(t *testing.T) {
+	original := encodeTestJPEG(t, 8, 4)
+	data := insertJPEGSegments(original,
+		jpegSegment(jpegMarkerAPP1, exifPayload(1)),
+		jpegSegment(jpegMarkerAPP1, []byte("http://ns.adobe.com/xap/1.0/\x00<x:xmpmeta/>")),
+		jpegSegment(jpegMarkerCOM, []byte("comment")),
+	)
+
+	got, err := newTestValidator().Process(data)
+	if err != nil {
+		t.Fatalf("Process: %v", err)
+	}
+	if !bytes.Equal(got, original) {
+		t.Error("metadata was not removed from JPEG image")
+	}
+
+	// orientation is applied before the EXIF data is removed
+	data = insertJPEGSegments(original, jpegSegment(jpegMarkerAPP1, exifPayload(6)))
+	got, err = newTestValidator().Process(data)
+	if err != nil {
+		t.Fatalf("Process: %v", err)
+	}
+	if bytes.Contains(got, []byte("Exif")) {
+		t.Error("EXIF data was not removed from rotated JPEG image")
+	}
+	config, _, err := image.DecodeConfig(bytes.NewReader(got))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if config.Width != 4 || config.Height != 8 {
+		t.Errorf("rotated dimensions: got %dx%d want 4x8", config.Width, config.Height)
+	}
+}
+
+func TestStripPNGMetadata(t *testing.T) {
+	original := encodeTestPNG(t, 4, 3)
+	// insert metadata chunks after the IHDR chunk
+	ihdrEnd := len(pngSignature) + 12 + 13
+	data := append([]byte{}, original[:ihdrEnd]...)
+	data = append(data, pngChunk("tEXt", []byte("Comment\x00secret"))...)
+	data = append(data, pngChunk("eXIf", exifPayload(1)[6:])...)
+	data = append(data, original[ihdrEnd:]...)
+
+	got, err := newTestValidator().Process(data)
+	if err != nil {
+		t.Fatalf("Process: %v", err)
+	}
+	if !bytes.Equal(got, original) {
+		t.Error("metadata was not removed from PNG image")
+	}
+}
+
+func TestSanitiseSVG(t *testing.T) {
+	svg := `<?xml version="1.0"?>
+<!DOCTYPE svg PUBLIC "-//W3C//DTD SVG 1.1//EN" "http://www.w3.org/Graphics/SVG/1.1/DTD/svg11.dtd">
+<svg xmlns="http://www.w3.org/2000/svg" xmlns:xlink="http://www.w3.org/1999/xlink" onload="alert(1)" width="10" height="10">
+	<!-- comment -->
+	<script>alert(1)</script>
+	<style>@import url(http://example.com/style.css);</style>
+	<style>rect { fill: url(#gradient); }</style>
+	<foreignObject><div xmlns="http://www.w3.org/1999/xhtml">html</div></foreignObject>
+	<rect id="r" width="10" height="10" onclick="alert(1)" style="fill: red"/>
+	<use xlink:href="#r"/>
+	<use href="http://example.com/image.svg#r"/>
+	<image href="data:image/png;base64,AAAA"/>
+	<image href="data:image/svg+xml;base64,AAAA"/>
+	<a href="javascript:alert(1)"><text>link</text></a>
+	<rect style="background: url(http://example.com/track.png)"/>
+</svg>`
+
+	got, err := newTestValidator().Process([]byte(svg))
+	if err != nil {
+		t.Fatalf("Process: %v", err)
+	}
+	result := string(got)
+
+	for _, removed := range []string{"alert", "script", "@import", "foreignObject", "html", "comment", "DOCTYPE", "example.com", "image/svg+xml"} {
+		if strings.Contains(result, removed) {
+			t.Errorf("sanitised SVG contains %q: %s", removed, result)
+		}
+	}
+
+	for _, kept := range []string{`<?xml version="1.0"?>`, `xmlns:xlink="http://www.w3.org/1999/xlink"`, `rect { fill: url(#gradient); }`, `style="fill: red"`, `xlink:href="#r"`, `href="data:image/png;base64,AAAA"`, `<text>link</text>`} {
+		if !strings.Contains(result, kept) {
+			t.Errorf("sanitised SVG does not contain %q: %s", kept, result)
+		}
+	}
+
+	if _, err := newTestValidator().Process([]byte("<svg><rect></svg>")); validationErrorCode(err) != ValidationErrorInvalidImage {
+		t.Errorf("Process of malformed SVG: got %v want %s", err, ValidationErrorInvalidImage)
+	}
+}
+
+func TestSanitiseSVGAllowlist(t *testing.T) {
+	svg := `<svg xmlns="http://www.w3.org/2000/svg" xmlns:xlink="http://www.w3.org/1999/xlink" width="10" height="10">
+	<a><set attributeName="href" to="java&#9;script:alert(1)"/><text>set</text></a>
+	<a><animate attributeName="xlink:href" values="javascript:alert(1)"/><text>animate</text></a>
+	<a xlink:href="java&#9;script:alert(1)"><text>tab</text></a>
+	<a href="&#10;JavaScript:alert(1)"><text>newline</text></a>
+	<rect width="10" height="10" fill="url(http://example.com/fill.svg#f)" custom="value" xlink:title="title">
+		<animate attributeName="opacity" from="0" to="1" dur="1s"/>
+	</rect>
+	<unknown><text>unknown</text></unknown>
+	<html:div xmlns:html="http://www.w3.org/1999/xhtml">div</html:div>
+</svg>`
+
+	got, err := newTestValidator().Process([]byte(svg))
+	if err != nil {
+		t.Fatalf("Process: %v", err)
+	}
+	result := string(got)
+
+	for _, removed := range []string{"alert", "script", "<set", "xlink:href", "example.com", "custom", "xlink:title", "unknown", "div"} {
+		if strings.Contains(result, removed) {
+			t.Errorf("sanitised SVG contains %q: %s", removed, result)
+		}
+	}
+
+	for _, kept := range []string{`<text>set</text>`, `<text>animate</text>`, `<text>tab</text>`, `<text>newline</text>`, `<animate attributeName="opacity" from="0" to="1" dur="1s">`} {
+		if !strings.Contains(result, kept) {
+			t.Errorf("sanitised SVG does not contain %q: %s", kept, result)
+		}
+	}
+}
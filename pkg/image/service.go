@@ -5,14 +5,18 @@ import (
 	"database/sql"
 	"errors"
 	"strings"
+	"time"
 
 	"github.com/gofrs/uuid"
+	"github.com/stashapp/stash-box/pkg/manager/config"
 	"github.com/stashapp/stash-box/pkg/models"
 )
 
 type Service struct {
 	Repository models.ImageRepo
 	Backend    ImageBackend
+	Fetcher    *Fetcher
+	Validator  *Validator
 }
 
 func (s *Service) Create(input models.ImageCreateInput) (*models.Image, error) {
@@ -35,6 +39,7 @@ func (s *Service) Create(input models.ImageCreateInput) (*models.Image, error) {
 	}
 
 	newImage.CopyFromCreateInput(input)
+	newImage.CreatedAt = models.SQLiteTimestamp{Timestamp: time.Now()}
 
 	// set RemoteURL from URL
 	if input.URL != nil {
@@ -44,10 +49,31 @@ func (s *Service) Create(input models.ImageCreateInput) (*models.Image, error) {
 		}
 	}
 
-	// handle image upload
+	var file []byte
 	if input.File != nil {
-		file := make([]byte, input.File.Size)
-		if _, err := input.File.File.Read(file); err != nil {
+		// handle image upload
+		file, err = s.Validator.Read(input.File.File)
+		if err != nil {
+			return nil, err
+		}
+	} else if input.URL != nil {
+		// images are only stored from a URL when downloaded, as the
+		// checksum must be unique. Requests can opt out of downloading,
+		// but not download when the server does not.
+		fetch := config.GetImageFetch() && (input.Fetch == nil || *input.Fetch)
+		if !fetch {
+			return nil, errors.New("Missing URL or file")
+		}
+
+		file, err = s.Fetcher.Fetch(*input.URL)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if file != nil {
+		file, err = s.Validator.Process(file)
+		if err != nil {
 			return nil, err
 		}
 		fileReader := bytes.NewReader(file)
@@ -78,14 +104,19 @@ func (s *Service) Create(input models.ImageCreateInput) (*models.Image, error) {
 			return nil, err
 		}
 
+		if _, err = fileReader.Seek(0, 0); err != nil {
+			return nil, err
+		}
+		if err := populateImagePHash(fileReader, &newImage); err != nil {
+			return nil, err
+		}
+
 		if _, err = fileReader.Seek(0, 0); err != nil {
 			return nil, err
 		}
 		if err := s.Backend.WriteFile(fileReader, &newImage); err != nil {
 			return nil, err
 		}
-	} else if input.URL != nil {
-		return nil, errors.New("Missing URL or file")
 	}
 
 	image, err := s.Repository.Create(newImage)
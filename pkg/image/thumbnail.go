@@ -0,0 +1,160 @@
+package image
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+
+	"github.com/stashapp/stash-box/pkg/manager/config"
+	"github.com/stashapp/stash-box/pkg/utils"
+)
+
+var checksumRegex = regexp.MustCompile(`^[0-9a-f]{32}$`)
+
+// ErrInvalidChecksum is returned when a requested image checksum is malformed.
+var ErrInvalidChecksum = errors.New("invalid image checksum")
+
+// GetThumbnailPath returns the path of the resized copy of the image with
+// the provided checksum, with a maximum dimension of size.
+func GetThumbnailPath(imageDir string, checksum string, size int) string {
+	return GetImagePath(imageDir, checksum) + "-" + strconv.Itoa(size)
+}
+
+// thumbnailSize returns the smallest configured image size of at least the
+// requested size, or zero if there is none.
+func thumbnailSize(size int) int {
+	sizes := append([]int(nil), config.GetImageSizes()...)
+	sort.Ints(sizes)
+
+	for _, s := range sizes {
+		if s >= size {
+			return s
+		}
+	}
+
+	return 0
+}
+
+// GetThumbnail returns the path of the smallest copy of the image with the
+// provided checksum which has a maximum dimension of at least size. Copies
+// that do not exist yet are generated. The original is returned if there is
+// no configured size smaller than the image.
+func GetThumbnail(imageDir string, checksum string, size int) (string, error) {
+	if !checksumRegex.MatchString(checksum) {
+		return "", ErrInvalidChecksum
+	}
+
+	path := GetImagePath(imageDir, checksum)
+	size = thumbnailSize(size)
+	if size == 0 {
+		return path, nil
+	}
+
+	thumbnailPath := GetThumbnailPath(imageDir, checksum, size)
+	if exists, _ := utils.FileExists(thumbnailPath); exists {
+		return thumbnailPath, nil
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer func() { _ = f.Close() }()
+
+	// SVGs and other formats that cannot be decoded are served as is. Only
+	// the header is read, so the original is only read in full to resize it.
+	imageConfig, _, err := image.DecodeConfig(f)
+	if err != nil || !needsResize(imageConfig.Width, imageConfig.Height, size) {
+		return path, nil
+	}
+
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return "", err
+	}
+	src, err := ioutil.ReadAll(f)
+	if err != nil {
+		return "", err
+	}
+
+	if err := writeThumbnail(imageDir, checksum, src, size); err != nil {
+		return "", err
+	}
+
+	return thumbnailPath, nil
+}
+
+func needsResize(width int, height int, size int) bool {
+	return width > size || height > size
+}
+
+// writeThumbnails generates the resized copies of an image for each
+// configured size smaller than the image.
+func writeThumbnails(imageDir string, checksum string, src []byte, width int, height int) error {
+	for _, size := range config.GetImageSizes() {
+		if size <= 0 || !needsResize(width, height, size) {
+			continue
+		}
+
+		if err := writeThumbnail(imageDir, checksum, src, size); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func writeThumbnail(imageDir string, checksum string, src []byte, size int) error {
+	resized, err := resizeImage(bytes.NewReader(src), int64(size))
+	if err != nil {
+		return err
+	}
+
+	// write to a temporary file first, so that concurrent requests never
+	// serve a partially written thumbnail
+	path := GetThumbnailPath(imageDir, checksum, size)
+	tmp, err := ioutil.TempFile(imageDir, filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+
+	_, err = tmp.Write(resized)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Chmod(tmp.Name(), os.FileMode(0644))
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), path)
+	}
+	if err != nil {
+		_ = os.Remove(tmp.Name())
+		return err
+	}
+
+	return nil
+}
+
+// destroyThumbnails removes all resized copies of the image with the
+// provided checksum, including those of sizes no longer configured.
+func destroyThumbnails(imageDir string, checksum string) error {
+	paths, err := filepath.Glob(GetImagePath(imageDir, checksum) + "-*")
+	if err != nil {
+		return err
+	}
+
+	for _, path := range paths {
+		if err := os.Remove(path); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,135 @@
+package image
+
+import (
+	"bytes"
+	"image"
+	"image/png"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/stashapp/stash-box/pkg/manager/config"
+	"github.com/stashapp/stash-box/pkg/utils"
+)
+
+func writeTestImage(t *testing.T, dir string, width int, height int) string {
+	t.Helper()
+
+	buf := new(bytes.Buffer)
+	if err := png.Encode(buf, image.NewRGBA(image.Rect(0, 0, width, height))); err != nil {
+		t.Fatal(err)
+	}
+
+	checksum, err := calculateChecksum(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := ioutil.WriteFile(GetImagePath(dir, checksum), buf.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	return checksum
+}
+
+func TestGetThumbnail(t *testing.T) {
+	dir, err := ioutil.TempDir("", "thumbnails")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		_ = os.RemoveAll(dir)
+	}()
+
+	config.C.ImageSizes = []int{200, 20}
+	defer func() {
+		config.C.ImageSizes = []int{320, 1280}
+	}()
+
+	checksum := writeTestImage(t, dir, 100, 50)
+	original := GetImagePath(dir, checksum)
+
+	path, err := GetThumbnail(dir, checksum, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if path != GetThumbnailPath(dir, checksum, 20) {
+		t.Errorf("GetThumbnail(10): got %s want size 20", path)
+	}
+
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	thumbnail, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if thumbnail.Width != 20 || thumbnail.Height != 10 {
+		t.Errorf("thumbnail dimensions: got %dx%d want 20x10", thumbnail.Width, thumbnail.Height)
+	}
+
+	// images smaller than the size are served as is
+	if path, _ := GetThumbnail(dir, checksum, 150); path != original {
+		t.Errorf("GetThumbnail(150): got %s want original", path)
+	}
+	if path, _ := GetThumbnail(dir, checksum, 500); path != original {
+		t.Errorf("GetThumbnail(500): got %s want original", path)
+	}
+
+	// formats that cannot be decoded are served as is
+	svgChecksum := "0123456789abcdef0123456789abcdef"
+	svgPath := GetImagePath(dir, svgChecksum)
+	if err := ioutil.WriteFile(svgPath, []byte(`<svg xmlns="http://www.w3.org/2000/svg"/>`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if path, err := GetThumbnail(dir, svgChecksum, 10); err != nil || path != svgPath {
+		t.Errorf("GetThumbnail of SVG: got %s, %v want original", path, err)
+	}
+
+	if _, err := GetThumbnail(dir, "../"+checksum, 10); err != ErrInvalidChecksum {
+		t.Errorf("GetThumbnail with invalid checksum: got %v want %v", err, ErrInvalidChecksum)
+	}
+
+	if err := destroyThumbnails(dir, checksum); err != nil {
+		t.Fatal(err)
+	}
+	if exists, _ := utils.FileExists(GetThumbnailPath(dir, checksum, 20)); exists {
+		t.Error("thumbnail was not destroyed")
+	}
+	if exists, _ := utils.FileExists(original); !exists {
+		t.Error("original was destroyed")
+	}
+}
+
+func TestWriteThumbnails(t *testing.T) {
+	dir, err := ioutil.TempDir("", "thumbnails")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		_ = os.RemoveAll(dir)
+	}()
+
+	config.C.ImageSizes = []int{20, 200}
+	defer func() {
+		config.C.ImageSizes = []int{320, 1280}
+	}()
+
+	checksum := writeTestImage(t, dir, 100, 50)
+	src, err := ioutil.ReadFile(GetImagePath(dir, checksum))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := writeThumbnails(dir, checksum, src, 100, 50); err != nil {
+		t.Fatal(err)
+	}
+
+	if exists, _ := utils.FileExists(GetThumbnailPath(dir, checksum, 20)); !exists {
+		t.Error("thumbnail of size 20 was not generated")
+	}
+	if exists, _ := utils.FileExists(GetThumbnailPath(dir, checksum, 200)); exists {
+		t.Error("thumbnail larger than the image was generated")
+	}
+}
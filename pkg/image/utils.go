@@ -9,6 +9,7 @@ import (
 	"image/jpeg"
 	_ "image/png"
 	"io"
+	"net/http"
 	"path/filepath"
 
 	_ "golang.org/x/image/webp"
@@ -77,6 +78,18 @@ func calculateChecksum(file io.Reader) (string, error) {
 	return checksum, nil
 }
 
+// DetectContentType returns the content type of the image data. SVG is not
+// correctly detected by http.DetectContentType, so XML and text content is
+// reported as SVG.
+func DetectContentType(data []byte) string {
+	contentType := http.DetectContentType(data)
+	if contentType == "text/xml; charset=utf-8" || contentType == "text/plain; charset=utf-8" {
+		contentType = "image/svg+xml"
+	}
+
+	return contentType
+}
+
 func GetImagePath(imageDir string, checksum string) string {
 	return filepath.Join(imageDir, checksum)
 }
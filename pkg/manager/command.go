@@ -0,0 +1,84 @@
+package manager
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/spf13/pflag"
+
+	"github.com/stashapp/stash-box/pkg/image"
+	"github.com/stashapp/stash-box/pkg/logger"
+	"github.com/stashapp/stash-box/pkg/manager/config"
+	"github.com/stashapp/stash-box/pkg/models"
+)
+
+const (
+	migrateImagesUsage = "usage: stash-box migrate-images <source backend> <destination backend>"
+	imagePHashesUsage  = "usage: stash-box calculate-image-phashes"
+)
+
+// GetCommand returns the command and its arguments passed on the command
+// line, or nil if stash-box should run the server.
+func GetCommand() []string {
+	return pflag.Args()
+}
+
+// RunCommand runs the provided command line command.
+func RunCommand(fac models.Repo, args []string) error {
+	switch args[0] {
+	case "migrate-images":
+		return migrateImages(fac, args[1:])
+	case "calculate-image-phashes":
+		return calculateImagePHashes(fac, args[1:])
+	default:
+		return fmt.Errorf("unknown command: %s", args[0])
+	}
+}
+
+func migrateImages(fac models.Repo, args []string) error {
+	if len(args) != 2 {
+		return errors.New(migrateImagesUsage)
+	}
+
+	source, err := image.GetBackend(config.ImageBackendType(args[0]))
+	if err != nil {
+		return err
+	}
+	destination, err := image.GetBackend(config.ImageBackendType(args[1]))
+	if err != nil {
+		return err
+	}
+
+	logger.Infof("Migrating images from %s to %s", args[0], args[1])
+	result, err := image.MigrateImages(fac, source, destination)
+	if err != nil {
+		return err
+	}
+
+	logger.Infof("Image migration complete: %d copied, %d skipped, %d failed", result.Migrated, result.Skipped, result.Failed)
+	if result.Failed > 0 {
+		return fmt.Errorf("%d images failed to migrate, run the migration again to retry", result.Failed)
+	}
+
+	return nil
+}
+
+func calculateImagePHashes(fac models.Repo, args []string) error {
+	if len(args) != 0 {
+		return errors.New(imagePHashesUsage)
+	}
+
+	backend, err := image.GetBackend(config.GetImageBackend())
+	if err != nil {
+		return err
+	}
+
+	logger.Infof("Calculating missing image phashes")
+	updated, err := image.CalculateMissingPHashes(fac, backend)
+	if err != nil {
+		return err
+	}
+
+	logger.Infof("Image phash calculation complete: %d updated", updated)
+	return nil
+}
@@ -2,6 +2,7 @@ package config
 
 import (
 	"errors"
+	"strings"
 	"time"
 
 	"github.com/spf13/viper"
@@ -17,6 +18,12 @@ type S3Config struct {
 	AccessKey    string `mapstructure:"access_key"`
 	Secret       string `mapstructure:"secret"`
 	MaxDimension int64  `mapstructure:"max_dimension"`
+	// DisableSSL connects to the endpoint over plain HTTP, for local
+	// S3-compatible stores
+	DisableSSL bool `mapstructure:"disable_ssl"`
+	// SignedRedirects redirects image requests to presigned S3 URLs instead
+	// of streaming the images through the server
+	SignedRedirects bool `mapstructure:"signed_redirects"`
 }
 
 type config struct {
@@ -27,6 +34,30 @@ type config struct {
 	HTTPUpgrade  bool `mapstructure:"http_upgrade"`
 	IsProduction bool `mapstructure:"is_production"`
 
+	// Time in seconds to wait for requests to finish when shutting down
+	ShutdownTimeout int `mapstructure:"shutdown_timeout"`
+
+	// GraphQL query limits. Depth and complexity limits of 0 are disabled
+	MaxQueryDepth        int  `mapstructure:"max_query_depth"`
+	MaxQueryComplexity   int  `mapstructure:"max_query_complexity"`
+	MaxPerPage           int  `mapstructure:"max_per_page"`
+	DisableIntrospection bool `mapstructure:"disable_introspection"`
+
+	// Number of automatic persisted queries cached in memory. 0 to disable
+	PersistedQueryCacheSize int `mapstructure:"persisted_query_cache_size"`
+	// Store automatic persisted queries in the database
+	PersistedQueryStore bool `mapstructure:"persisted_query_store"`
+	// Maximum number of automatic persisted queries stored in the database
+	PersistedQueryStoreSize int `mapstructure:"persisted_query_store_size"`
+	// Time in seconds that responses to GET queries can be cached. 0 to disable
+	QueryCacheMaxAge int `mapstructure:"query_cache_max_age"`
+
+	// Webhook delivery settings. Times are in seconds
+	WebhookDeliveryInterval int `mapstructure:"webhook_delivery_interval"`
+	WebhookTimeout          int `mapstructure:"webhook_timeout"`
+	WebhookRetryDelay       int `mapstructure:"webhook_retry_delay"`
+	WebhookMaxAttempts      int `mapstructure:"webhook_max_attempts"`
+
 	// Key used to sign JWT tokens
 	JWTSignKey string `mapstructure:"jwt_secret_key"`
 	// Key used for session store
@@ -50,6 +81,22 @@ type config struct {
 	// Image storage settings
 	ImageLocation string `mapstructure:"image_location"`
 	ImageBackend  string `mapstructure:"image_backend"`
+	ImageSizes    []int  `mapstructure:"image_sizes"`
+
+	// Image validation settings
+	ImageMaxSize      int64    `mapstructure:"image_max_size"`
+	ImageMaxDimension int      `mapstructure:"image_max_dimension"`
+	ImageFormats      []string `mapstructure:"image_formats"`
+
+	// Orphaned image garbage collection settings
+	ImageGCInterval    int `mapstructure:"image_gc_interval"`
+	ImageGCGracePeriod int `mapstructure:"image_gc_grace_period"`
+
+	// Remote image download settings
+	ImageFetch             bool     `mapstructure:"image_fetch"`
+	ImageFetchMaxSize      int64    `mapstructure:"image_fetch_max_size"`
+	ImageFetchTimeout      int      `mapstructure:"image_fetch_timeout"`
+	ImageFetchContentTypes []string `mapstructure:"image_fetch_content_types"`
 
 	// Logging options
 	LogFile     string `mapstructure:"logFile"`
@@ -62,6 +109,19 @@ type config struct {
 	}
 
 	PHashDistance int `mapstructure:"phash_distance"`
+
+	// Edit voting settings
+	VoteApplicationThreshold int `mapstructure:"vote_application_threshold"`
+	VotingPeriod             int `mapstructure:"voting_period"`
+	EditResolutionInterval   int `mapstructure:"edit_resolution_interval"`
+
+	// API call tracking settings
+	APICallWindow        int            `mapstructure:"api_call_window"`
+	APICallFlushInterval int            `mapstructure:"api_call_flush_interval"`
+	RateLimits           map[string]int `mapstructure:"rate_limits"`
+
+	// Token granting access to the metrics endpoint, in addition to admins
+	MetricsToken string `mapstructure:"metrics_token"`
 }
 
 var JWTSignKey = "jwt_secret_key"
@@ -83,7 +143,41 @@ var C = &config{
 	EmailCooldown:     5 * 60,
 	EmailPort:         25,
 	ImageBackend:      string(FileBackend),
+	ImageSizes:        []int{320, 1280},
 	PHashDistance:     0,
+
+	ImageMaxSize:      10 * 1024 * 1024,
+	ImageMaxDimension: 10000,
+	ImageFormats:      []string{"jpeg", "png", "gif", "webp", "svg"},
+
+	ImageGCInterval:    24 * 60 * 60,
+	ImageGCGracePeriod: 7 * 24 * 60 * 60,
+
+	ImageFetchMaxSize:      10 * 1024 * 1024,
+	ImageFetchTimeout:      30,
+	ImageFetchContentTypes: []string{"image/jpeg", "image/png", "image/gif", "image/webp", "image/svg+xml"},
+
+	VoteApplicationThreshold: 3,
+	VotingPeriod:             4 * 24 * 60 * 60,
+	EditResolutionInterval:   5 * 60,
+
+	APICallWindow:        24 * 60 * 60,
+	APICallFlushInterval: 60,
+
+	ShutdownTimeout: 30,
+
+	MaxQueryDepth:      15,
+	MaxQueryComplexity: 50000,
+	MaxPerPage:         2000,
+
+	PersistedQueryCacheSize: 1000,
+	PersistedQueryStoreSize: 10000,
+	QueryCacheMaxAge:        60,
+
+	WebhookDeliveryInterval: 10,
+	WebhookTimeout:          10,
+	WebhookRetryDelay:       60,
+	WebhookMaxAttempts:      10,
 }
 
 func GetDatabasePath() string {
@@ -114,6 +208,84 @@ func GetIsProduction() bool {
 	return C.IsProduction
 }
 
+// GetShutdownTimeout returns the time to wait for in-flight requests to
+// finish when the server is shut down.
+func GetShutdownTimeout() time.Duration {
+	return time.Duration(C.ShutdownTimeout) * time.Second
+}
+
+// GetMaxQueryDepth returns the maximum nesting of fields in a GraphQL
+// operation, or 0 if unlimited.
+func GetMaxQueryDepth() int {
+	return C.MaxQueryDepth
+}
+
+// GetMaxQueryComplexity returns the maximum complexity score of a GraphQL
+// operation, or 0 if unlimited.
+func GetMaxQueryComplexity() int {
+	return C.MaxQueryComplexity
+}
+
+// GetMaxPerPage returns the maximum number of results which can be requested
+// by the per_page of a query, or the limit of a search.
+func GetMaxPerPage() int {
+	return C.MaxPerPage
+}
+
+// GetIntrospectionEnabled returns true if the GraphQL schema can be
+// introspected. Introspection can only be disabled in production.
+func GetIntrospectionEnabled() bool {
+	return !C.IsProduction || !C.DisableIntrospection
+}
+
+// GetPersistedQueryCacheSize returns the number of automatic persisted
+// queries cached in memory, or 0 if automatic persisted queries are disabled.
+func GetPersistedQueryCacheSize() int {
+	return C.PersistedQueryCacheSize
+}
+
+// GetPersistedQueryStore returns true if automatic persisted queries are
+// stored in the database.
+func GetPersistedQueryStore() bool {
+	return C.PersistedQueryStore
+}
+
+// GetPersistedQueryStoreSize returns the maximum number of automatic
+// persisted queries stored in the database. The least recently used queries
+// are removed once it is exceeded.
+func GetPersistedQueryStoreSize() int {
+	return C.PersistedQueryStoreSize
+}
+
+// GetQueryCacheMaxAge returns the time in seconds that responses to GET
+// queries can be cached, or 0 if caching is disabled.
+func GetQueryCacheMaxAge() int {
+	return C.QueryCacheMaxAge
+}
+
+// GetWebhookDeliveryInterval returns the interval between attempts to send
+// pending webhook deliveries.
+func GetWebhookDeliveryInterval() time.Duration {
+	return time.Duration(C.WebhookDeliveryInterval) * time.Second
+}
+
+// GetWebhookTimeout returns the timeout of webhook requests.
+func GetWebhookTimeout() time.Duration {
+	return time.Duration(C.WebhookTimeout) * time.Second
+}
+
+// GetWebhookRetryDelay returns the delay before the first retry of a failed
+// webhook delivery. The delay doubles with each further attempt.
+func GetWebhookRetryDelay() time.Duration {
+	return time.Duration(C.WebhookRetryDelay) * time.Second
+}
+
+// GetWebhookMaxAttempts returns the number of attempts after which a webhook
+// delivery is abandoned.
+func GetWebhookMaxAttempts() int {
+	return C.WebhookMaxAttempts
+}
+
 // GetRequireInvite returns true if new users cannot register without an invite
 // key.
 func GetRequireInvite() bool {
@@ -189,10 +361,68 @@ func GetImageBackend() ImageBackendType {
 	return ImageBackendType(C.ImageBackend)
 }
 
+// GetImageSizes returns the maximum dimensions of the resized copies of
+// images generated by the file image backend.
+func GetImageSizes() []int {
+	return C.ImageSizes
+}
+
 func GetS3Config() *S3Config {
 	return &C.S3.S3Config
 }
 
+// GetImageMaxSize returns the maximum size - in bytes - of stored images.
+// Zero removes the limit.
+func GetImageMaxSize() int64 {
+	return C.ImageMaxSize
+}
+
+// GetImageMaxDimension returns the maximum width and height of stored
+// images. Zero removes the limit.
+func GetImageMaxDimension() int {
+	return C.ImageMaxDimension
+}
+
+// GetImageFormats returns the formats allowed for stored images.
+func GetImageFormats() []string {
+	return C.ImageFormats
+}
+
+// GetImageGCInterval returns the interval between removals of orphaned
+// images. A value of zero disables the scheduled removal.
+func GetImageGCInterval() time.Duration {
+	return time.Duration(C.ImageGCInterval) * time.Second
+}
+
+// GetImageGCGracePeriod returns the minimum age of orphaned images and
+// files before they are removed.
+func GetImageGCGracePeriod() time.Duration {
+	return time.Duration(C.ImageGCGracePeriod) * time.Second
+}
+
+// GetImageFetch returns true if remote images should be downloaded and
+// stored when created from a URL.
+func GetImageFetch() bool {
+	return C.ImageFetch
+}
+
+// GetImageFetchMaxSize returns the maximum size - in bytes - of a downloaded
+// image.
+func GetImageFetchMaxSize() int64 {
+	return C.ImageFetchMaxSize
+}
+
+// GetImageFetchTimeout returns the timeout of image downloads.
+func GetImageFetchTimeout() time.Duration {
+	return time.Duration(C.ImageFetchTimeout) * time.Second
+}
+
+// GetImageFetchContentTypes returns the content types allowed for downloaded
+// images.
+func GetImageFetchContentTypes() []string {
+	return C.ImageFetchContentTypes
+}
+
 // ValidateImageLocation returns an error is image_location is not set.
 func ValidateImageLocation() error {
 	if C.ImageLocation == "" {
@@ -239,6 +469,50 @@ func GetPHashDistance() int {
 	return C.PHashDistance
 }
 
+// GetVoteApplicationThreshold returns the net vote count at which a pending
+// edit is accepted or rejected before the end of the voting period.
+// A value of zero disables early resolution.
+func GetVoteApplicationThreshold() int {
+	return C.VoteApplicationThreshold
+}
+
+// GetVotingPeriod returns the duration that edits are open for voting.
+func GetVotingPeriod() time.Duration {
+	return time.Duration(C.VotingPeriod * int(time.Second))
+}
+
+// GetEditResolutionInterval returns the interval between checks for edits
+// that can be resolved. A value of zero disables automatic resolution.
+func GetEditResolutionInterval() time.Duration {
+	return time.Duration(C.EditResolutionInterval * int(time.Second))
+}
+
+// GetAPICallWindow returns the rolling time period over which API calls are
+// counted.
+func GetAPICallWindow() time.Duration {
+	return time.Duration(C.APICallWindow * int(time.Second))
+}
+
+// GetAPICallFlushInterval returns the interval at which API call counts are
+// written to the database.
+func GetAPICallFlushInterval() time.Duration {
+	return time.Duration(C.APICallFlushInterval * int(time.Second))
+}
+
+// GetRateLimit returns the number of API calls per minute permitted for the
+// provided role, and whether a limit has been configured for the role.
+func GetRateLimit(role string) (int, bool) {
+	// viper lower-cases map keys
+	limit, found := C.RateLimits[strings.ToLower(role)]
+	return limit, found
+}
+
+// GetMetricsToken returns the bearer token granting access to the metrics
+// endpoint without an admin user, or an empty string if not configured.
+func GetMetricsToken() string {
+	return C.MetricsToken
+}
+
 func InitializeDefaults() error {
 	// generate some api keys
 	const apiKeyLength = 32
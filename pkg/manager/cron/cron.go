@@ -0,0 +1,76 @@
+package cron
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Job is a recurring task run by a Scheduler. The context is cancelled when
+// the scheduler is stopped without waiting for the job to finish.
+type Job func(ctx context.Context)
+
+// Scheduler runs jobs at fixed intervals on background goroutines.
+type Scheduler struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+	stop   chan struct{}
+	wg     sync.WaitGroup
+	once   sync.Once
+}
+
+func NewScheduler() *Scheduler {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Scheduler{
+		ctx:    ctx,
+		cancel: cancel,
+		stop:   make(chan struct{}),
+	}
+}
+
+// Every runs job each time interval elapses, until the scheduler is stopped.
+// Jobs with a non-positive interval are not scheduled.
+func (s *Scheduler) Every(interval time.Duration, job Job) {
+	if interval <= 0 {
+		return
+	}
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				job(s.ctx)
+			case <-s.stop:
+				return
+			}
+		}
+	}()
+}
+
+// Stop prevents any further job runs and waits for running jobs to finish.
+// If the context is done first, the running jobs are cancelled and the
+// context error is returned.
+func (s *Scheduler) Stop(ctx context.Context) error {
+	s.once.Do(func() {
+		close(s.stop)
+	})
+	defer s.cancel()
+
+	done := make(chan struct{})
+	go func() {
+		s.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,42 @@
+package cron
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestStop(t *testing.T) {
+	started := make(chan struct{})
+	cancelled := make(chan struct{})
+
+	s := NewScheduler()
+	s.Every(time.Millisecond, func(ctx context.Context) {
+		select {
+		case started <- struct{}{}:
+		default:
+			return
+		}
+		<-ctx.Done()
+		close(cancelled)
+	})
+	<-started
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := s.Stop(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("stop: got %v want %v", err, context.DeadlineExceeded)
+	}
+
+	select {
+	case <-cancelled:
+	case <-time.After(time.Second):
+		t.Error("running job was not cancelled")
+	}
+
+	// stopping again waits for the cancelled job
+	if err := s.Stop(context.Background()); err != nil {
+		t.Errorf("second stop: got %v want nil", err)
+	}
+}
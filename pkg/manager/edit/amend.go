@@ -0,0 +1,103 @@
+package edit
+
+import (
+	"errors"
+
+	"github.com/gofrs/uuid"
+
+	"github.com/stashapp/stash-box/pkg/models"
+	"github.com/stashapp/stash-box/pkg/utils"
+)
+
+var ErrAmendTarget = errors.New("cannot change the target or operation of an edit")
+
+// FindAmendable returns the pending edit with the given ID, provided that the
+// edit input refers to the same target type, operation and target object.
+func FindAmendable(fac models.Repo, editID uuid.UUID, targetType models.TargetTypeEnum, input *models.EditInput) (*models.Edit, error) {
+	edit, err := fac.Edit().Find(editID)
+	if err != nil {
+		return nil, err
+	}
+	if edit == nil {
+		return nil, errors.New("Edit not found")
+	}
+
+	var status models.VoteStatusEnum
+	utils.ResolveEnumString(edit.Status, &status)
+	if status != models.VoteStatusEnumPending {
+		return nil, errors.New("Invalid vote status: " + edit.Status)
+	}
+
+	if edit.TargetType != targetType.String() || edit.Operation != input.Operation.String() {
+		return nil, ErrAmendTarget
+	}
+
+	targetID, err := findTargetID(fac, edit)
+	if err != nil {
+		return nil, err
+	}
+
+	if targetID == nil {
+		if input.ID != nil {
+			return nil, ErrAmendTarget
+		}
+	} else if input.ID == nil || *input.ID != targetID.String() {
+		return nil, ErrAmendTarget
+	}
+
+	return edit, nil
+}
+
+// findTargetID returns the ID of the object targeted by the edit, or nil for
+// unapplied create edits.
+func findTargetID(fac models.Repo, edit *models.Edit) (*uuid.UUID, error) {
+	if edit.Operation == models.OperationEnumCreate.String() {
+		return nil, nil
+	}
+
+	eqb := fac.Edit()
+	var targetType models.TargetTypeEnum
+	utils.ResolveEnumString(edit.TargetType, &targetType)
+	switch targetType {
+	case models.TargetTypeEnumTag:
+		return eqb.FindTagID(edit.ID)
+	case models.TargetTypeEnumPerformer:
+		return eqb.FindPerformerID(edit.ID)
+	case models.TargetTypeEnumStudio:
+		return eqb.FindStudioID(edit.ID)
+	case models.TargetTypeEnumScene:
+		return eqb.FindSceneID(edit.ID)
+	default:
+		return nil, errors.New("Not implemented: " + edit.TargetType)
+	}
+}
+
+// Amend records the current details of the edit in its amendment history and
+// removes its votes, since they were cast against the previous details. It
+// must be called before the edit details are replaced.
+func (m *mutator) Amend(user *models.User) error {
+	eqb := m.fac.Edit()
+
+	amendmentID, err := uuid.NewV4()
+	if err != nil {
+		return err
+	}
+
+	amendment := models.NewEditAmendment(amendmentID, user, m.edit)
+	if err := eqb.CreateAmendment(*amendment); err != nil {
+		return err
+	}
+
+	return eqb.DeleteVotes(m.edit.ID)
+}
+
+func (m *mutator) UpdateEdit() (*models.Edit, error) {
+	m.edit.Amend()
+	updated, err := m.fac.Edit().Update(*m.edit)
+	if err != nil {
+		return nil, err
+	}
+
+	m.edit = updated
+	return updated, nil
+}
@@ -7,6 +7,7 @@ import (
 
 	"github.com/stashapp/stash-box/pkg/models"
 	"github.com/stashapp/stash-box/pkg/utils"
+	"github.com/stashapp/stash-box/pkg/webhook"
 )
 
 // InputSpecifiedFunc is function that returns true if the qualified field name
@@ -49,7 +50,9 @@ type editApplyer interface {
 	apply() error
 }
 
-func ApplyEdit(fac models.Repo, editID uuid.UUID) (*models.Edit, error) {
+// ApplyEdit applies the changes of a pending or failed edit. Immediate
+// application marks the edit as immediately accepted, bypassing voting.
+func ApplyEdit(fac models.Repo, editID uuid.UUID, immediate bool) (*models.Edit, error) {
 	var updatedEdit *models.Edit
 	err := fac.WithTxn(func() error {
 		eqb := fac.Edit()
@@ -65,9 +68,10 @@ func ApplyEdit(fac models.Repo, editID uuid.UUID) (*models.Edit, error) {
 			return errors.New("Edit already applied")
 		}
 
+		// failed edits can be applied once the cause of the failure is fixed
 		var status models.VoteStatusEnum
 		utils.ResolveEnumString(edit.Status, &status)
-		if status != models.VoteStatusEnumPending {
+		if status != models.VoteStatusEnumPending && status != models.VoteStatusEnumFailed {
 			return errors.New("Invalid vote status: " + edit.Status)
 		}
 
@@ -84,6 +88,8 @@ func ApplyEdit(fac models.Repo, editID uuid.UUID) (*models.Edit, error) {
 			applyer = Performer(fac, edit)
 		case models.TargetTypeEnumStudio:
 			applyer = Studio(fac, edit)
+		case models.TargetTypeEnumScene:
+			applyer = Scene(fac, edit)
 		default:
 			return errors.New("Not implemented: " + edit.TargetType)
 		}
@@ -92,14 +98,18 @@ func ApplyEdit(fac models.Repo, editID uuid.UUID) (*models.Edit, error) {
 			return err
 		}
 
-		edit.ImmediateAccept()
+		if immediate {
+			edit.ImmediateAccept()
+		} else {
+			edit.Accept()
+		}
 		updatedEdit, err = eqb.Update(*edit)
 
 		if err != nil {
 			return err
 		}
 
-		return nil
+		return webhook.Enqueue(fac, models.WebhookEventEnumEditApplied, updatedEdit)
 	})
 
 	if err != nil {
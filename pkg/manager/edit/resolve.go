@@ -0,0 +1,85 @@
+package edit
+
+import (
+	"time"
+
+	"github.com/stashapp/stash-box/pkg/logger"
+	"github.com/stashapp/stash-box/pkg/models"
+	"github.com/stashapp/stash-box/pkg/webhook"
+)
+
+type resolution int
+
+const (
+	resolutionNone resolution = iota
+	resolutionAccept
+	resolutionReject
+	resolutionClose
+)
+
+func resolve(edit *models.Edit, threshold int, votingEnd time.Time) resolution {
+	if threshold > 0 {
+		if edit.VoteCount >= threshold {
+			return resolutionAccept
+		}
+		if edit.VoteCount <= -threshold {
+			return resolutionReject
+		}
+	}
+
+	if !edit.CreatedAt.Timestamp.After(votingEnd) {
+		if edit.VoteCount > 0 {
+			return resolutionAccept
+		}
+		return resolutionClose
+	}
+
+	return resolutionNone
+}
+
+// ResolveEdits applies or rejects pending edits which have reached the vote
+// threshold, and resolves edits whose voting period has expired. Edits with a
+// positive vote count at expiry are applied, all others are closed. Accepted
+// edits which cannot be applied are marked as failed, so that they are not
+// retried.
+func ResolveEdits(fac models.Repo, threshold int, votingPeriod time.Duration) error {
+	votingEnd := time.Now().Add(-votingPeriod)
+
+	edits, err := fac.Edit().FindCompletedEdits(threshold, votingEnd)
+	if err != nil {
+		return err
+	}
+
+	for _, edit := range edits {
+		var err error
+		switch resolve(edit, threshold, votingEnd) {
+		case resolutionAccept:
+			if _, err = ApplyEdit(fac, edit.ID, false); err != nil {
+				logger.Errorf("Error applying edit %s, marking it as failed: %s", edit.ID, err.Error())
+				err = closeEdit(fac, edit, (*models.Edit).Fail)
+			}
+		case resolutionReject:
+			err = closeEdit(fac, edit, (*models.Edit).Reject)
+		case resolutionClose:
+			err = closeEdit(fac, edit, (*models.Edit).Close)
+		}
+
+		if err != nil {
+			logger.Errorf("Error resolving edit %s: %s", edit.ID, err.Error())
+		}
+	}
+
+	return nil
+}
+
+func closeEdit(fac models.Repo, edit *models.Edit, setStatus func(*models.Edit)) error {
+	return fac.WithTxn(func() error {
+		setStatus(edit)
+		updatedEdit, err := fac.Edit().Update(*edit)
+		if err != nil {
+			return err
+		}
+
+		return webhook.Enqueue(fac, models.WebhookEventEnumEditRejected, updatedEdit)
+	})
+}
@@ -0,0 +1,361 @@
+package edit
+
+import (
+	"errors"
+
+	"github.com/gofrs/uuid"
+
+	"github.com/stashapp/stash-box/pkg/models"
+	"github.com/stashapp/stash-box/pkg/utils"
+)
+
+type SceneEditProcessor struct {
+	mutator
+}
+
+func Scene(fac models.Repo, edit *models.Edit) *SceneEditProcessor {
+	return &SceneEditProcessor{
+		mutator{
+			fac:  fac,
+			edit: edit,
+		},
+	}
+}
+
+func (m *SceneEditProcessor) Edit(input models.SceneEditInput, inputSpecified InputSpecifiedFunc) error {
+	var err error
+	switch input.Edit.Operation {
+	case models.OperationEnumModify:
+		err = m.modifyEdit(input, inputSpecified)
+	case models.OperationEnumMerge:
+		err = m.mergeEdit(input, inputSpecified)
+	case models.OperationEnumDestroy:
+		err = m.destroyEdit(input, inputSpecified)
+	case models.OperationEnumCreate:
+		err = m.createEdit(input, inputSpecified)
+	default:
+		panic("not implemented")
+	}
+
+	return err
+}
+
+func (m *SceneEditProcessor) modifyEdit(input models.SceneEditInput, inputSpecified InputSpecifiedFunc) error {
+	sqb := m.fac.Scene()
+
+	// get the existing scene
+	sceneID, _ := uuid.FromString(*input.Edit.ID)
+	scene, err := sqb.Find(sceneID)
+
+	if err != nil {
+		return err
+	}
+
+	if scene == nil {
+		return errors.New("scene with id " + sceneID.String() + " not found")
+	}
+
+	// perform a diff against the input and the current object
+	sceneEdit := input.Details.SceneEditFromDiff(*scene)
+
+	if err := m.diffRelationships(&sceneEdit, sceneID, input); err != nil {
+		return err
+	}
+
+	return m.edit.SetData(sceneEdit)
+}
+
+func (m *SceneEditProcessor) mergeEdit(input models.SceneEditInput, inputSpecified InputSpecifiedFunc) error {
+	sqb := m.fac.Scene()
+
+	// get the existing scene
+	if input.Edit.ID == nil {
+		return errors.New("Merge scene ID is required")
+	}
+	sceneID, _ := uuid.FromString(*input.Edit.ID)
+	scene, err := sqb.Find(sceneID)
+
+	if err != nil {
+		return err
+	}
+
+	if scene == nil {
+		return errors.New("scene with id " + sceneID.String() + " not found")
+	}
+
+	mergeSources := []string{}
+	for _, mergeSourceID := range input.Edit.MergeSourceIds {
+		sourceID, _ := uuid.FromString(mergeSourceID)
+		sourceScene, err := sqb.Find(sourceID)
+		if err != nil {
+			return err
+		}
+
+		if sourceScene == nil {
+			return errors.New("scene with id " + sourceID.String() + " not found")
+		}
+		if sceneID == sourceID {
+			return errors.New("merge target cannot be used as source")
+		}
+		mergeSources = append(mergeSources, mergeSourceID)
+	}
+
+	if len(mergeSources) < 1 {
+		return errors.New("No merge sources found")
+	}
+
+	// perform a diff against the input and the current object
+	sceneEdit := input.Details.SceneEditFromMerge(*scene, mergeSources)
+
+	if err := m.diffRelationships(&sceneEdit, sceneID, input); err != nil {
+		return err
+	}
+
+	return m.edit.SetData(sceneEdit)
+}
+
+func (m *SceneEditProcessor) diffRelationships(sceneEdit *models.SceneEditData, sceneID uuid.UUID, input models.SceneEditInput) error {
+	sqb := m.fac.Scene()
+
+	urls, err := sqb.GetURLs(sceneID)
+	if err != nil {
+		return err
+	}
+	var existingUrls []*models.URL
+	for _, url := range urls {
+		existingURL := url.ToURL()
+		existingUrls = append(existingUrls, &existingURL)
+	}
+	sceneEdit.New.AddedUrls, sceneEdit.New.RemovedUrls = urlCompare(input.Details.Urls, existingUrls)
+
+	fingerprints, err := sqb.GetFingerprints(sceneID)
+	if err != nil {
+		return err
+	}
+	sceneEdit.New.AddedFingerprints, sceneEdit.New.RemovedFingerprints = fingerprintCompare(input.Details.Fingerprints, fingerprints)
+
+	performers, err := sqb.GetPerformers(sceneID)
+	if err != nil {
+		return err
+	}
+	sceneEdit.New.AddedPerformers, sceneEdit.New.RemovedPerformers = performerAppearanceCompare(input.Details.Performers, performers)
+
+	tags, errs := m.fac.Tag().FindIdsBySceneIds([]uuid.UUID{sceneID})
+	if errs != nil && errs[0] != nil {
+		return errs[0]
+	}
+	existingTags := []string{}
+	for _, tagID := range tags[0] {
+		existingTags = append(existingTags, tagID.String())
+	}
+	sceneEdit.New.AddedTags, sceneEdit.New.RemovedTags = utils.StrSliceCompare(input.Details.TagIds, existingTags)
+
+	images, err := m.fac.Image().FindBySceneID(sceneID)
+	if err != nil {
+		return err
+	}
+	existingImages := []string{}
+	for _, image := range images {
+		existingImages = append(existingImages, image.ID.String())
+	}
+	sceneEdit.New.AddedImages, sceneEdit.New.RemovedImages = utils.StrSliceCompare(input.Details.ImageIds, existingImages)
+
+	return nil
+}
+
+func (m *SceneEditProcessor) createEdit(input models.SceneEditInput, inputSpecified InputSpecifiedFunc) error {
+	sceneEdit := input.Details.SceneEditFromCreate()
+
+	if len(input.Details.Urls) != 0 || inputSpecified("urls") {
+		sceneEdit.New.AddedUrls = input.Details.Urls
+	}
+
+	if len(input.Details.Fingerprints) != 0 || inputSpecified("fingerprints") {
+		sceneEdit.New.AddedFingerprints, _ = fingerprintCompare(input.Details.Fingerprints, nil)
+	}
+
+	if len(input.Details.Performers) != 0 || inputSpecified("performers") {
+		sceneEdit.New.AddedPerformers = input.Details.Performers
+	}
+
+	if len(input.Details.TagIds) != 0 || inputSpecified("tag_ids") {
+		sceneEdit.New.AddedTags = input.Details.TagIds
+	}
+
+	if len(input.Details.ImageIds) != 0 || inputSpecified("image_ids") {
+		sceneEdit.New.AddedImages = input.Details.ImageIds
+	}
+
+	return m.edit.SetData(sceneEdit)
+}
+
+func (m *SceneEditProcessor) destroyEdit(input models.SceneEditInput, inputSpecified InputSpecifiedFunc) error {
+	sqb := m.fac.Scene()
+
+	// get the existing scene
+	sceneID, _ := uuid.FromString(*input.Edit.ID)
+	scene, err := sqb.Find(sceneID)
+
+	if err != nil {
+		return err
+	}
+
+	if scene == nil {
+		return errors.New("scene with id " + sceneID.String() + " not found")
+	}
+
+	return nil
+}
+
+func (m *SceneEditProcessor) CreateJoin(input models.SceneEditInput) error {
+	if input.Edit.ID != nil {
+		sceneID, _ := uuid.FromString(*input.Edit.ID)
+
+		editScene := models.EditScene{
+			EditID:  m.edit.ID,
+			SceneID: sceneID,
+		}
+
+		return m.fac.Edit().CreateEditScene(editScene)
+	}
+
+	return nil
+}
+
+func (m *SceneEditProcessor) apply() error {
+	sqb := m.fac.Scene()
+	eqb := m.fac.Edit()
+	operation := m.operation()
+	isCreate := operation == models.OperationEnumCreate
+
+	var scene *models.Scene = nil
+	if !isCreate {
+		sceneID, err := eqb.FindSceneID(m.edit.ID)
+		if err != nil {
+			return err
+		}
+		scene, err = sqb.Find(*sceneID)
+		if err != nil {
+			return err
+		}
+		if scene == nil {
+			return errors.New("Scene not found: " + sceneID.String())
+		}
+	}
+	newScene, err := sqb.ApplyEdit(*m.edit, operation, scene)
+	if err != nil {
+		return err
+	}
+
+	if isCreate {
+		editScene := models.EditScene{
+			EditID:  m.edit.ID,
+			SceneID: newScene.ID,
+		}
+
+		err = eqb.CreateEditScene(editScene)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func fingerprintCompare(subject []*models.FingerprintEditInput, against []*models.Fingerprint) (added []*models.Fingerprint, missing []*models.Fingerprint) {
+	for _, s := range subject {
+		newFingerprint := true
+		for _, a := range against {
+			if s.Hash == a.Hash && s.Algorithm == a.Algorithm {
+				newFingerprint = false
+			}
+		}
+
+		for _, a := range added {
+			if s.Hash == a.Hash && s.Algorithm == a.Algorithm {
+				newFingerprint = false
+			}
+		}
+
+		if newFingerprint {
+			added = append(added, &models.Fingerprint{
+				Hash:        s.Hash,
+				Algorithm:   s.Algorithm,
+				Duration:    s.Duration,
+				Submissions: s.Submissions,
+				Created:     s.Created,
+				Updated:     s.Updated,
+			})
+		}
+	}
+
+	for _, s := range against {
+		removedFingerprint := true
+		for _, a := range subject {
+			if s.Hash == a.Hash && s.Algorithm == a.Algorithm {
+				removedFingerprint = false
+			}
+		}
+
+		for _, a := range missing {
+			if s.Hash == a.Hash && s.Algorithm == a.Algorithm {
+				removedFingerprint = false
+			}
+		}
+
+		if removedFingerprint {
+			missing = append(missing, s)
+		}
+	}
+	return
+}
+
+func performerAppearanceCompare(subject []*models.PerformerAppearanceInput, against models.PerformersScenes) (added []*models.PerformerAppearanceInput, missing []*models.PerformerAppearanceInput) {
+	for _, s := range subject {
+		newAppearance := true
+		for _, a := range against {
+			if s.PerformerID == a.PerformerID.String() {
+				newAppearance = (s.As != nil && (!a.As.Valid || *s.As != a.As.String)) ||
+					(s.As == nil && a.As.Valid)
+			}
+		}
+
+		for _, a := range added {
+			if s.PerformerID == a.PerformerID {
+				newAppearance = false
+			}
+		}
+
+		if newAppearance {
+			added = append(added, s)
+		}
+	}
+
+	for _, s := range against {
+		removedAppearance := true
+		for _, a := range subject {
+			if s.PerformerID.String() == a.PerformerID {
+				removedAppearance = (a.As != nil && (!s.As.Valid || *a.As != s.As.String)) ||
+					(a.As == nil && s.As.Valid)
+			}
+		}
+
+		for _, a := range missing {
+			if s.PerformerID.String() == a.PerformerID {
+				removedAppearance = false
+			}
+		}
+
+		if removedAppearance {
+			appearance := &models.PerformerAppearanceInput{
+				PerformerID: s.PerformerID.String(),
+			}
+			if s.As.Valid {
+				as := s.As.String
+				appearance.As = &as
+			}
+			missing = append(missing, appearance)
+		}
+	}
+	return
+}
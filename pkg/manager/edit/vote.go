@@ -0,0 +1,85 @@
+package edit
+
+import (
+	"errors"
+
+	"github.com/gofrs/uuid"
+
+	"github.com/stashapp/stash-box/pkg/models"
+	"github.com/stashapp/stash-box/pkg/utils"
+	"github.com/stashapp/stash-box/pkg/webhook"
+)
+
+var ErrOwnEditVote = errors.New("cannot vote on own edit")
+
+// CastVote records the user's vote on a pending edit and updates the vote
+// count of the edit. Immediate votes apply or reject the edit directly, so
+// the caller is responsible for ensuring the user is permitted to cast them.
+func CastVote(fac models.Repo, user *models.User, editID uuid.UUID, voteType models.VoteTypeEnum, comment *string) (*models.Edit, error) {
+	var updatedEdit *models.Edit
+	err := fac.WithTxn(func() error {
+		eqb := fac.Edit()
+		edit, err := eqb.Find(editID)
+		if err != nil {
+			return err
+		}
+		if edit == nil {
+			return errors.New("Edit not found")
+		}
+
+		var status models.VoteStatusEnum
+		utils.ResolveEnumString(edit.Status, &status)
+		if status != models.VoteStatusEnumPending {
+			return errors.New("Invalid vote status: " + edit.Status)
+		}
+
+		if edit.UserID == user.ID && (voteType == models.VoteTypeEnumAccept || voteType == models.VoteTypeEnumReject) {
+			return ErrOwnEditVote
+		}
+
+		vote := models.NewVoteComment(user, edit, voteType, comment)
+		if err := eqb.CreateVote(*vote); err != nil {
+			return err
+		}
+
+		votes, err := eqb.GetVotes(edit.ID)
+		if err != nil {
+			return err
+		}
+		edit.VoteCount = votes.VoteCount()
+
+		switch voteType {
+		case models.VoteTypeEnumImmediateAccept:
+			if _, err := eqb.Update(*edit); err != nil {
+				return err
+			}
+			if err := webhook.Enqueue(fac, models.WebhookEventEnumEditVoted, edit); err != nil {
+				return err
+			}
+			updatedEdit, err = ApplyEdit(fac, edit.ID, true)
+			return err
+		case models.VoteTypeEnumImmediateReject:
+			edit.ImmediateReject()
+		}
+
+		updatedEdit, err = eqb.Update(*edit)
+		if err != nil {
+			return err
+		}
+
+		if err := webhook.Enqueue(fac, models.WebhookEventEnumEditVoted, updatedEdit); err != nil {
+			return err
+		}
+		if voteType == models.VoteTypeEnumImmediateReject {
+			return webhook.Enqueue(fac, models.WebhookEventEnumEditRejected, updatedEdit)
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return updatedEdit, nil
+}
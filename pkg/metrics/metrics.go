@@ -0,0 +1,82 @@
+// Package metrics defines the Prometheus metrics collected by the server.
+package metrics
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+const namespace = "stashbox"
+
+var (
+	// OperationDuration observes the duration of each query and mutation,
+	// labelled by the operation type and the name of the root field.
+	OperationDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "graphql",
+		Name:      "operation_duration_seconds",
+		Help:      "Duration of GraphQL queries and mutations.",
+	}, []string{"type", "operation"})
+
+	// OperationErrors counts the queries and mutations returning an error.
+	OperationErrors = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "graphql",
+		Name:      "operation_errors_total",
+		Help:      "Number of GraphQL queries and mutations returning an error.",
+	}, []string{"type", "operation"})
+
+	// TransactionDuration observes the duration of database transactions.
+	TransactionDuration = promauto.NewHistogram(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "db",
+		Name:      "transaction_duration_seconds",
+		Help:      "Duration of database transactions.",
+	})
+
+	// FingerprintLookupDuration observes the duration of scene lookups by
+	// fingerprint, labelled by the lookup method.
+	FingerprintLookupDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "fingerprint",
+		Name:      "lookup_duration_seconds",
+		Help:      "Duration of scene lookups by fingerprint.",
+	}, []string{"method"})
+
+	// FingerprintLookups counts the fingerprints looked up, labelled by the
+	// lookup method.
+	FingerprintLookups = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "fingerprint",
+		Name:      "lookups_total",
+		Help:      "Number of fingerprints looked up.",
+	}, []string{"method"})
+
+	// ImageBytesServed counts the bytes of image files served.
+	ImageBytesServed = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "image",
+		Name:      "served_bytes_total",
+		Help:      "Number of bytes of image files served.",
+	})
+)
+
+// ObserveFingerprintLookup counts the fingerprints of a lookup and returns a
+// function observing the duration of the lookup when called.
+func ObserveFingerprintLookup(method string, fingerprints int) func() {
+	FingerprintLookups.WithLabelValues(method).Add(float64(fingerprints))
+
+	start := time.Now()
+	return func() {
+		FingerprintLookupDuration.WithLabelValues(method).Observe(time.Since(start).Seconds())
+	}
+}
+
+// RegisterDatabase registers the connection pool statistics of the database.
+func RegisterDatabase(db *sql.DB) {
+	prometheus.MustRegister(collectors.NewDBStatsCollector(db, namespace))
+}
@@ -0,0 +1,21 @@
+package metrics
+
+import (
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestObserveFingerprintLookup(t *testing.T) {
+	const method = "test"
+
+	ObserveFingerprintLookup(method, 3)()
+	ObserveFingerprintLookup(method, 2)()
+
+	if got := testutil.ToFloat64(FingerprintLookups.WithLabelValues(method)); got != 5 {
+		t.Errorf("fingerprints looked up: got %v want 5", got)
+	}
+	if got := testutil.CollectAndCount(FingerprintLookupDuration); got != 1 {
+		t.Errorf("lookup duration series: got %d want 1", got)
+	}
+}
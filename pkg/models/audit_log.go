@@ -0,0 +1,11 @@
+package models
+
+type AuditLogCreator interface {
+	Create(newEntry AuditLog) (*AuditLog, error)
+}
+
+type AuditLogRepo interface {
+	AuditLogCreator
+
+	Query(auditFilter *AuditLogFilterType, findFilter *QuerySpec) ([]*AuditLog, int, error)
+}
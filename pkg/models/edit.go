@@ -1,6 +1,10 @@
 package models
 
-import "github.com/gofrs/uuid"
+import (
+	"time"
+
+	"github.com/gofrs/uuid"
+)
 
 type EditRepo interface {
 	Create(newEdit Edit) (*Edit, error)
@@ -10,14 +14,24 @@ type EditRepo interface {
 	CreateEditTag(newJoin EditTag) error
 	CreateEditPerformer(newJoin EditPerformer) error
 	CreateEditStudio(newJoin EditStudio) error
+	CreateEditScene(newJoin EditScene) error
 	FindTagID(id uuid.UUID) (*uuid.UUID, error)
 	FindPerformerID(id uuid.UUID) (*uuid.UUID, error)
 	FindStudioID(id uuid.UUID) (*uuid.UUID, error)
+	FindSceneID(id uuid.UUID) (*uuid.UUID, error)
 	Count() (int, error)
+	CountByStatus() (map[VoteStatusEnum]int, error)
 	Query(editFilter *EditFilterType, findFilter *QuerySpec) ([]*Edit, int)
 	CreateComment(newJoin EditComment) error
 	GetComments(id uuid.UUID) (EditComments, error)
+	CreateVote(newJoin VoteComment) error
+	GetVotes(id uuid.UUID) (VoteComments, error)
+	DeleteVotes(id uuid.UUID) error
+	CreateAmendment(newJoin EditAmendment) error
+	GetAmendments(id uuid.UUID) (EditAmendments, error)
 	FindByTagID(id uuid.UUID) ([]*Edit, error)
 	FindByPerformerID(id uuid.UUID) ([]*Edit, error)
 	FindByStudioID(id uuid.UUID) ([]*Edit, error)
+	FindBySceneID(id uuid.UUID) ([]*Edit, error)
+	FindCompletedEdits(threshold int, votingEnd time.Time) ([]*Edit, error)
 }
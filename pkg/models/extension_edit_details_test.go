@@ -319,3 +319,75 @@ func TestPerformerEditFromDiff(t *testing.T) {
 		Old: &PerformerEdit{},
 	}, out)
 }
+
+func TestSceneEditFromDiff(t *testing.T) {
+	aTitle := "aTitle"
+	bTitle := "bTitle"
+	aDuration := 100
+	bDuration := 200
+	aDuration64 := int64(aDuration)
+	bDuration64 := int64(bDuration)
+
+	orig := Scene{
+		Title:    sql.NullString{String: aTitle, Valid: true},
+		Details:  sql.NullString{String: aDescription, Valid: true},
+		Date:     SQLiteDate{String: aDate, Valid: true},
+		StudioID: uuid.NullUUID{UUID: aCategoryID, Valid: true},
+		Duration: sql.NullInt64{Int64: aDuration64, Valid: true},
+	}
+	input := SceneEditDetailsInput{
+		Title:    &bTitle,
+		Details:  &bDescription,
+		Date:     &bDate,
+		StudioID: &bCategoryIDStr,
+		Duration: &bDuration,
+	}
+
+	out := input.SceneEditFromDiff(orig)
+
+	assert := assert.New(t)
+	assert.Equal(SceneEditData{
+		New: &SceneEdit{
+			Title:    &bTitle,
+			Details:  &bDescription,
+			Date:     &bDate,
+			StudioID: &bCategoryIDStr,
+			Duration: &bDuration64,
+		},
+		Old: &SceneEdit{
+			Title:    &aTitle,
+			Details:  &aDescription,
+			Date:     &aDate,
+			StudioID: &aCategoryIDStr,
+			Duration: &aDuration64,
+		},
+	}, out)
+
+	emptyInput := SceneEditDetailsInput{}
+
+	out = emptyInput.SceneEditFromDiff(orig)
+	assert.Equal(SceneEditData{
+		New: &SceneEdit{},
+		Old: &SceneEdit{
+			Title:    &aTitle,
+			Details:  &aDescription,
+			Date:     &aDate,
+			StudioID: &aCategoryIDStr,
+			Duration: &aDuration64,
+		},
+	}, out)
+
+	equalInput := SceneEditDetailsInput{
+		Title:    &aTitle,
+		Details:  &aDescription,
+		Date:     &aDate,
+		StudioID: &aCategoryIDStr,
+		Duration: &aDuration,
+	}
+
+	out = equalInput.SceneEditFromDiff(orig)
+	assert.Equal(SceneEditData{
+		New: &SceneEdit{},
+		Old: &SceneEdit{},
+	}, out)
+}
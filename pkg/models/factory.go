@@ -21,4 +21,8 @@ type Repo interface {
 	PendingActivation() PendingActivationRepo
 	Invite() InviteKeyRepo
 	User() UserRepo
+
+	AuditLog() AuditLogRepo
+	PersistedQuery() PersistedQueryRepo
+	Webhook() WebhookRepo
 }
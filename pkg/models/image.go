@@ -1,11 +1,14 @@
 package models
 
 import (
+	"time"
+
 	"github.com/gofrs/uuid"
 )
 
 type ImageRepo interface {
 	ImageCreator
+	ImageUpdater
 	ImageDestroyer
 	ImageFinder
 
@@ -16,12 +19,22 @@ type ImageRepo interface {
 	FindByPerformerID(performerID uuid.UUID) (Images, error)
 	FindByStudioID(studioID uuid.UUID) ([]*Image, error)
 	FindIdsByStudioIds(ids []uuid.UUID) ([][]uuid.UUID, []error)
+	FindWithChecksum(after uuid.UUID, limit int) ([]*Image, error)
+	CountWithChecksum() (int, error)
+	FindDuplicatePHashes(distance int) ([]*ImageDuplicate, error)
+	FindOrphans(createdBefore time.Time, editsUpdatedAfter time.Time, after uuid.UUID, limit int) ([]*Image, error)
+	MergeInto(sourceIDs []uuid.UUID, targetID uuid.UUID) error
+	IsInPendingEdit(imageID uuid.UUID) (bool, error)
 }
 
 type ImageCreator interface {
 	Create(newImage Image) (*Image, error)
 }
 
+type ImageUpdater interface {
+	Update(updatedImage Image) (*Image, error)
+}
+
 type ImageFinder interface {
 	Find(id uuid.UUID) (*Image, error)
 	FindByChecksum(checksum string) (*Image, error)
@@ -0,0 +1,70 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/gofrs/uuid"
+	"github.com/jmoiron/sqlx/types"
+)
+
+// AuditLog records a privileged action performed by a user.
+type AuditLog struct {
+	ID         uuid.UUID       `db:"id" json:"id"`
+	UserID     uuid.NullUUID   `db:"user_id" json:"user_id"`
+	UserName   string          `db:"user_name" json:"user_name"`
+	Action     string          `db:"action" json:"action"`
+	TargetType string          `db:"target_type" json:"target_type"`
+	TargetID   uuid.UUID       `db:"target_id" json:"target_id"`
+	OldData    types.JSONText  `db:"old_data" json:"old_data"`
+	NewData    types.JSONText  `db:"new_data" json:"new_data"`
+	CreatedAt  SQLiteTimestamp `db:"created_at" json:"created_at"`
+}
+
+func (p AuditLog) GetID() uuid.UUID {
+	return p.ID
+}
+
+type AuditLogs []*AuditLog
+
+func (p AuditLogs) Each(fn func(interface{})) {
+	for _, v := range p {
+		fn(v)
+	}
+}
+
+func (p *AuditLogs) Add(o interface{}) {
+	*p = append(*p, o.(*AuditLog))
+}
+
+// NewAuditLog returns a new audit log entry of the action by the user. The
+// old and new data are stored as JSON, and are omitted if nil.
+func NewAuditLog(UUID uuid.UUID, user *User, action AuditActionEnum, targetType AuditTargetTypeEnum, targetID uuid.UUID, oldData interface{}, newData interface{}) (*AuditLog, error) {
+	ret := &AuditLog{
+		ID:         UUID,
+		UserID:     uuid.NullUUID{UUID: user.ID, Valid: true},
+		UserName:   user.Name,
+		Action:     action.String(),
+		TargetType: targetType.String(),
+		TargetID:   targetID,
+		CreatedAt:  SQLiteTimestamp{Timestamp: time.Now()},
+	}
+
+	var err error
+	if ret.OldData, err = marshalAuditData(oldData); err != nil {
+		return nil, err
+	}
+	if ret.NewData, err = marshalAuditData(newData); err != nil {
+		return nil, err
+	}
+
+	return ret, nil
+}
+
+func marshalAuditData(data interface{}) (types.JSONText, error) {
+	if data == nil {
+		return nil, nil
+	}
+
+	return json.Marshal(data)
+}
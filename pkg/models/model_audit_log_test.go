@@ -0,0 +1,50 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/gofrs/uuid"
+)
+
+func TestNewAuditLog(t *testing.T) {
+	user := &User{
+		ID:   uuid.Must(uuid.NewV4()),
+		Name: "admin",
+	}
+	targetID := uuid.Must(uuid.NewV4())
+
+	oldData := map[string]int{"invite_tokens": 1}
+	entry, err := NewAuditLog(uuid.Must(uuid.NewV4()), user, AuditActionEnumInviteGrant, AuditTargetTypeEnumUser, targetID, oldData, nil)
+	if err != nil {
+		t.Fatalf("NewAuditLog: %s", err.Error())
+	}
+
+	if !entry.UserID.Valid || entry.UserID.UUID != user.ID {
+		t.Errorf("UserID: got %v want %s", entry.UserID, user.ID)
+	}
+	if entry.UserName != user.Name {
+		t.Errorf("UserName: got %s want %s", entry.UserName, user.Name)
+	}
+	if entry.Action != "INVITE_GRANT" {
+		t.Errorf("Action: got %s want INVITE_GRANT", entry.Action)
+	}
+	if entry.TargetType != "USER" {
+		t.Errorf("TargetType: got %s want USER", entry.TargetType)
+	}
+	if entry.TargetID != targetID {
+		t.Errorf("TargetID: got %s want %s", entry.TargetID, targetID)
+	}
+	if string(entry.OldData) != `{"invite_tokens":1}` {
+		t.Errorf("OldData: got %s want %s", entry.OldData, `{"invite_tokens":1}`)
+	}
+	if entry.NewData != nil {
+		t.Errorf("NewData: got %s want nil", entry.NewData)
+	}
+}
+
+func TestNewAuditLogInvalidData(t *testing.T) {
+	user := &User{ID: uuid.Must(uuid.NewV4())}
+	if _, err := NewAuditLog(uuid.Must(uuid.NewV4()), user, AuditActionEnumPerformerDestroy, AuditTargetTypeEnumPerformer, uuid.Nil, make(chan int), nil); err == nil {
+		t.Error("expected error marshalling invalid data")
+	}
+}
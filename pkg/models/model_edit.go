@@ -2,6 +2,7 @@ package models
 
 import (
 	"bytes"
+	"database/sql"
 	"encoding/json"
 	"time"
 
@@ -30,6 +31,16 @@ type EditComment struct {
 	Text      string          `db:"text" json:"text"`
 }
 
+// EditAmendment records the state of an edit before it was amended.
+type EditAmendment struct {
+	ID        uuid.UUID       `db:"id" json:"id"`
+	EditID    uuid.UUID       `db:"edit_id" json:"edit_id"`
+	UserID    uuid.UUID       `db:"user_id" json:"user_id"`
+	CreatedAt SQLiteTimestamp `db:"created_at" json:"created_at"`
+	Data      types.JSONText  `db:"data" json:"data"`
+	VoteCount int             `db:"votes" json:"votes"`
+}
+
 func NewEdit(UUID uuid.UUID, user *User, targetType TargetTypeEnum, input *EditInput) *Edit {
 	currentTime := time.Now()
 
@@ -60,6 +71,32 @@ func NewEditComment(UUID uuid.UUID, user *User, edit *Edit, text string) *EditCo
 	return ret
 }
 
+func NewEditAmendment(UUID uuid.UUID, user *User, edit *Edit) *EditAmendment {
+	return &EditAmendment{
+		ID:        UUID,
+		EditID:    edit.ID,
+		UserID:    user.ID,
+		CreatedAt: SQLiteTimestamp{Timestamp: time.Now()},
+		Data:      edit.Data,
+		VoteCount: edit.VoteCount,
+	}
+}
+
+func NewVoteComment(user *User, edit *Edit, voteType VoteTypeEnum, comment *string) *VoteComment {
+	ret := &VoteComment{
+		EditID: edit.ID,
+		UserID: user.ID,
+		Date:   SQLiteTimestamp{Timestamp: time.Now()},
+		Type:   voteType.String(),
+	}
+
+	if comment != nil && len(*comment) > 0 {
+		ret.Comment = sql.NullString{String: *comment, Valid: true}
+	}
+
+	return ret
+}
+
 func (e Edit) GetID() uuid.UUID {
 	return e.ID
 }
@@ -75,6 +112,36 @@ func (e *Edit) ImmediateReject() {
 	e.UpdatedAt = SQLiteTimestamp{Timestamp: time.Now()}
 }
 
+func (e *Edit) Accept() {
+	e.Status = VoteStatusEnumAccepted.String()
+	e.Applied = true
+	e.UpdatedAt = SQLiteTimestamp{Timestamp: time.Now()}
+}
+
+func (e *Edit) Reject() {
+	e.Status = VoteStatusEnumRejected.String()
+	e.UpdatedAt = SQLiteTimestamp{Timestamp: time.Now()}
+}
+
+// Close rejects an edit which did not receive a positive vote count within
+// the voting period.
+func (e *Edit) Close() {
+	e.Status = VoteStatusEnumClosed.String()
+	e.UpdatedAt = SQLiteTimestamp{Timestamp: time.Now()}
+}
+
+// Fail marks an edit which was accepted by vote, but could not be applied.
+func (e *Edit) Fail() {
+	e.Status = VoteStatusEnumFailed.String()
+	e.UpdatedAt = SQLiteTimestamp{Timestamp: time.Now()}
+}
+
+// Amend resets the vote count of an edit whose details have been revised.
+func (e *Edit) Amend() {
+	e.VoteCount = 0
+	e.UpdatedAt = SQLiteTimestamp{Timestamp: time.Now()}
+}
+
 func (e *Edit) SetData(data interface{}) error {
 	buffer := &bytes.Buffer{}
 	encoder := json.NewEncoder(buffer)
@@ -114,6 +181,12 @@ func (e *Edit) GetStudioData() (*StudioEditData, error) {
 	return &data, nil
 }
 
+func (e *Edit) GetSceneData() (*SceneEditData, error) {
+	data := SceneEditData{}
+	_ = json.Unmarshal(e.Data, &data)
+	return &data, nil
+}
+
 type Edits []*Edit
 
 func (p Edits) Each(fn func(interface{})) {
@@ -194,14 +267,57 @@ func (p *EditStudios) Add(o interface{}) {
 	*p = append(*p, o.(*EditStudio))
 }
 
-// type VoteComment struct {
-// 	ID      uuid.UUID      `db:"id" json:"id"`
-// 	EditID  uuid.UUID      `db:"edit_id" json:"edit_id"`
-// 	UserID  uuid.UUID      `db:"user_id" json:"user_id"`
-// 	Date    SQLiteDate     `db:"date" json:"date"`
-// 	Comment sql.NullString `db:"comment" json:"comment"`
-// 	Type    string         `db:"type" json:"type"`
-// }
+type EditScene struct {
+	EditID  uuid.UUID `db:"edit_id" json:"edit_id"`
+	SceneID uuid.UUID `db:"scene_id" json:"scene_id"`
+}
+
+type EditScenes []*EditScene
+
+func (p EditScenes) Each(fn func(interface{})) {
+	for _, v := range p {
+		fn(*v)
+	}
+}
+
+func (p *EditScenes) Add(o interface{}) {
+	*p = append(*p, o.(*EditScene))
+}
+
+type VoteComment struct {
+	EditID  uuid.UUID       `db:"edit_id" json:"edit_id"`
+	UserID  uuid.UUID       `db:"user_id" json:"user_id"`
+	Date    SQLiteTimestamp `db:"date" json:"date"`
+	Comment sql.NullString  `db:"comment" json:"comment"`
+	Type    string          `db:"type" json:"type"`
+}
+
+type VoteComments []*VoteComment
+
+func (p VoteComments) Each(fn func(interface{})) {
+	for _, v := range p {
+		fn(*v)
+	}
+}
+
+func (p *VoteComments) Add(o interface{}) {
+	*p = append(*p, o.(*VoteComment))
+}
+
+// VoteCount returns the number of accept votes minus the number of reject
+// votes.
+func (p VoteComments) VoteCount() int {
+	count := 0
+	for _, v := range p {
+		if v.Type == VoteTypeEnumAccept.String() {
+			count++
+		} else if v.Type == VoteTypeEnumReject.String() {
+			count--
+		}
+	}
+
+	return count
+}
 
 // func (p *Scene) CopyFromCreateInput(input SceneCreateInput) {
 // 	CopyFull(p, input)
@@ -293,6 +409,33 @@ type StudioEditData struct {
 	MergeSources []string    `json:"merge_sources,omitempty"`
 }
 
+type SceneEdit struct {
+	Title               *string                     `json:"title,omitempty"`
+	Details             *string                     `json:"details,omitempty"`
+	AddedUrls           []*URL                      `json:"added_urls,omitempty"`
+	RemovedUrls         []*URL                      `json:"removed_urls,omitempty"`
+	Date                *string                     `json:"date,omitempty"`
+	StudioID            *string                     `json:"studio_id,omitempty"`
+	AddedPerformers     []*PerformerAppearanceInput `json:"added_performers,omitempty"`
+	RemovedPerformers   []*PerformerAppearanceInput `json:"removed_performers,omitempty"`
+	AddedTags           []string                    `json:"added_tags,omitempty"`
+	RemovedTags         []string                    `json:"removed_tags,omitempty"`
+	AddedImages         []string                    `json:"added_images,omitempty"`
+	RemovedImages       []string                    `json:"removed_images,omitempty"`
+	AddedFingerprints   []*Fingerprint              `json:"added_fingerprints,omitempty"`
+	RemovedFingerprints []*Fingerprint              `json:"removed_fingerprints,omitempty"`
+	Duration            *int64                      `json:"duration,omitempty"`
+	Director            *string                     `json:"director,omitempty"`
+}
+
+func (SceneEdit) IsEditDetails() {}
+
+type SceneEditData struct {
+	New          *SceneEdit `json:"new_data,omitempty"`
+	Old          *SceneEdit `json:"old_data,omitempty"`
+	MergeSources []string   `json:"merge_sources,omitempty"`
+}
+
 type EditData struct {
 	New          *json.RawMessage `json:"new_data,omitempty"`
 	Old          *json.RawMessage `json:"old_data,omitempty"`
@@ -310,3 +453,15 @@ func (p EditComments) Each(fn func(interface{})) {
 func (p *EditComments) Add(o interface{}) {
 	*p = append(*p, o.(*EditComment))
 }
+
+type EditAmendments []*EditAmendment
+
+func (p EditAmendments) Each(fn func(interface{})) {
+	for _, v := range p {
+		fn(*v)
+	}
+}
+
+func (p *EditAmendments) Add(o interface{}) {
+	*p = append(*p, o.(*EditAmendment))
+}
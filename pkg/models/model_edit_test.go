@@ -0,0 +1,20 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVoteCount(t *testing.T) {
+	votes := VoteComments{
+		{Type: VoteTypeEnumAccept.String()},
+		{Type: VoteTypeEnumAccept.String()},
+		{Type: VoteTypeEnumReject.String()},
+		{Type: VoteTypeEnumComment.String()},
+		{Type: VoteTypeEnumImmediateAccept.String()},
+	}
+
+	assert.Equal(t, 1, votes.VoteCount())
+	assert.Equal(t, 0, VoteComments{}.VoteCount())
+}
@@ -8,11 +8,20 @@ import (
 )
 
 type Image struct {
-	ID        uuid.UUID      `db:"id" json:"id"`
-	RemoteURL sql.NullString `db:"url" json:"url"`
-	Checksum  string         `db:"checksum" json:"checksum"`
-	Width     int64          `db:"width" json:"width"`
-	Height    int64          `db:"height" json:"height"`
+	ID        uuid.UUID       `db:"id" json:"id"`
+	RemoteURL sql.NullString  `db:"url" json:"url"`
+	Checksum  string          `db:"checksum" json:"checksum"`
+	Width     int64           `db:"width" json:"width"`
+	Height    int64           `db:"height" json:"height"`
+	PHash     sql.NullInt64   `db:"phash" json:"phash"`
+	CreatedAt SQLiteTimestamp `db:"created_at" json:"created_at"`
+}
+
+// ImageDuplicate is a pair of images with perceptual hashes within the
+// queried distance of each other.
+type ImageDuplicate struct {
+	ImageID     uuid.UUID `db:"image_id"`
+	DuplicateID uuid.UUID `db:"duplicate_id"`
 }
 
 func (p Image) GetID() uuid.UUID {
@@ -12,6 +12,10 @@ type PerformerScene struct {
 	SceneID     uuid.UUID      `db:"scene_id" json:"scene_id"`
 }
 
+func (p PerformerScene) ID() string {
+	return p.PerformerID.String()
+}
+
 type PerformersScenes []*PerformerScene
 
 func (p PerformersScenes) Each(fn func(interface{})) {
@@ -20,15 +24,35 @@ func (p PerformersScenes) Each(fn func(interface{})) {
 	}
 }
 
+func (p PerformersScenes) EachPtr(fn func(interface{})) {
+	for _, v := range p {
+		fn(v)
+	}
+}
+
 func (p *PerformersScenes) Add(o interface{}) {
 	*p = append(*p, o.(*PerformerScene))
 }
 
+func (p *PerformersScenes) Remove(id string) {
+	for i, v := range *p {
+		if (*v).ID() == id {
+			(*p)[i] = (*p)[len(*p)-1]
+			*p = (*p)[:len(*p)-1]
+			break
+		}
+	}
+}
+
 type SceneTag struct {
 	SceneID uuid.UUID `db:"scene_id" json:"scene_id"`
 	TagID   uuid.UUID `db:"tag_id" json:"tag_id"`
 }
 
+func (p SceneTag) ID() string {
+	return p.TagID.String()
+}
+
 type ScenesTags []*SceneTag
 
 func (p ScenesTags) Each(fn func(interface{})) {
@@ -37,15 +61,35 @@ func (p ScenesTags) Each(fn func(interface{})) {
 	}
 }
 
+func (p ScenesTags) EachPtr(fn func(interface{})) {
+	for _, v := range p {
+		fn(v)
+	}
+}
+
 func (p *ScenesTags) Add(o interface{}) {
 	*p = append(*p, o.(*SceneTag))
 }
 
+func (p *ScenesTags) Remove(id string) {
+	for i, v := range *p {
+		if (*v).ID() == id {
+			(*p)[i] = (*p)[len(*p)-1]
+			*p = (*p)[:len(*p)-1]
+			break
+		}
+	}
+}
+
 type SceneImage struct {
 	SceneID uuid.UUID `db:"scene_id" json:"scene_id"`
 	ImageID uuid.UUID `db:"image_id" json:"image_id"`
 }
 
+func (p SceneImage) ID() string {
+	return p.ImageID.String()
+}
+
 type ScenesImages []*SceneImage
 
 func (p ScenesImages) Each(fn func(interface{})) {
@@ -54,10 +98,26 @@ func (p ScenesImages) Each(fn func(interface{})) {
 	}
 }
 
+func (p ScenesImages) EachPtr(fn func(interface{})) {
+	for _, v := range p {
+		fn(v)
+	}
+}
+
 func (p *ScenesImages) Add(o interface{}) {
 	*p = append(*p, o.(*SceneImage))
 }
 
+func (p *ScenesImages) Remove(id string) {
+	for i, v := range *p {
+		if (*v).ID() == id {
+			(*p)[i] = (*p)[len(*p)-1]
+			*p = (*p)[:len(*p)-1]
+			break
+		}
+	}
+}
+
 type PerformerImage struct {
 	PerformerID uuid.UUID `db:"performer_id" json:"performer_id"`
 	ImageID     uuid.UUID `db:"image_id" json:"image_id"`
@@ -0,0 +1,16 @@
+package models
+
+// PersistedQuery is a GraphQL query registered by a client, keyed by the
+// hex-encoded SHA-256 hash of the query.
+type PersistedQuery struct {
+	Hash       string          `db:"hash" json:"hash"`
+	Query      string          `db:"query" json:"query"`
+	CreatedAt  SQLiteTimestamp `db:"created_at" json:"created_at"`
+	LastUsedAt SQLiteTimestamp `db:"last_used_at" json:"last_used_at"`
+}
+
+type PersistedQueries []*PersistedQuery
+
+func (p *PersistedQueries) Add(o interface{}) {
+	*p = append(*p, o.(*PersistedQuery))
+}
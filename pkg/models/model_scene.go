@@ -2,6 +2,7 @@ package models
 
 import (
 	"database/sql"
+	"time"
 
 	"github.com/gofrs/uuid"
 )
@@ -45,6 +46,83 @@ type SceneFingerprint struct {
 	UpdatedAt   SQLiteTimestamp `db:"updated_at" json:"updated_at"`
 }
 
+// Fingerprint is a scene fingerprint as returned by the API, and as stored in
+// scene edit data.
+type Fingerprint struct {
+	Hash        string               `json:"hash"`
+	Algorithm   FingerprintAlgorithm `json:"algorithm"`
+	Duration    int                  `json:"duration"`
+	Submissions int                  `json:"submissions"`
+	Created     time.Time            `json:"created"`
+	Updated     time.Time            `json:"updated"`
+	// UserSubmitted depends on the current user, so is not stored in edits
+	UserSubmitted bool `json:"-"`
+}
+
+// SceneFingerprintSubmission records the submission of a scene fingerprint
+// by a user.
+type SceneFingerprintSubmission struct {
+	SceneID   uuid.UUID       `db:"scene_id" json:"scene_id"`
+	Hash      string          `db:"hash" json:"hash"`
+	UserID    uuid.UUID       `db:"user_id" json:"user_id"`
+	Duration  int             `db:"duration" json:"duration"`
+	CreatedAt SQLiteTimestamp `db:"created_at" json:"created_at"`
+}
+
+type SceneFingerprintSubmissions []*SceneFingerprintSubmission
+
+func (p SceneFingerprintSubmissions) Each(fn func(interface{})) {
+	for _, v := range p {
+		fn(*v)
+	}
+}
+
+func (p *SceneFingerprintSubmissions) Add(o interface{}) {
+	*p = append(*p, o.(*SceneFingerprintSubmission))
+}
+
+func CreateSceneFingerprintSubmissions(userID uuid.UUID, fingerprints SceneFingerprints) SceneFingerprintSubmissions {
+	var ret SceneFingerprintSubmissions
+	currentTime := time.Now()
+
+	for _, fingerprint := range fingerprints {
+		ret = append(ret, &SceneFingerprintSubmission{
+			SceneID:   fingerprint.SceneID,
+			Hash:      fingerprint.Hash,
+			UserID:    userID,
+			Duration:  fingerprint.Duration,
+			CreatedAt: SQLiteTimestamp{Timestamp: currentTime},
+		})
+	}
+
+	return ret
+}
+
+// MaxDurationDifference returns the maximum difference in seconds between
+// the queried and stored duration, or -1 if durations are not compared.
+func (f FingerprintQueryInput) MaxDurationDifference() int {
+	if f.Duration == nil || f.DurationTolerance == nil || *f.DurationTolerance < 0 {
+		return -1
+	}
+
+	return *f.DurationTolerance
+}
+
+// MatchesDuration returns true if the stored duration is within the duration
+// tolerance of the queried fingerprint. Unknown stored durations always match.
+func (f FingerprintQueryInput) MatchesDuration(duration int) bool {
+	tolerance := f.MaxDurationDifference()
+	if tolerance < 0 || duration == 0 {
+		return true
+	}
+
+	difference := duration - *f.Duration
+	if difference < 0 {
+		difference = -difference
+	}
+	return difference <= tolerance
+}
+
 type SceneURL struct {
 	SceneID uuid.UUID `db:"scene_id" json:"scene_id"`
 	URL     string    `db:"url" json:"url"`
@@ -59,6 +137,10 @@ func (p *SceneURL) ToURL() URL {
 	return url
 }
 
+func (p SceneURL) ID() string {
+	return p.URL + p.Type
+}
+
 type SceneURLs []*SceneURL
 
 func (p SceneURLs) Each(fn func(interface{})) {
@@ -67,10 +149,26 @@ func (p SceneURLs) Each(fn func(interface{})) {
 	}
 }
 
+func (p SceneURLs) EachPtr(fn func(interface{})) {
+	for _, v := range p {
+		fn(v)
+	}
+}
+
 func (p *SceneURLs) Add(o interface{}) {
 	*p = append(*p, o.(*SceneURL))
 }
 
+func (p *SceneURLs) Remove(id string) {
+	for i, v := range *p {
+		if (*v).ID() == id {
+			(*p)[i] = (*p)[len(*p)-1]
+			*p = (*p)[:len(*p)-1]
+			break
+		}
+	}
+}
+
 func CreateSceneURLs(sceneID uuid.UUID, urls []*URLInput) SceneURLs {
 	var ret SceneURLs
 
@@ -96,6 +194,10 @@ func (p SceneFingerprint) ToFingerprint() *Fingerprint {
 	}
 }
 
+func (p SceneFingerprint) ID() string {
+	return p.Algorithm + p.Hash
+}
+
 type SceneFingerprints []*SceneFingerprint
 
 func (p SceneFingerprints) Each(fn func(interface{})) {
@@ -104,10 +206,26 @@ func (p SceneFingerprints) Each(fn func(interface{})) {
 	}
 }
 
+func (p SceneFingerprints) EachPtr(fn func(interface{})) {
+	for _, v := range p {
+		fn(v)
+	}
+}
+
 func (p *SceneFingerprints) Add(o interface{}) {
 	*p = append(*p, o.(*SceneFingerprint))
 }
 
+func (p *SceneFingerprints) Remove(id string) {
+	for i, v := range *p {
+		if (*v).ID() == id {
+			(*p)[i] = (*p)[len(*p)-1]
+			*p = (*p)[:len(*p)-1]
+			break
+		}
+	}
+}
+
 func (p SceneFingerprints) ToFingerprints() []*Fingerprint {
 	var ret []*Fingerprint
 	for _, v := range p {
@@ -137,6 +255,24 @@ func CreateSceneFingerprints(sceneID uuid.UUID, fingerprints []*FingerprintEditI
 	return ret
 }
 
+func CreateSceneEditFingerprints(sceneID uuid.UUID, fingerprints []*Fingerprint) SceneFingerprints {
+	var ret SceneFingerprints
+
+	for _, fingerprint := range fingerprints {
+		ret = append(ret, &SceneFingerprint{
+			SceneID:     sceneID,
+			Hash:        fingerprint.Hash,
+			Algorithm:   fingerprint.Algorithm.String(),
+			Duration:    fingerprint.Duration,
+			Submissions: fingerprint.Submissions,
+			CreatedAt:   SQLiteTimestamp{Timestamp: fingerprint.Created},
+			UpdatedAt:   SQLiteTimestamp{Timestamp: fingerprint.Updated},
+		})
+	}
+
+	return ret
+}
+
 func CreateSubmittedSceneFingerprints(sceneID uuid.UUID, fingerprints []*FingerprintInput) SceneFingerprints {
 	var ret SceneFingerprints
 
@@ -223,3 +359,32 @@ func (p *Scene) CopyFromUpdateInput(input SceneUpdateInput) {
 		p.setDate(*input.Date)
 	}
 }
+
+func (p *Scene) CopyFromSceneEdit(input SceneEdit, old *SceneEdit) {
+	if old == nil {
+		old = &SceneEdit{}
+	}
+
+	fe := fromEdit{}
+	fe.nullString(&p.Title, input.Title, old.Title)
+	fe.nullString(&p.Details, input.Details, old.Details)
+	fe.sqliteDate(&p.Date, input.Date, old.Date)
+	fe.nullUUID(&p.StudioID, input.StudioID, old.StudioID)
+	fe.nullInt64(&p.Duration, input.Duration, old.Duration)
+	fe.nullString(&p.Director, input.Director, old.Director)
+
+	p.UpdatedAt = SQLiteTimestamp{Timestamp: time.Now()}
+}
+
+func (p *Scene) ValidateModifyEdit(edit SceneEditData) error {
+	v := editValidator{}
+
+	v.string("title", edit.Old.Title, p.Title.String)
+	v.string("details", edit.Old.Details, p.Details.String)
+	v.string("date", edit.Old.Date, p.Date.String)
+	v.uuid("studio id", edit.Old.StudioID, p.StudioID)
+	v.int64("duration", edit.Old.Duration, p.Duration.Int64)
+	v.string("director", edit.Old.Director, p.Director.String)
+
+	return v.err
+}
@@ -0,0 +1,32 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFingerprintQueryMatchesDuration(t *testing.T) {
+	duration := 600
+	tolerance := 5
+	negative := -1
+
+	unchecked := FingerprintQueryInput{Duration: &duration}
+	assert.Equal(t, -1, unchecked.MaxDurationDifference())
+	assert.True(t, unchecked.MatchesDuration(60))
+
+	disabled := FingerprintQueryInput{Duration: &duration, DurationTolerance: &negative}
+	assert.Equal(t, -1, disabled.MaxDurationDifference())
+	assert.True(t, disabled.MatchesDuration(60))
+
+	input := FingerprintQueryInput{Duration: &duration, DurationTolerance: &tolerance}
+	assert.Equal(t, tolerance, input.MaxDurationDifference())
+	assert.True(t, input.MatchesDuration(600))
+	assert.True(t, input.MatchesDuration(595))
+	assert.True(t, input.MatchesDuration(605))
+	assert.False(t, input.MatchesDuration(594))
+	assert.False(t, input.MatchesDuration(606))
+
+	// unknown stored durations always match
+	assert.True(t, input.MatchesDuration(0))
+}
@@ -0,0 +1,98 @@
+package models
+
+import (
+	"database/sql"
+
+	"github.com/gofrs/uuid"
+	"github.com/jmoiron/sqlx/types"
+	"github.com/lib/pq"
+)
+
+const (
+	WebhookDeliveryStatusPending   = "PENDING"
+	WebhookDeliveryStatusDelivered = "DELIVERED"
+	WebhookDeliveryStatusFailed    = "FAILED"
+)
+
+// Webhook is an HTTP endpoint notified of edit events.
+type Webhook struct {
+	ID        uuid.UUID       `db:"id" json:"id"`
+	URL       string          `db:"url" json:"url"`
+	Secret    string          `db:"secret" json:"secret"`
+	Events    pq.StringArray  `db:"events" json:"events"`
+	CreatedAt SQLiteTimestamp `db:"created_at" json:"created_at"`
+	UpdatedAt SQLiteTimestamp `db:"updated_at" json:"updated_at"`
+}
+
+func (p Webhook) GetID() uuid.UUID {
+	return p.ID
+}
+
+type Webhooks []*Webhook
+
+func (p Webhooks) Each(fn func(interface{})) {
+	for _, v := range p {
+		fn(v)
+	}
+}
+
+func (p *Webhooks) Add(o interface{}) {
+	*p = append(*p, o.(*Webhook))
+}
+
+func (p *Webhook) CopyFromCreateInput(input WebhookCreateInput) {
+	p.URL = input.URL
+	p.setEvents(input.Events)
+	if input.Secret != nil {
+		p.Secret = *input.Secret
+	}
+}
+
+func (p *Webhook) CopyFromUpdateInput(input WebhookUpdateInput) {
+	if input.URL != nil {
+		p.URL = *input.URL
+	}
+	if input.Events != nil {
+		p.setEvents(input.Events)
+	}
+	if input.Secret != nil {
+		p.Secret = *input.Secret
+	}
+}
+
+func (p *Webhook) setEvents(events []WebhookEventEnum) {
+	p.Events = pq.StringArray{}
+	for _, event := range events {
+		p.Events = append(p.Events, event.String())
+	}
+}
+
+// WebhookDelivery is a payload queued for delivery to a webhook.
+type WebhookDelivery struct {
+	ID            uuid.UUID       `db:"id" json:"id"`
+	WebhookID     uuid.UUID       `db:"webhook_id" json:"webhook_id"`
+	Event         string          `db:"event" json:"event"`
+	Payload       types.JSONText  `db:"payload" json:"payload"`
+	Status        string          `db:"status" json:"status"`
+	Attempts      int             `db:"attempts" json:"attempts"`
+	LastError     sql.NullString  `db:"last_error" json:"last_error"`
+	NextAttemptAt SQLiteTimestamp `db:"next_attempt_at" json:"next_attempt_at"`
+	CreatedAt     SQLiteTimestamp `db:"created_at" json:"created_at"`
+	UpdatedAt     SQLiteTimestamp `db:"updated_at" json:"updated_at"`
+}
+
+func (p WebhookDelivery) GetID() uuid.UUID {
+	return p.ID
+}
+
+type WebhookDeliveries []*WebhookDelivery
+
+func (p WebhookDeliveries) Each(fn func(interface{})) {
+	for _, v := range p {
+		fn(v)
+	}
+}
+
+func (p *WebhookDeliveries) Add(o interface{}) {
+	*p = append(*p, o.(*WebhookDelivery))
+}
@@ -45,6 +45,28 @@ func (p *Users) Add(o interface{}) {
 	*p = append(*p, o.(*User))
 }
 
+// UserEditCount holds the number of resolved edits submitted by a user, and
+// the number of votes cast by the user on resolved edits.
+type UserEditCount struct {
+	UserID            uuid.UUID `db:"user_id" json:"user_id"`
+	SuccessfulEdits   int       `db:"successful_edits" json:"successful_edits"`
+	UnsuccessfulEdits int       `db:"unsuccessful_edits" json:"unsuccessful_edits"`
+	SuccessfulVotes   int       `db:"successful_votes" json:"successful_votes"`
+	UnsuccessfulVotes int       `db:"unsuccessful_votes" json:"unsuccessful_votes"`
+}
+
+type UserEditCounts []*UserEditCount
+
+func (p UserEditCounts) Each(fn func(interface{})) {
+	for _, v := range p {
+		fn(*v)
+	}
+}
+
+func (p *UserEditCounts) Add(o interface{}) {
+	*p = append(*p, o.(*UserEditCount))
+}
+
 type UserRole struct {
 	UserID uuid.UUID `db:"user_id" json:"user_id"`
 	Role   string    `db:"role" json:"role"`
@@ -0,0 +1,13 @@
+package models
+
+type PersistedQueryRepo interface {
+	// Find returns the query with the SHA-256 hash, or nil if not found, and
+	// marks the query as used.
+	Find(hash string) (*PersistedQuery, error)
+	// Create stores the query, or marks it as used if a query with the hash
+	// already exists.
+	Create(newQuery PersistedQuery) error
+	// Prune removes the least recently used queries, keeping at most limit
+	// queries.
+	Prune(limit int) error
+}
@@ -10,19 +10,27 @@ type SceneRepo interface {
 	UpdateURLs(scene uuid.UUID, updatedJoins SceneURLs) error
 	CreateFingerprints(newJoins SceneFingerprints) error
 	UpdateFingerprints(sceneID uuid.UUID, updatedJoins SceneFingerprints) error
+	CreateFingerprintSubmissions(newJoins SceneFingerprintSubmissions) error
+	DestroyFingerprintSubmissions(joins SceneFingerprintSubmissions) error
+	GetFingerprintSubmissions(userID uuid.UUID, sceneIDs []uuid.UUID) (SceneFingerprintSubmissions, error)
+	GetAllSubmittedHashes(userID uuid.UUID, ids []uuid.UUID) ([][]string, []error)
 	Find(id uuid.UUID) (*Scene, error)
-	FindByFingerprint(algorithm FingerprintAlgorithm, hash string) ([]*Scene, error)
+	FindByIds(ids []uuid.UUID) ([]*Scene, []error)
+	FindByFingerprint(fingerprint FingerprintQueryInput) ([]*Scene, error)
 	FindByFingerprints(fingerprints []string) ([]*Scene, error)
 	FindByFullFingerprints(fingerprints []*FingerprintQueryInput) ([]*Scene, error)
+	FindFingerprintMatches(fingerprints []*FingerprintQueryInput, distance int) (SceneFingerprints, error)
 	FindByTitle(name string) ([]*Scene, error)
 	Count() (int, error)
 	Query(sceneFilter *SceneFilterType, findFilter *QuerySpec) ([]*Scene, int)
 	GetFingerprints(id uuid.UUID) ([]*Fingerprint, error)
 	GetAllFingerprints(ids []uuid.UUID) ([][]*Fingerprint, []error)
+	GetMedianDurations(ids []uuid.UUID) ([]*int, []error)
 	GetPerformers(id uuid.UUID) (PerformersScenes, error)
 	GetAllAppearances(ids []uuid.UUID) ([]PerformersScenes, []error)
 	GetURLs(id uuid.UUID) (SceneURLs, error)
 	GetAllURLs(ids []uuid.UUID) ([][]*URL, []error)
 	SearchScenes(term string, limit int) ([]*Scene, error)
 	CountByPerformer(id uuid.UUID) (int, error)
+	ApplyEdit(edit Edit, operation OperationEnum, scene *Scene) (*Scene, error)
 }
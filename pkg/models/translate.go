@@ -106,6 +106,20 @@ func (d *editDiff) nullInt64(old sql.NullInt64, new *int) (oldOut *int64, newOut
 	return
 }
 
+func (d *editDiff) sqliteDate(old SQLiteDate, new *string) (oldOut *string, newOut *string) {
+	if old.Valid && (new == nil || *new != old.String) {
+		oldVal := old.String
+		oldOut = &oldVal
+	}
+
+	if new != nil && (!old.Valid || *new != old.String) {
+		newVal := *new
+		newOut = &newVal
+	}
+
+	return
+}
+
 func (d *editDiff) nullUUID(old uuid.NullUUID, new *string) (oldOut *string, newOut *string) {
 	oldStr := old.UUID.String()
 	if old.Valid && (new == nil || *new != oldStr) {
@@ -1,6 +1,8 @@
 package models
 
 import (
+	"time"
+
 	"github.com/gofrs/uuid"
 )
 
@@ -17,6 +19,9 @@ type UserRepo interface {
 	Count() (int, error)
 	Query(userFilter *UserFilterType, findFilter *QuerySpec) (Users, int)
 	GetRoles(id uuid.UUID) (UserRoles, error)
+	GetEditCounts(ids []uuid.UUID) ([]*UserEditCount, []error)
+	AddAPICalls(userID uuid.UUID, period time.Time, calls int, lastCall time.Time) error
+	UpdateAPICallCounts(windowStart time.Time) error
 }
 
 // UserFinder is an interface to find and update User objects.
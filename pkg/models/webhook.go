@@ -0,0 +1,27 @@
+package models
+
+import (
+	"time"
+
+	"github.com/gofrs/uuid"
+)
+
+type WebhookRepo interface {
+	Create(newWebhook Webhook) (*Webhook, error)
+	Update(updatedWebhook Webhook) (*Webhook, error)
+	Destroy(id uuid.UUID) error
+	Find(id uuid.UUID) (*Webhook, error)
+	FindAll() ([]*Webhook, error)
+	// FindByEvent returns the webhooks subscribed to the event.
+	FindByEvent(event WebhookEventEnum) ([]*Webhook, error)
+
+	CreateDelivery(newDelivery WebhookDelivery) error
+	UpdateDelivery(updatedDelivery WebhookDelivery) error
+	// ClaimDeliveries returns up to limit pending deliveries which are due,
+	// and postpones their next attempt until the lease expires, so that
+	// they are not claimed again while being delivered.
+	ClaimDeliveries(limit int, lease time.Duration) ([]*WebhookDelivery, error)
+	// DestroyCompletedDeliveries removes delivered and failed deliveries
+	// last updated before the time.
+	DestroyCompletedDeliveries(updatedBefore time.Time) error
+}
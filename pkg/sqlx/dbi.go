@@ -168,18 +168,24 @@ func (q dbi) InsertJoins(tj tableJoin, joins Joins) error {
 	return err
 }
 
-// InsertJoinsWithConflictHandling inserts multiple join objects and adds a conflict clause
+// InsertJoinsWithConflictHandling inserts multiple join objects using a
+// multi-row insert and adds a conflict clause. The join objects must not
+// conflict with each other.
 func (q dbi) InsertJoinsWithConflictHandling(tj tableJoin, joins Joins, conflictHandling string) error {
-	var err error
+	var objects []interface{}
 	joins.Each(func(ro interface{}) {
-		if err != nil {
-			return
-		}
-
-		err = q.InsertJoin(tj, ro, &conflictHandling)
+		objects = append(objects, ro)
 	})
 
-	return err
+	if len(objects) == 0 {
+		return nil
+	}
+
+	if err := insertObjects(q.txn, tj.Name(), objects, &conflictHandling); err != nil {
+		return errors.Wrap(err, fmt.Sprintf("Error creating %s", reflect.TypeOf(objects[0]).Name()))
+	}
+
+	return nil
 }
 
 // ReplaceJoins replaces table join objects with the provided primary table
@@ -51,3 +51,15 @@ func (f *repo) Invite() models.InviteKeyRepo {
 func (f *repo) User() models.UserRepo {
 	return newUserQueryBuilder(f.txnState)
 }
+
+func (f *repo) AuditLog() models.AuditLogRepo {
+	return newAuditLogQueryBuilder(f.txnState)
+}
+
+func (f *repo) PersistedQuery() models.PersistedQueryRepo {
+	return newPersistedQueryQueryBuilder(f.txnState)
+}
+
+func (f *repo) Webhook() models.WebhookRepo {
+	return newWebhookQueryBuilder(f.txnState)
+}
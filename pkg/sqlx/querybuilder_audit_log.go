@@ -0,0 +1,86 @@
+package sqlx
+
+import (
+	"github.com/stashapp/stash-box/pkg/models"
+)
+
+const (
+	auditLogTable = "audit_log"
+)
+
+var (
+	auditLogDBTable = newTable(auditLogTable, func() interface{} {
+		return &models.AuditLog{}
+	})
+)
+
+type auditLogQueryBuilder struct {
+	dbi *dbi
+}
+
+func newAuditLogQueryBuilder(txn *txnState) models.AuditLogRepo {
+	return &auditLogQueryBuilder{
+		dbi: newDBI(txn),
+	}
+}
+
+func (qb *auditLogQueryBuilder) toModel(ro interface{}) *models.AuditLog {
+	if ro != nil {
+		return ro.(*models.AuditLog)
+	}
+
+	return nil
+}
+
+func (qb *auditLogQueryBuilder) Create(newEntry models.AuditLog) (*models.AuditLog, error) {
+	ret, err := qb.dbi.Insert(auditLogDBTable, newEntry)
+	return qb.toModel(ret), err
+}
+
+func (qb *auditLogQueryBuilder) Query(auditFilter *models.AuditLogFilterType, findFilter *models.QuerySpec) ([]*models.AuditLog, int, error) {
+	if auditFilter == nil {
+		auditFilter = &models.AuditLogFilterType{}
+	}
+	if findFilter == nil {
+		findFilter = &models.QuerySpec{}
+	}
+
+	query := newQueryBuilder(auditLogDBTable)
+
+	if q := auditFilter.UserID; q != nil && *q != "" {
+		query.Eq(auditLogDBTable.Name()+".user_id", *q)
+	}
+	if q := auditFilter.Action; q != nil {
+		query.Eq(auditLogDBTable.Name()+".action", q.String())
+	}
+	if q := auditFilter.TargetType; q != nil {
+		query.Eq(auditLogDBTable.Name()+".target_type", q.String())
+	}
+	if q := auditFilter.TargetID; q != nil && *q != "" {
+		query.Eq(auditLogDBTable.Name()+".target_id", *q)
+	}
+	if q := auditFilter.CreatedAfter; q != nil {
+		query.AddWhere(auditLogDBTable.Name() + ".created_at >= ?")
+		query.AddArg(*q)
+	}
+	if q := auditFilter.CreatedBefore; q != nil {
+		query.AddWhere(auditLogDBTable.Name() + ".created_at < ?")
+		query.AddArg(*q)
+	}
+
+	query.SortAndPagination = qb.getAuditLogSort(findFilter) + getPagination(findFilter)
+
+	var entries models.AuditLogs
+	countResult, err := qb.dbi.Query(*query, &entries)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return entries, countResult, nil
+}
+
+func (qb *auditLogQueryBuilder) getAuditLogSort(findFilter *models.QuerySpec) string {
+	sort := findFilter.GetSort("created_at")
+	direction := findFilter.GetDirection()
+	return getSort(qb.dbi.txn.dialect, sort, direction, auditLogTable, nil)
+}
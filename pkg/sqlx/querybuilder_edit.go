@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"time"
 
 	"github.com/gofrs/uuid"
 	"github.com/stashapp/stash-box/pkg/models"
@@ -12,8 +13,6 @@ import (
 const (
 	editTable   = "edits"
 	editJoinKey = "edit_id"
-
-	//voteTable = "votes"
 )
 
 var (
@@ -33,13 +32,21 @@ var (
 		return &models.EditStudio{}
 	})
 
+	editSceneTable = newTableJoin(editTable, "scene_edits", editJoinKey, func() interface{} {
+		return &models.EditScene{}
+	})
+
 	editCommentTable = newTableJoin(editTable, "edit_comments", editJoinKey, func() interface{} {
 		return &models.EditComment{}
 	})
 
-	// voteDBTable = database.NewTable(editTable, func() interface{} {
-	// 	return &Edit{}
-	// })
+	editVoteTable = newTableJoin(editTable, "edit_votes", editJoinKey, func() interface{} {
+		return &models.VoteComment{}
+	})
+
+	editAmendmentTable = newTableJoin(editTable, "edit_amendments", editJoinKey, func() interface{} {
+		return &models.EditAmendment{}
+	})
 )
 
 type editQueryBuilder struct {
@@ -91,6 +98,10 @@ func (qb *editQueryBuilder) CreateEditStudio(newJoin models.EditStudio) error {
 	return qb.dbi.InsertJoin(editStudioTable, newJoin, nil)
 }
 
+func (qb *editQueryBuilder) CreateEditScene(newJoin models.EditScene) error {
+	return qb.dbi.InsertJoin(editSceneTable, newJoin, nil)
+}
+
 func (qb *editQueryBuilder) FindTagID(id uuid.UUID) (*uuid.UUID, error) {
 	joins := models.EditTags{}
 	err := qb.dbi.FindJoins(editTagTable, id, &joins)
@@ -127,6 +138,18 @@ func (qb *editQueryBuilder) FindStudioID(id uuid.UUID) (*uuid.UUID, error) {
 	return &joins[0].StudioID, nil
 }
 
+func (qb *editQueryBuilder) FindSceneID(id uuid.UUID) (*uuid.UUID, error) {
+	joins := models.EditScenes{}
+	err := qb.dbi.FindJoins(editSceneTable, id, &joins)
+	if err != nil {
+		return nil, err
+	}
+	if len(joins) == 0 {
+		return nil, errors.New("scene edit not found")
+	}
+	return &joins[0].SceneID, nil
+}
+
 // func (qb *SceneQueryBuilder) FindByStudioID(sceneID int) ([]*Scene, error) {
 // 	query := `
 // 		SELECT scenes.* FROM scenes
@@ -170,6 +193,29 @@ func (qb *editQueryBuilder) Count() (int, error) {
 	return runCountQuery(qb.dbi.db(), buildCountQuery("SELECT edits.id FROM edits"), nil)
 }
 
+// CountByStatus returns the number of edits of each status.
+func (qb *editQueryBuilder) CountByStatus() (map[models.VoteStatusEnum]int, error) {
+	rows, err := qb.dbi.queryx("SELECT status, COUNT(*) FROM edits GROUP BY status")
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	ret := make(map[models.VoteStatusEnum]int)
+	for rows.Next() {
+		var status string
+		var count int
+		if err := rows.Scan(&status, &count); err != nil {
+			return nil, err
+		}
+		ret[models.VoteStatusEnum(status)] = count
+	}
+
+	return ret, rows.Err()
+}
+
 func (qb *editQueryBuilder) Query(editFilter *models.EditFilterType, findFilter *models.QuerySpec) ([]*models.Edit, int) {
 	if editFilter == nil {
 		editFilter = &models.EditFilterType{}
@@ -203,6 +249,11 @@ func (qb *editQueryBuilder) Query(editFilter *models.EditFilterType, findFilter
 			query.AddWhere("(" + editStudioTable.Name() + ".studio_id = ? OR " + editDBTable.Name() + ".data->'merge_sources' @> ?)")
 			jsonID, _ := json.Marshal(*q)
 			query.AddArg(*q, jsonID)
+		} else if *editFilter.TargetType == models.TargetTypeEnumScene {
+			query.AddJoin(editSceneTable.table, editSceneTable.Name()+".edit_id = edits.id")
+			query.AddWhere("(" + editSceneTable.Name() + ".scene_id = ? OR " + editDBTable.Name() + ".data->'merge_sources' @> ?)")
+			jsonID, _ := json.Marshal(*q)
+			query.AddArg(*q, jsonID)
 		} else {
 			panic("TargetType is not yet supported: " + *editFilter.TargetType)
 		}
@@ -263,6 +314,37 @@ func (qb *editQueryBuilder) GetComments(id uuid.UUID) (models.EditComments, erro
 	return joins, err
 }
 
+func (qb *editQueryBuilder) CreateVote(newJoin models.VoteComment) error {
+	// a user has a single vote per edit, so replace any existing vote
+	conflictHandling := `
+		ON CONFLICT ON CONSTRAINT edit_votes_pkey
+		DO UPDATE SET (date, comment, type) = (EXCLUDED.date, EXCLUDED.comment, EXCLUDED.type)
+	`
+	return qb.dbi.InsertJoin(editVoteTable, newJoin, &conflictHandling)
+}
+
+func (qb *editQueryBuilder) GetVotes(id uuid.UUID) (models.VoteComments, error) {
+	joins := models.VoteComments{}
+	err := qb.dbi.FindJoins(editVoteTable, id, &joins)
+
+	return joins, err
+}
+
+func (qb *editQueryBuilder) DeleteVotes(id uuid.UUID) error {
+	return qb.dbi.DeleteJoins(editVoteTable, id)
+}
+
+func (qb *editQueryBuilder) CreateAmendment(newJoin models.EditAmendment) error {
+	return qb.dbi.InsertJoin(editAmendmentTable, newJoin, nil)
+}
+
+func (qb *editQueryBuilder) GetAmendments(id uuid.UUID) (models.EditAmendments, error) {
+	joins := models.EditAmendments{}
+	err := qb.dbi.FindJoins(editAmendmentTable, id, &joins)
+
+	return joins, err
+}
+
 func (qb *editQueryBuilder) findByJoin(id uuid.UUID, table tableJoin, idColumn string) ([]*models.Edit, error) {
 	query := fmt.Sprintf(`
 SELECT edits.* FROM edits
@@ -285,3 +367,20 @@ func (qb *editQueryBuilder) FindByPerformerID(id uuid.UUID) ([]*models.Edit, err
 func (qb *editQueryBuilder) FindByStudioID(id uuid.UUID) ([]*models.Edit, error) {
 	return qb.findByJoin(id, editStudioTable, "studio_id")
 }
+
+func (qb *editQueryBuilder) FindBySceneID(id uuid.UUID) ([]*models.Edit, error) {
+	return qb.findByJoin(id, editSceneTable, "scene_id")
+}
+
+// FindCompletedEdits returns pending edits which have reached the vote
+// threshold in either direction, or were created before votingEnd.
+func (qb *editQueryBuilder) FindCompletedEdits(threshold int, votingEnd time.Time) ([]*models.Edit, error) {
+	query := `
+SELECT edits.* FROM edits
+WHERE status = ?
+AND (created_at <= ? OR (? > 0 AND (votes >= ? OR votes <= ?)))
+ORDER BY created_at ASC`
+
+	args := []interface{}{models.VoteStatusEnumPending.String(), votingEnd, threshold, threshold, -threshold}
+	return qb.queryEdits(query, args)
+}
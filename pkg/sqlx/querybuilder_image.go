@@ -1,6 +1,8 @@
 package sqlx
 
 import (
+	"time"
+
 	"github.com/gofrs/uuid"
 	"github.com/jmoiron/sqlx"
 	"github.com/stashapp/stash-box/pkg/models"
@@ -84,6 +86,144 @@ func (qb *imageQueryBuilder) FindByChecksum(checksum string) (*models.Image, err
 	return nil, nil
 }
 
+// FindWithChecksum returns images with a stored file, ordered by ID,
+// starting after the provided ID.
+func (qb *imageQueryBuilder) FindWithChecksum(after uuid.UUID, limit int) ([]*models.Image, error) {
+	query := `
+		SELECT images.* FROM images
+		WHERE images.checksum != '' AND images.id > ?
+		ORDER BY images.id
+		LIMIT ?
+	`
+	args := []interface{}{after, limit}
+	return qb.queryImages(query, args)
+}
+
+func (qb *imageQueryBuilder) CountWithChecksum() (int, error) {
+	return runCountQuery(qb.dbi.db(), buildCountQuery("SELECT images.id FROM images WHERE images.checksum != ''"), nil)
+}
+
+// FindDuplicatePHashes returns the pairs of images with perceptual hashes
+// within the provided distance of each other. Distances above 0 require the
+// bktree extension.
+func (qb *imageQueryBuilder) FindDuplicatePHashes(distance int) ([]*models.ImageDuplicate, error) {
+	condition := "D.phash = I.phash"
+	if distance > 0 {
+		condition = "D.phash <@ (I.phash, :distance)"
+	}
+
+	query := `
+		SELECT I.id AS image_id, D.id AS duplicate_id FROM images I
+		JOIN images D ON ` + condition + ` AND D.id > I.id
+		WHERE I.phash IS NOT NULL AND D.phash IS NOT NULL
+		ORDER BY I.id, D.id`
+	query, args, err := sqlx.Named(query, map[string]interface{}{"distance": distance})
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := qb.dbi.queryx(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	var ret []*models.ImageDuplicate
+	for rows.Next() {
+		duplicate := models.ImageDuplicate{}
+		if err := rows.StructScan(&duplicate); err != nil {
+			return nil, err
+		}
+		ret = append(ret, &duplicate)
+	}
+
+	return ret, rows.Err()
+}
+
+// MergeInto replaces the source images with the target image in all scene,
+// performer and studio images.
+func (qb *imageQueryBuilder) MergeInto(sourceIDs []uuid.UUID, targetID uuid.UUID) error {
+	arg := map[string]interface{}{
+		"sources": sourceIDs,
+		"target":  targetID,
+	}
+
+	for _, table := range []tableJoin{sceneImageTable, performerImageTable, studioImageTable} {
+		insert := `
+			INSERT INTO ` + table.name + ` (` + table.joinColumn + `, image_id)
+			SELECT DISTINCT ` + table.joinColumn + `, :target FROM ` + table.name + `
+			WHERE image_id IN (:sources)
+			AND ` + table.joinColumn + ` NOT IN (
+				SELECT ` + table.joinColumn + ` FROM ` + table.name + ` WHERE image_id = :target
+			)`
+		remove := `DELETE FROM ` + table.name + ` WHERE image_id IN (:sources)`
+
+		for _, query := range []string{insert, remove} {
+			query, args, err := sqlx.Named(query, arg)
+			if err != nil {
+				return err
+			}
+			query, args, err = sqlx.In(query, args...)
+			if err != nil {
+				return err
+			}
+			if err := qb.dbi.RawExec(query, args); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// IsInPendingEdit returns true if the image is added or removed by a
+// pending edit.
+func (qb *imageQueryBuilder) IsInPendingEdit(imageID uuid.UUID) (bool, error) {
+	query := `
+		SELECT edits.id FROM edits
+		WHERE edits.status = 'PENDING'
+		AND (
+			edits.data->'new_data'->'added_images' @> jsonb_build_array(?::text)
+			OR edits.data->'new_data'->'removed_images' @> jsonb_build_array(?::text)
+		)
+	`
+	args := []interface{}{imageID.String(), imageID.String()}
+	count, err := runCountQuery(qb.dbi.db(), buildCountQuery(query), args)
+	if err != nil {
+		return false, err
+	}
+
+	return count > 0, nil
+}
+
+// FindOrphans returns images created before the provided time which are not
+// used by a scene, performer or studio, and are not added or removed by a
+// pending edit or an edit updated after the provided time. Images are
+// ordered by ID, starting after the provided ID.
+func (qb *imageQueryBuilder) FindOrphans(createdBefore time.Time, editsUpdatedAfter time.Time, after uuid.UUID, limit int) ([]*models.Image, error) {
+	query := `
+		SELECT images.* FROM images
+		WHERE images.created_at < ? AND images.id > ?
+		AND NOT EXISTS (SELECT 1 FROM scene_images WHERE image_id = images.id)
+		AND NOT EXISTS (SELECT 1 FROM performer_images WHERE image_id = images.id)
+		AND NOT EXISTS (SELECT 1 FROM studio_images WHERE image_id = images.id)
+		AND NOT EXISTS (
+			SELECT 1 FROM edits
+			WHERE (edits.status = 'PENDING' OR edits.updated_at > ?)
+			AND (
+				edits.data->'new_data'->'added_images' @> jsonb_build_array(images.id::text)
+				OR edits.data->'new_data'->'removed_images' @> jsonb_build_array(images.id::text)
+			)
+		)
+		ORDER BY images.id
+		LIMIT ?
+	`
+	args := []interface{}{createdBefore, after, editsUpdatedAfter, limit}
+	return qb.queryImages(query, args)
+}
+
 func (qb *imageQueryBuilder) FindUnused() ([]*models.Image, error) {
 	query := `
 		SELECT images.* from images
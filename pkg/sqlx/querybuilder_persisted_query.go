@@ -0,0 +1,59 @@
+package sqlx
+
+import (
+	"time"
+
+	"github.com/stashapp/stash-box/pkg/models"
+)
+
+const (
+	persistedQueryTable = "persisted_queries"
+)
+
+var (
+	persistedQueryDBTable = newTable(persistedQueryTable, func() interface{} {
+		return &models.PersistedQuery{}
+	})
+)
+
+type persistedQueryQueryBuilder struct {
+	dbi *dbi
+}
+
+func newPersistedQueryQueryBuilder(txn *txnState) models.PersistedQueryRepo {
+	return &persistedQueryQueryBuilder{
+		dbi: newDBI(txn),
+	}
+}
+
+func (qb *persistedQueryQueryBuilder) Find(hash string) (*models.PersistedQuery, error) {
+	query := "UPDATE " + persistedQueryTable + " SET last_used_at = ? WHERE hash = ? RETURNING *"
+	args := []interface{}{models.SQLiteTimestamp{Timestamp: time.Now()}, hash}
+
+	var output models.PersistedQueries
+	if err := qb.dbi.RawQuery(persistedQueryDBTable, query, args, &output); err != nil {
+		return nil, err
+	}
+
+	if len(output) == 0 {
+		return nil, nil
+	}
+	return output[0], nil
+}
+
+func (qb *persistedQueryQueryBuilder) Create(newQuery models.PersistedQuery) error {
+	query := "INSERT INTO " + persistedQueryTable + " (hash, query, created_at, last_used_at) VALUES (?, ?, ?, ?)" +
+		" ON CONFLICT (hash) DO UPDATE SET last_used_at = EXCLUDED.last_used_at"
+	return qb.dbi.RawExec(query, []interface{}{newQuery.Hash, newQuery.Query, newQuery.CreatedAt, newQuery.LastUsedAt})
+}
+
+func (qb *persistedQueryQueryBuilder) Prune(limit int) error {
+	query := `
+		DELETE FROM ` + persistedQueryTable + ` WHERE hash IN (
+			SELECT hash FROM ` + persistedQueryTable + `
+			ORDER BY last_used_at DESC
+			OFFSET ?
+		)
+	`
+	return qb.dbi.RawExec(query, []interface{}{limit})
+}
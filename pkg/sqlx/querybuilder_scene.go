@@ -1,12 +1,15 @@
 package sqlx
 
 import (
+	"errors"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/gofrs/uuid"
 	"github.com/jmoiron/sqlx"
 	"github.com/stashapp/stash-box/pkg/manager/config"
+	"github.com/stashapp/stash-box/pkg/metrics"
 	"github.com/stashapp/stash-box/pkg/models"
 	"github.com/stashapp/stash-box/pkg/utils"
 )
@@ -25,9 +28,17 @@ var (
 		return &models.SceneFingerprint{}
 	})
 
+	sceneFingerprintSubmissionTable = newTableJoin(sceneTable, "scene_fingerprint_submissions", sceneJoinKey, func() interface{} {
+		return &models.SceneFingerprintSubmission{}
+	})
+
 	sceneURLTable = newTableJoin(sceneTable, "scene_urls", sceneJoinKey, func() interface{} {
 		return &models.SceneURL{}
 	})
+
+	sceneSourceRedirectTable = newTableJoin(sceneTable, "scene_redirects", "source_id", func() interface{} {
+		return &models.Redirect{}
+	})
 )
 
 type sceneQueryBuilder struct {
@@ -71,15 +82,163 @@ func (qb *sceneQueryBuilder) UpdateURLs(scene uuid.UUID, updatedJoins models.Sce
 }
 
 func (qb *sceneQueryBuilder) CreateFingerprints(newJoins models.SceneFingerprints) error {
+	// a single insert statement cannot update the same row twice, so
+	// duplicate fingerprints are combined first
+	type fingerprintKey struct {
+		sceneID uuid.UUID
+		hash    string
+	}
+
+	var joins models.SceneFingerprints
+	existing := make(map[fingerprintKey]*models.SceneFingerprint)
+	for _, join := range newJoins {
+		key := fingerprintKey{sceneID: join.SceneID, hash: join.Hash}
+		if e := existing[key]; e != nil {
+			e.Submissions += join.Submissions
+			continue
+		}
+
+		j := *join
+		existing[key] = &j
+		joins = append(joins, &j)
+	}
+
+	// the submissions of existing fingerprints are counted when the user
+	// submissions are added
 	conflictHandling := `
 		ON CONFLICT ON CONSTRAINT scene_hash_unique
-		DO UPDATE SET submissions = (scene_fingerprints.submissions+1), updated_at = NOW()
+		DO UPDATE SET updated_at = NOW()
 	`
-	return qb.dbi.InsertJoinsWithConflictHandling(sceneFingerprintTable, &newJoins, conflictHandling)
+	return qb.dbi.InsertJoinsWithConflictHandling(sceneFingerprintTable, &joins, conflictHandling)
+}
+
+// CreateFingerprintSubmissions adds the provided submissions and increments
+// the submission count of the fingerprints by the number of submissions
+// added. Fingerprints submitted before user submissions were recorded keep
+// their existing count.
+func (qb *sceneQueryBuilder) CreateFingerprintSubmissions(newJoins models.SceneFingerprintSubmissions) error {
+	if len(newJoins) == 0 {
+		return nil
+	}
+
+	var values []string
+	var args []interface{}
+	for _, join := range newJoins {
+		values = append(values, "(?::uuid, ?, ?::uuid, ?::integer, ?::timestamp)")
+		args = append(args, join.SceneID, join.Hash, join.UserID, join.Duration, join.CreatedAt)
+	}
+
+	// a user can only submit a fingerprint once
+	query := `
+		WITH inserted AS (
+			INSERT INTO scene_fingerprint_submissions (scene_id, hash, user_id, duration, created_at)
+			VALUES ` + strings.Join(values, ", ") + `
+			ON CONFLICT ON CONSTRAINT scene_fingerprint_submissions_pkey DO NOTHING
+			RETURNING scene_id, hash
+		)
+		UPDATE scene_fingerprints F SET submissions = F.submissions + I.count, updated_at = NOW()
+		FROM (SELECT scene_id, hash, COUNT(*) AS count FROM inserted GROUP BY scene_id, hash) I
+		WHERE F.scene_id = I.scene_id AND F.hash = I.hash`
+	return qb.dbi.RawExec(query, args)
+}
+
+// DestroyFingerprintSubmissions removes the provided submissions and
+// decrements the submission count of the fingerprints by the number of
+// submissions removed. Fingerprints without any remaining submissions are
+// deleted.
+func (qb *sceneQueryBuilder) DestroyFingerprintSubmissions(joins models.SceneFingerprintSubmissions) error {
+	if len(joins) == 0 {
+		return nil
+	}
+
+	var values []string
+	var args []interface{}
+	for _, join := range joins {
+		values = append(values, "(?::uuid, ?, ?::uuid)")
+		args = append(args, join.SceneID, join.Hash, join.UserID)
+	}
+	submissions := `(VALUES ` + strings.Join(values, ", ") + `) AS V(scene_id, hash, user_id)`
+
+	query := `
+		WITH deleted AS (
+			DELETE FROM scene_fingerprint_submissions S
+			USING ` + submissions + `
+			WHERE S.scene_id = V.scene_id AND S.hash = V.hash AND S.user_id = V.user_id
+			RETURNING S.scene_id, S.hash
+		)
+		UPDATE scene_fingerprints F SET submissions = F.submissions - D.count, updated_at = NOW()
+		FROM (SELECT scene_id, hash, COUNT(*) AS count FROM deleted GROUP BY scene_id, hash) D
+		WHERE F.scene_id = D.scene_id AND F.hash = D.hash`
+	if err := qb.dbi.RawExec(query, args); err != nil {
+		return err
+	}
+
+	// the count includes submissions made before user submissions were
+	// recorded, so fingerprints are only deleted when neither remain
+	query = `
+		DELETE FROM scene_fingerprints F
+		USING ` + submissions + `
+		WHERE F.scene_id = V.scene_id AND F.hash = V.hash
+		AND F.submissions <= 0
+		AND NOT EXISTS (
+			SELECT 1 FROM scene_fingerprint_submissions S
+			WHERE S.scene_id = F.scene_id AND S.hash = F.hash
+		)`
+	return qb.dbi.RawExec(query, args)
+}
+
+// destroyRemovedFingerprintSubmissions removes the submissions of
+// fingerprints which are no longer on the scene.
+func (qb *sceneQueryBuilder) destroyRemovedFingerprintSubmissions(sceneID uuid.UUID) error {
+	query := `
+		DELETE FROM scene_fingerprint_submissions S
+		WHERE S.scene_id = ?
+		AND NOT EXISTS (
+			SELECT 1 FROM scene_fingerprints F
+			WHERE F.scene_id = S.scene_id AND F.hash = S.hash
+		)`
+	return qb.dbi.RawExec(query, []interface{}{sceneID})
+}
+
+func (qb *sceneQueryBuilder) GetFingerprintSubmissions(userID uuid.UUID, sceneIDs []uuid.UUID) (models.SceneFingerprintSubmissions, error) {
+	if len(sceneIDs) == 0 {
+		return nil, nil
+	}
+
+	query := `SELECT * FROM scene_fingerprint_submissions WHERE user_id = ? AND scene_id IN (?)`
+	query, args, _ := sqlx.In(query, userID, sceneIDs)
+
+	output := models.SceneFingerprintSubmissions{}
+	err := qb.dbi.RawQuery(sceneFingerprintSubmissionTable.table, query, args, &output)
+	return output, err
+}
+
+// GetAllSubmittedHashes returns the hashes of the fingerprints of each scene
+// submitted by the user.
+func (qb *sceneQueryBuilder) GetAllSubmittedHashes(userID uuid.UUID, ids []uuid.UUID) ([][]string, []error) {
+	submissions, err := qb.GetFingerprintSubmissions(userID, ids)
+	if err != nil {
+		return nil, utils.DuplicateError(err, len(ids))
+	}
+
+	m := make(map[uuid.UUID][]string)
+	for _, submission := range submissions {
+		m[submission.SceneID] = append(m[submission.SceneID], submission.Hash)
+	}
+
+	result := make([][]string, len(ids))
+	for i, id := range ids {
+		result[i] = m[id]
+	}
+	return result, nil
 }
 
 func (qb *sceneQueryBuilder) UpdateFingerprints(sceneID uuid.UUID, updatedJoins models.SceneFingerprints) error {
-	return qb.dbi.ReplaceJoins(sceneFingerprintTable, sceneID, &updatedJoins)
+	if err := qb.dbi.ReplaceJoins(sceneFingerprintTable, sceneID, &updatedJoins); err != nil {
+		return err
+	}
+
+	return qb.destroyRemovedFingerprintSubmissions(sceneID)
 }
 
 func (qb *sceneQueryBuilder) Find(id uuid.UUID) (*models.Scene, error) {
@@ -87,18 +246,46 @@ func (qb *sceneQueryBuilder) Find(id uuid.UUID) (*models.Scene, error) {
 	return qb.toModel(ret), err
 }
 
-func (qb *sceneQueryBuilder) FindByFingerprint(algorithm models.FingerprintAlgorithm, hash string) ([]*models.Scene, error) {
+func (qb *sceneQueryBuilder) FindByIds(ids []uuid.UUID) ([]*models.Scene, []error) {
+	query := "SELECT scenes.* FROM scenes WHERE id IN (?)"
+	query, args, _ := sqlx.In(query, ids)
+	scenes, err := qb.queryScenes(query, args)
+	if err != nil {
+		return nil, utils.DuplicateError(err, len(ids))
+	}
+
+	m := make(map[uuid.UUID]*models.Scene)
+	for _, scene := range scenes {
+		m[scene.ID] = scene
+	}
+
+	result := make([]*models.Scene, len(ids))
+	for i, id := range ids {
+		result[i] = m[id]
+	}
+	return result, nil
+}
+
+func (qb *sceneQueryBuilder) FindByFingerprint(fingerprint models.FingerprintQueryInput) ([]*models.Scene, error) {
+	defer metrics.ObserveFingerprintLookup("fingerprint", 1)()
+
 	query := `
 		SELECT scenes.* FROM scenes
 		LEFT JOIN scene_fingerprints as scenes_join on scenes_join.scene_id = scenes.id
 		WHERE scenes_join.algorithm = ? AND scenes_join.hash = ?`
 	var args []interface{}
-	args = append(args, algorithm.String())
-	args = append(args, hash)
+	args = append(args, fingerprint.Algorithm.String())
+	args = append(args, fingerprint.Hash)
+	if tolerance := fingerprint.MaxDurationDifference(); tolerance >= 0 {
+		query += ` AND (scenes_join.duration = 0 OR ABS(scenes_join.duration - ?) <= ?)`
+		args = append(args, *fingerprint.Duration, tolerance)
+	}
 	return qb.queryScenes(query, args)
 }
 
 func (qb *sceneQueryBuilder) FindByFingerprints(fingerprints []string) ([]*models.Scene, error) {
+	defer metrics.ObserveFingerprintLookup("fingerprints", len(fingerprints))()
+
 	query := `
 		SELECT scenes.* FROM scenes
 		WHERE id IN (
@@ -113,31 +300,55 @@ func (qb *sceneQueryBuilder) FindByFingerprints(fingerprints []string) ([]*model
 	return qb.queryScenes(query, args)
 }
 
+// fingerprintDurationCondition restricts matches to stored fingerprints
+// within the duration tolerance of the queried fingerprint. A negative
+// tolerance disables the check, and unknown stored durations always match.
+const fingerprintDurationCondition = `
+	(Q.tolerance < 0 OR F.duration = 0 OR ABS(F.duration - Q.duration) <= Q.tolerance)
+`
+
 func (qb *sceneQueryBuilder) FindByFullFingerprints(fingerprints []*models.FingerprintQueryInput) ([]*models.Scene, error) {
+	defer metrics.ObserveFingerprintLookup("full_fingerprints", len(fingerprints))()
+
 	hashClause := `
-		SELECT scene_id id FROM scene_fingerprints
-		WHERE hash IN (:hashes)
-		GROUP BY id
-	`
+		SELECT F.scene_id id
+		FROM UNNEST(ARRAY[:hashes]::::text[], ARRAY[:hash_durations]::::int[], ARRAY[:hash_tolerances]::::int[]) AS Q(hash, duration, tolerance)
+		JOIN scene_fingerprints F ON F.hash = Q.hash
+		AND ` + fingerprintDurationCondition
 	phashClause := `
-		SELECT scene_id as id
-		FROM UNNEST(ARRAY[:phashes]) phash
-		JOIN scene_fingerprints ON ('x' || hash)::::bit(64)::::bigint <@ (phash::::BIGINT, :distance)
-		AND algorithm = 'PHASH'
-	`
+		SELECT F.scene_id as id
+		FROM UNNEST(ARRAY[:phashes]::::bigint[], ARRAY[:phash_durations]::::int[], ARRAY[:phash_tolerances]::::int[]) AS Q(phash, duration, tolerance)
+		JOIN scene_fingerprints F ON ('x' || F.hash)::::bit(64)::::bigint <@ (Q.phash, :distance)
+		AND F.algorithm = 'PHASH'
+		AND ` + fingerprintDurationCondition
+
+	// the distance operator requires the bktree extension, so PHASH
+	// fingerprints are matched by hash when no distance is allowed
+	distance := config.GetPHashDistance()
 
 	var phashes []int64
+	var phashDurations, phashTolerances []int
 	var hashes []string
+	var hashDurations, hashTolerances []int
 	for _, fp := range fingerprints {
-		if fp.Algorithm == models.FingerprintAlgorithmPhash {
+		duration := 0
+		if fp.Duration != nil {
+			duration = *fp.Duration
+		}
+
+		if fp.Algorithm == models.FingerprintAlgorithmPhash && distance > 0 {
 			// Postgres only supports signed integers, so we parse
 			// as uint64 and cast to int64 to ensure values are the same.
 			value, err := strconv.ParseUint(fp.Hash, 16, 64)
 			if err == nil {
 				phashes = append(phashes, int64(value))
+				phashDurations = append(phashDurations, duration)
+				phashTolerances = append(phashTolerances, fp.MaxDurationDifference())
 			}
 		} else {
 			hashes = append(hashes, fp.Hash)
+			hashDurations = append(hashDurations, duration)
+			hashTolerances = append(hashTolerances, fp.MaxDurationDifference())
 		}
 	}
 
@@ -153,9 +364,13 @@ func (qb *sceneQueryBuilder) FindByFullFingerprints(fingerprints []*models.Finge
 	}
 
 	arg := map[string]interface{}{
-		"phashes":  phashes,
-		"hashes":   hashes,
-		"distance": config.GetPHashDistance(),
+		"phashes":          phashes,
+		"phash_durations":  phashDurations,
+		"phash_tolerances": phashTolerances,
+		"hashes":           hashes,
+		"hash_durations":   hashDurations,
+		"hash_tolerances":  hashTolerances,
+		"distance":         distance,
 	}
 
 	query := `
@@ -172,6 +387,65 @@ func (qb *sceneQueryBuilder) FindByFullFingerprints(fingerprints []*models.Finge
 	return qb.queryScenes(query, args)
 }
 
+// FindFingerprintMatches returns the stored fingerprints with the same hash
+// as one of the provided fingerprints, along with the PHASH fingerprints
+// within the provided distance of one of the provided PHASH fingerprints.
+func (qb *sceneQueryBuilder) FindFingerprintMatches(fingerprints []*models.FingerprintQueryInput, distance int) (models.SceneFingerprints, error) {
+	defer metrics.ObserveFingerprintLookup("fingerprint_matches", len(fingerprints))()
+
+	hashClause := `
+		SELECT scene_fingerprints.* FROM scene_fingerprints
+		WHERE hash IN (:hashes)
+	`
+	phashClause := `
+		SELECT scene_fingerprints.* FROM UNNEST(ARRAY[:phashes]) phash
+		JOIN scene_fingerprints ON ('x' || hash)::::bit(64)::::bigint <@ (phash::::BIGINT, :distance)
+		AND algorithm = 'PHASH'
+	`
+
+	var phashes []int64
+	var hashes []string
+	for _, fp := range fingerprints {
+		hashes = append(hashes, fp.Hash)
+		if fp.Algorithm == models.FingerprintAlgorithmPhash && distance > 0 {
+			// Postgres only supports signed integers, so we parse
+			// as uint64 and cast to int64 to ensure values are the same.
+			value, err := utils.ParsePHash(fp.Hash)
+			if err == nil {
+				phashes = append(phashes, int64(value))
+			}
+		}
+	}
+
+	if len(hashes) == 0 {
+		return nil, nil
+	}
+
+	clauses := []string{hashClause}
+	if len(phashes) > 0 {
+		clauses = append(clauses, phashClause)
+	}
+
+	arg := map[string]interface{}{
+		"phashes":  phashes,
+		"hashes":   hashes,
+		"distance": distance,
+	}
+
+	query, args, err := sqlx.Named(strings.Join(clauses, " UNION "), arg)
+	if err != nil {
+		return nil, err
+	}
+	query, args, err = sqlx.In(query, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	output := models.SceneFingerprints{}
+	err = qb.dbi.RawQuery(sceneFingerprintTable.table, query, args, &output)
+	return output, err
+}
+
 // func (qb *SceneQueryBuilder) FindByStudioID(sceneID int) ([]*models.Scene, error) {
 // 	query := `
 // 		SELECT scenes.* FROM scenes
@@ -403,6 +677,63 @@ func (qb *sceneQueryBuilder) GetAllFingerprints(ids []uuid.UUID) ([][]*models.Fi
 	return result, nil
 }
 
+// GetMedianDurations returns the median duration of the submitted
+// fingerprints of each scene. Fingerprints without recorded submissions
+// contribute their stored duration.
+func (qb *sceneQueryBuilder) GetMedianDurations(ids []uuid.UUID) ([]*int, []error) {
+	query := `
+		SELECT scene_id, percentile_disc(0.5) WITHIN GROUP (ORDER BY duration) AS duration
+		FROM (
+			SELECT S.scene_id, S.duration FROM scene_fingerprint_submissions S
+			WHERE S.scene_id IN (:ids) AND S.duration > 0
+			UNION ALL
+			SELECT F.scene_id, F.duration FROM scene_fingerprints F
+			WHERE F.scene_id IN (:ids) AND F.duration > 0
+			AND NOT EXISTS (
+				SELECT 1 FROM scene_fingerprint_submissions S
+				WHERE S.scene_id = F.scene_id AND S.hash = F.hash
+			)
+		) D
+		GROUP BY scene_id`
+	query, args, err := sqlx.Named(query, map[string]interface{}{"ids": ids})
+	if err != nil {
+		return nil, utils.DuplicateError(err, len(ids))
+	}
+	query, args, err = sqlx.In(query, args...)
+	if err != nil {
+		return nil, utils.DuplicateError(err, len(ids))
+	}
+
+	rows, err := qb.dbi.queryx(query, args...)
+	if err != nil {
+		return nil, utils.DuplicateError(err, len(ids))
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	m := make(map[uuid.UUID]*int)
+	for rows.Next() {
+		var median struct {
+			SceneID  uuid.UUID `db:"scene_id"`
+			Duration int       `db:"duration"`
+		}
+		if err := rows.StructScan(&median); err != nil {
+			return nil, utils.DuplicateError(err, len(ids))
+		}
+		m[median.SceneID] = &median.Duration
+	}
+	if err := rows.Err(); err != nil {
+		return nil, utils.DuplicateError(err, len(ids))
+	}
+
+	result := make([]*int, len(ids))
+	for i, id := range ids {
+		result[i] = m[id]
+	}
+	return result, nil
+}
+
 func (qb *sceneQueryBuilder) GetPerformers(id uuid.UUID) (models.PerformersScenes, error) {
 	joins := models.PerformersScenes{}
 	err := qb.dbi.FindJoins(scenePerformerTable, id, &joins)
@@ -480,3 +811,276 @@ func (qb *sceneQueryBuilder) CountByPerformer(id uuid.UUID) (int, error) {
 	args = append(args, id)
 	return runCountQuery(qb.dbi.db(), buildCountQuery("SELECT scene_id FROM scene_performers WHERE performer_id = ?"), args)
 }
+
+func (qb *sceneQueryBuilder) getTags(id uuid.UUID) (models.ScenesTags, error) {
+	joins := models.ScenesTags{}
+	err := qb.dbi.FindJoins(sceneTagTable, id, &joins)
+
+	return joins, err
+}
+
+func (qb *sceneQueryBuilder) getImages(id uuid.UUID) (models.ScenesImages, error) {
+	joins := models.ScenesImages{}
+	err := qb.dbi.FindJoins(sceneImageTable, id, &joins)
+
+	return joins, err
+}
+
+func (qb *sceneQueryBuilder) getSceneFingerprints(id uuid.UUID) (models.SceneFingerprints, error) {
+	joins := models.SceneFingerprints{}
+	err := qb.dbi.FindJoins(sceneFingerprintTable, id, &joins)
+
+	return joins, err
+}
+
+func (qb *sceneQueryBuilder) SoftDelete(scene models.Scene) (*models.Scene, error) {
+	// Delete joins
+	if err := qb.dbi.DeleteJoins(scenePerformerTable, scene.ID); err != nil {
+		return nil, err
+	}
+	if err := qb.dbi.DeleteJoins(sceneTagTable, scene.ID); err != nil {
+		return nil, err
+	}
+	if err := qb.dbi.DeleteJoins(sceneImageTable, scene.ID); err != nil {
+		return nil, err
+	}
+	if err := qb.dbi.DeleteJoins(sceneURLTable, scene.ID); err != nil {
+		return nil, err
+	}
+	if err := qb.dbi.DeleteJoins(sceneFingerprintTable, scene.ID); err != nil {
+		return nil, err
+	}
+	if err := qb.destroyRemovedFingerprintSubmissions(scene.ID); err != nil {
+		return nil, err
+	}
+
+	ret, err := qb.dbi.SoftDelete(sceneDBTable, scene)
+	return qb.toModel(ret), err
+}
+
+func (qb *sceneQueryBuilder) CreateRedirect(newJoin models.Redirect) error {
+	return qb.dbi.InsertJoin(sceneSourceRedirectTable, newJoin, nil)
+}
+
+func (qb *sceneQueryBuilder) UpdateRedirects(oldTargetID uuid.UUID, newTargetID uuid.UUID) error {
+	query := "UPDATE " + sceneSourceRedirectTable.table.Name() + " SET target_id = ? WHERE target_id = ?"
+	args := []interface{}{newTargetID, oldTargetID}
+	return qb.dbi.RawQuery(sceneSourceRedirectTable.table, query, args, nil)
+}
+
+func (qb *sceneQueryBuilder) updateSceneFingerprints(sourceID uuid.UUID, targetID uuid.UUID) error {
+	// Reassign fingerprints to the target scene where it doesn't already have them
+	query := `UPDATE scene_fingerprints
+					 SET scene_id = ?
+					 WHERE scene_id = ?
+					 AND hash NOT IN (SELECT hash FROM scene_fingerprints WHERE scene_id = ?)`
+	args := []interface{}{targetID, sourceID, targetID}
+	if err := qb.dbi.RawQuery(sceneFingerprintTable.table, query, args, nil); err != nil {
+		return err
+	}
+
+	// Reassign the user submissions of the fingerprints. Fingerprints the
+	// target already had remain on the source, and are counted for the users
+	// who had not submitted them to the target.
+	query = `WITH inserted AS (
+						 INSERT INTO scene_fingerprint_submissions (scene_id, hash, user_id, duration, created_at)
+						 SELECT ?, hash, user_id, duration, created_at FROM scene_fingerprint_submissions
+						 WHERE scene_id = ?
+						 ON CONFLICT ON CONSTRAINT scene_fingerprint_submissions_pkey DO NOTHING
+						 RETURNING scene_id, hash
+					 )
+					 UPDATE scene_fingerprints F
+					 SET submissions = F.submissions + I.count, updated_at = NOW()
+					 FROM (SELECT scene_id, hash, COUNT(*) AS count FROM inserted GROUP BY scene_id, hash) I
+					 WHERE F.scene_id = I.scene_id AND F.hash = I.hash
+					 AND F.hash IN (SELECT hash FROM scene_fingerprints WHERE scene_id = ?)`
+	args = []interface{}{targetID, sourceID, sourceID}
+	return qb.dbi.RawExec(query, args)
+}
+
+func (qb *sceneQueryBuilder) mergeInto(sourceID uuid.UUID, targetID uuid.UUID) error {
+	scene, err := qb.Find(sourceID)
+	if err != nil {
+		return err
+	}
+	if scene == nil {
+		return errors.New("Merge source scene not found: " + sourceID.String())
+	}
+	if scene.Deleted {
+		return errors.New("Merge source scene is deleted: " + sourceID.String())
+	}
+	if err := qb.updateSceneFingerprints(sourceID, targetID); err != nil {
+		return err
+	}
+	if _, err := qb.SoftDelete(*scene); err != nil {
+		return err
+	}
+	if err := qb.UpdateRedirects(sourceID, targetID); err != nil {
+		return err
+	}
+	redirect := models.Redirect{SourceID: sourceID, TargetID: targetID}
+	return qb.CreateRedirect(redirect)
+}
+
+func (qb *sceneQueryBuilder) ApplyEdit(edit models.Edit, operation models.OperationEnum, scene *models.Scene) (*models.Scene, error) {
+	data, err := edit.GetSceneData()
+	if err != nil {
+		return nil, err
+	}
+
+	switch operation {
+	case models.OperationEnumCreate:
+		now := time.Now()
+		UUID, err := uuid.NewV4()
+		if err != nil {
+			return nil, err
+		}
+		newScene := models.Scene{
+			ID:        UUID,
+			CreatedAt: models.SQLiteTimestamp{Timestamp: now},
+		}
+
+		newScene.CopyFromSceneEdit(*data.New, nil)
+
+		scene, err = qb.Create(newScene)
+		if err != nil {
+			return nil, err
+		}
+
+		if len(data.New.AddedUrls) > 0 {
+			urls := models.CreateSceneURLs(UUID, data.New.AddedUrls)
+			if err := qb.CreateURLs(urls); err != nil {
+				return nil, err
+			}
+		}
+
+		if len(data.New.AddedFingerprints) > 0 {
+			fingerprints := models.CreateSceneEditFingerprints(UUID, data.New.AddedFingerprints)
+			if err := qb.dbi.InsertJoins(sceneFingerprintTable, &fingerprints); err != nil {
+				return nil, err
+			}
+		}
+
+		if len(data.New.AddedPerformers) > 0 {
+			performers := models.CreateScenePerformers(UUID, data.New.AddedPerformers)
+			if err := qb.dbi.InsertJoins(scenePerformerTable, &performers); err != nil {
+				return nil, err
+			}
+		}
+
+		if len(data.New.AddedTags) > 0 {
+			tags := models.CreateSceneTags(UUID, data.New.AddedTags)
+			if err := qb.dbi.InsertJoins(sceneTagTable, &tags); err != nil {
+				return nil, err
+			}
+		}
+
+		if len(data.New.AddedImages) > 0 {
+			images := models.CreateSceneImages(UUID, data.New.AddedImages)
+			if err := qb.dbi.InsertJoins(sceneImageTable, &images); err != nil {
+				return nil, err
+			}
+		}
+
+		return scene, nil
+	case models.OperationEnumDestroy:
+		return qb.SoftDelete(*scene)
+	case models.OperationEnumModify:
+		return qb.applyModifyEdit(scene, data)
+	case models.OperationEnumMerge:
+		updatedScene, err := qb.applyModifyEdit(scene, data)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, v := range data.MergeSources {
+			sourceUUID, _ := uuid.FromString(v)
+			if err := qb.mergeInto(sourceUUID, scene.ID); err != nil {
+				return nil, err
+			}
+		}
+
+		return updatedScene, nil
+	default:
+		return nil, errors.New("Unsupported operation: " + operation.String())
+	}
+}
+
+func (qb *sceneQueryBuilder) applyModifyEdit(scene *models.Scene, data *models.SceneEditData) (*models.Scene, error) {
+	if err := scene.ValidateModifyEdit(*data); err != nil {
+		return nil, err
+	}
+
+	scene.CopyFromSceneEdit(*data.New, data.Old)
+	updatedScene, err := qb.Update(*scene)
+	if err != nil {
+		return nil, err
+	}
+
+	currentUrls, err := qb.GetURLs(updatedScene.ID)
+	if err != nil {
+		return nil, err
+	}
+	newUrls := models.CreateSceneURLs(updatedScene.ID, data.New.AddedUrls)
+	oldUrls := models.CreateSceneURLs(updatedScene.ID, data.New.RemovedUrls)
+	if err := models.ProcessSlice(&currentUrls, &newUrls, &oldUrls); err != nil {
+		return nil, err
+	}
+	if err := qb.UpdateURLs(updatedScene.ID, currentUrls); err != nil {
+		return nil, err
+	}
+
+	currentFingerprints, err := qb.getSceneFingerprints(updatedScene.ID)
+	if err != nil {
+		return nil, err
+	}
+	newFingerprints := models.CreateSceneEditFingerprints(updatedScene.ID, data.New.AddedFingerprints)
+	oldFingerprints := models.CreateSceneEditFingerprints(updatedScene.ID, data.New.RemovedFingerprints)
+	if err := models.ProcessSlice(&currentFingerprints, &newFingerprints, &oldFingerprints); err != nil {
+		return nil, err
+	}
+	if err := qb.UpdateFingerprints(updatedScene.ID, currentFingerprints); err != nil {
+		return nil, err
+	}
+
+	currentPerformers, err := qb.GetPerformers(updatedScene.ID)
+	if err != nil {
+		return nil, err
+	}
+	newPerformers := models.CreateScenePerformers(updatedScene.ID, data.New.AddedPerformers)
+	oldPerformers := models.CreateScenePerformers(updatedScene.ID, data.New.RemovedPerformers)
+	if err := models.ProcessSlice(&currentPerformers, &newPerformers, &oldPerformers); err != nil {
+		return nil, err
+	}
+	if err := qb.dbi.ReplaceJoins(scenePerformerTable, updatedScene.ID, &currentPerformers); err != nil {
+		return nil, err
+	}
+
+	currentTags, err := qb.getTags(updatedScene.ID)
+	if err != nil {
+		return nil, err
+	}
+	newTags := models.CreateSceneTags(updatedScene.ID, data.New.AddedTags)
+	oldTags := models.CreateSceneTags(updatedScene.ID, data.New.RemovedTags)
+	if err := models.ProcessSlice(&currentTags, &newTags, &oldTags); err != nil {
+		return nil, err
+	}
+	if err := qb.dbi.ReplaceJoins(sceneTagTable, updatedScene.ID, &currentTags); err != nil {
+		return nil, err
+	}
+
+	currentImages, err := qb.getImages(updatedScene.ID)
+	if err != nil {
+		return nil, err
+	}
+	newImages := models.CreateSceneImages(updatedScene.ID, data.New.AddedImages)
+	oldImages := models.CreateSceneImages(updatedScene.ID, data.New.RemovedImages)
+	if err := models.ProcessSlice(&currentImages, &newImages, &oldImages); err != nil {
+		return nil, err
+	}
+	if err := qb.dbi.ReplaceJoins(sceneImageTable, updatedScene.ID, &currentImages); err != nil {
+		return nil, err
+	}
+
+	return updatedScene, nil
+}
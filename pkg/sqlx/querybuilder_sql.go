@@ -32,6 +32,27 @@ func handleStringCriterion(column string, value *models.StringCriterionInput, qu
 	}
 }
 
+func handleIntCriterion(column string, value *models.IntCriterionInput, query *queryBuilder) {
+	if value != nil {
+		if modifier := value.Modifier.String(); value.Modifier.IsValid() {
+			switch modifier {
+			case "EQUALS":
+				query.AddWhere(column + " = ?")
+				query.AddArg(value.Value)
+			case "NOT_EQUALS":
+				query.AddWhere(column + " != ?")
+				query.AddArg(value.Value)
+			case "GREATER_THAN":
+				query.AddWhere(column + " > ?")
+				query.AddArg(value.Value)
+			case "LESS_THAN":
+				query.AddWhere(column + " < ?")
+				query.AddArg(value.Value)
+			}
+		}
+	}
+}
+
 func buildCountQuery(query string) string {
 	return "SELECT COUNT(*) as count FROM (" + query + ") as temp"
 }
@@ -1,8 +1,11 @@
 package sqlx
 
 import (
+	"time"
+
 	"github.com/gofrs/uuid"
 	"github.com/stashapp/stash-box/pkg/models"
+	"github.com/stashapp/stash-box/pkg/utils"
 )
 
 const (
@@ -18,6 +21,10 @@ var (
 	userRolesTable = newTableJoin(userTable, "user_roles", userJoinKey, func() interface{} {
 		return &models.UserRole{}
 	})
+
+	userEditCountTable = newTableJoin(userTable, "user_edit_counts", userJoinKey, func() interface{} {
+		return &models.UserEditCount{}
+	})
 )
 
 type userQueryBuilder struct {
@@ -113,6 +120,32 @@ func (qb *userQueryBuilder) Query(userFilter *models.UserFilterType, findFilter
 		query.AddArg(thisArgs...)
 	}
 
+	if q := userFilter.APICalls; q != nil {
+		handleIntCriterion("users.api_calls", q, query)
+	}
+
+	editCountCriteria := []struct {
+		column    string
+		criterion *models.IntCriterionInput
+	}{
+		{"successful_edits", userFilter.SuccessfulEdits},
+		{"unsuccessful_edits", userFilter.UnsuccessfulEdits},
+		{"successful_votes", userFilter.SuccessfulVotes},
+		{"unsuccessful_votes", userFilter.UnsuccessfulVotes},
+	}
+
+	// users without resolved edits or votes have no edit count row
+	joinEditCounts := isEditCountSort(findFilter.GetSort("name"))
+	for _, c := range editCountCriteria {
+		if c.criterion != nil {
+			joinEditCounts = true
+			handleIntCriterion(editCountColumn(c.column), c.criterion, query)
+		}
+	}
+	if joinEditCounts {
+		query.Body += "LEFT JOIN " + userEditCountTable.Name() + " ON " + userEditCountTable.Name() + ".user_id = users.id "
+	}
+
 	query.SortAndPagination = qb.getUserSort(findFilter) + getPagination(findFilter)
 	var studios models.Users
 	countResult, err := qb.dbi.Query(*query, &studios)
@@ -135,9 +168,29 @@ func (qb *userQueryBuilder) getUserSort(findFilter *models.QuerySpec) string {
 		sort = findFilter.GetSort("name")
 		direction = findFilter.GetDirection()
 	}
+
+	if isEditCountSort(sort) {
+		if direction != "ASC" && direction != "DESC" {
+			direction = "ASC"
+		}
+		return " ORDER BY " + editCountColumn(sort) + " " + direction + ", users.name ASC"
+	}
+
 	return getSort(qb.dbi.txn.dialect, sort, direction, "users", nil)
 }
 
+func isEditCountSort(sort string) bool {
+	switch sort {
+	case "successful_edits", "unsuccessful_edits", "successful_votes", "unsuccessful_votes":
+		return true
+	}
+	return false
+}
+
+func editCountColumn(column string) string {
+	return "COALESCE(" + getColumn(userEditCountTable.Name(), column) + ", 0)"
+}
+
 func (qb *userQueryBuilder) queryUsers(query string, args []interface{}) (models.Users, error) {
 	var output models.Users
 	err := qb.dbi.RawQuery(userDBTable, query, args, &output)
@@ -150,3 +203,57 @@ func (qb *userQueryBuilder) GetRoles(id uuid.UUID) (models.UserRoles, error) {
 
 	return joins, err
 }
+
+func (qb *userQueryBuilder) GetEditCounts(ids []uuid.UUID) ([]*models.UserEditCount, []error) {
+	joins := models.UserEditCounts{}
+	err := qb.dbi.FindAllJoins(userEditCountTable, ids, &joins)
+	if err != nil {
+		return nil, utils.DuplicateError(err, len(ids))
+	}
+
+	m := make(map[uuid.UUID]*models.UserEditCount)
+	for _, join := range joins {
+		m[join.UserID] = join
+	}
+
+	// users without resolved edits or votes have no counts stored
+	result := make([]*models.UserEditCount, len(ids))
+	for i, id := range ids {
+		result[i] = m[id]
+		if result[i] == nil {
+			result[i] = &models.UserEditCount{UserID: id}
+		}
+	}
+	return result, nil
+}
+
+// AddAPICalls adds the number of API calls made by the user to the count
+// for the provided period, and sets the time of the user's last API call.
+func (qb *userQueryBuilder) AddAPICalls(userID uuid.UUID, period time.Time, calls int, lastCall time.Time) error {
+	query := `
+INSERT INTO user_api_calls (user_id, period, calls) VALUES (?, ?, ?)
+ON CONFLICT ON CONSTRAINT user_api_calls_pkey
+DO UPDATE SET calls = user_api_calls.calls + EXCLUDED.calls`
+	if err := qb.dbi.RawExec(query, []interface{}{userID, period, calls}); err != nil {
+		return err
+	}
+
+	query = `UPDATE users SET last_api_call = ? WHERE id = ? AND last_api_call < ?`
+	return qb.dbi.RawExec(query, []interface{}{lastCall, userID, lastCall})
+}
+
+// UpdateAPICallCounts removes API call counts for periods before windowStart,
+// and sets the API call count of each user to the sum of the remaining counts.
+func (qb *userQueryBuilder) UpdateAPICallCounts(windowStart time.Time) error {
+	query := `DELETE FROM user_api_calls WHERE period < ?`
+	if err := qb.dbi.RawExec(query, []interface{}{windowStart}); err != nil {
+		return err
+	}
+
+	query = `
+UPDATE users SET api_calls = COALESCE((
+  SELECT SUM(calls) FROM user_api_calls WHERE user_api_calls.user_id = users.id
+), 0)
+WHERE api_calls != 0 OR id IN (SELECT user_id FROM user_api_calls)`
+	return qb.dbi.RawExec(query, nil)
+}
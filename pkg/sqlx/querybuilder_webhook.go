@@ -0,0 +1,113 @@
+package sqlx
+
+import (
+	"time"
+
+	"github.com/gofrs/uuid"
+
+	"github.com/stashapp/stash-box/pkg/models"
+)
+
+const (
+	webhookTable         = "webhooks"
+	webhookDeliveryTable = "webhook_deliveries"
+)
+
+var (
+	webhookDBTable = newTable(webhookTable, func() interface{} {
+		return &models.Webhook{}
+	})
+
+	webhookDeliveryDBTable = newTable(webhookDeliveryTable, func() interface{} {
+		return &models.WebhookDelivery{}
+	})
+)
+
+type webhookQueryBuilder struct {
+	dbi *dbi
+}
+
+func newWebhookQueryBuilder(txn *txnState) models.WebhookRepo {
+	return &webhookQueryBuilder{
+		dbi: newDBI(txn),
+	}
+}
+
+func (qb *webhookQueryBuilder) toModel(ro interface{}) *models.Webhook {
+	if ro != nil {
+		return ro.(*models.Webhook)
+	}
+
+	return nil
+}
+
+func (qb *webhookQueryBuilder) Create(newWebhook models.Webhook) (*models.Webhook, error) {
+	ret, err := qb.dbi.Insert(webhookDBTable, newWebhook)
+	return qb.toModel(ret), err
+}
+
+func (qb *webhookQueryBuilder) Update(updatedWebhook models.Webhook) (*models.Webhook, error) {
+	ret, err := qb.dbi.Update(webhookDBTable, updatedWebhook, false)
+	return qb.toModel(ret), err
+}
+
+func (qb *webhookQueryBuilder) Destroy(id uuid.UUID) error {
+	return qb.dbi.Delete(id, webhookDBTable)
+}
+
+func (qb *webhookQueryBuilder) Find(id uuid.UUID) (*models.Webhook, error) {
+	ret, err := qb.dbi.Find(id, webhookDBTable)
+	return qb.toModel(ret), err
+}
+
+func (qb *webhookQueryBuilder) queryWebhooks(query string, args []interface{}) (models.Webhooks, error) {
+	var output models.Webhooks
+	err := qb.dbi.RawQuery(webhookDBTable, query, args, &output)
+	return output, err
+}
+
+func (qb *webhookQueryBuilder) FindAll() ([]*models.Webhook, error) {
+	query := "SELECT * FROM " + webhookTable + " ORDER BY created_at"
+	return qb.queryWebhooks(query, nil)
+}
+
+func (qb *webhookQueryBuilder) FindByEvent(event models.WebhookEventEnum) ([]*models.Webhook, error) {
+	query := "SELECT * FROM " + webhookTable + " WHERE ? = ANY(events)"
+	return qb.queryWebhooks(query, []interface{}{event.String()})
+}
+
+func (qb *webhookQueryBuilder) CreateDelivery(newDelivery models.WebhookDelivery) error {
+	_, err := qb.dbi.Insert(webhookDeliveryDBTable, newDelivery)
+	return err
+}
+
+func (qb *webhookQueryBuilder) UpdateDelivery(updatedDelivery models.WebhookDelivery) error {
+	_, err := qb.dbi.Update(webhookDeliveryDBTable, updatedDelivery, true)
+	return err
+}
+
+func (qb *webhookQueryBuilder) ClaimDeliveries(limit int, lease time.Duration) ([]*models.WebhookDelivery, error) {
+	// skip deliveries locked by other instances claiming at the same time
+	query := `
+		UPDATE ` + webhookDeliveryTable + ` SET next_attempt_at = ?
+		WHERE id IN (
+			SELECT id FROM ` + webhookDeliveryTable + `
+			WHERE status = ? AND next_attempt_at <= ?
+			ORDER BY next_attempt_at
+			LIMIT ?
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING *
+	`
+	now := time.Now()
+	args := []interface{}{now.Add(lease), models.WebhookDeliveryStatusPending, now, limit}
+
+	var output models.WebhookDeliveries
+	err := qb.dbi.RawQuery(webhookDeliveryDBTable, query, args, &output)
+	return output, err
+}
+
+func (qb *webhookQueryBuilder) DestroyCompletedDeliveries(updatedBefore time.Time) error {
+	query := "DELETE FROM " + webhookDeliveryTable + " WHERE status <> ? AND updated_at < ?"
+	return qb.dbi.RawExec(query, []interface{}{models.WebhookDeliveryStatusPending, updatedBefore})
+}
@@ -7,8 +7,13 @@ import (
 	"strings"
 
 	"github.com/gofrs/uuid"
+	"github.com/jmoiron/sqlx"
 )
 
+// maxInsertParameters is the maximum number of bind parameters used by a
+// single multi-row insert statement.
+const maxInsertParameters = 65535
+
 type queryBuilder struct {
 	Table    table
 	Body     string
@@ -142,6 +147,68 @@ func insertObject(txn *txnState, t string, object interface{}, conflictHandling
 	return err
 }
 
+// insertObjects inserts the provided objects using multi-row insert
+// statements. Objects with differing non-empty fields are inserted by
+// separate statements.
+func insertObjects(txn *txnState, t string, objects []interface{}, conflictHandling *string) error {
+	ensureTx(txn)
+
+	conflictClause := ""
+	if conflictHandling != nil {
+		conflictClause = *conflictHandling
+	}
+
+	var fieldSets []string
+	values := make(map[string]string)
+	rows := make(map[string][]interface{})
+	for _, object := range objects {
+		fields, placeholders := sqlGenKeysCreate(txn.dialect, object)
+		if _, found := rows[fields]; !found {
+			fieldSets = append(fieldSets, fields)
+			values[fields] = placeholders
+		}
+		rows[fields] = append(rows[fields], object)
+	}
+
+	for _, fields := range fieldSets {
+		pending := rows[fields]
+		batchSize := maxInsertParameters / (strings.Count(fields, ",") + 1)
+		for len(pending) > 0 {
+			n := batchSize
+			if n > len(pending) {
+				n = len(pending)
+			}
+
+			if err := insertRows(txn, t, fields, values[fields], pending[:n], conflictClause); err != nil {
+				return err
+			}
+			pending = pending[n:]
+		}
+	}
+
+	return nil
+}
+
+func insertRows(txn *txnState, t string, fields string, values string, objects []interface{}, conflictClause string) error {
+	var bindings []string
+	var args []interface{}
+	for _, object := range objects {
+		binding, objectArgs, err := sqlx.Named(`(`+values+`)`, object)
+		if err != nil {
+			return err
+		}
+
+		bindings = append(bindings, binding)
+		args = append(args, objectArgs...)
+	}
+
+	query := txn.DB().Rebind(`INSERT INTO ` + t + ` (` + fields + `)
+				VALUES ` + strings.Join(bindings, ", ") + `
+                ` + conflictClause)
+	_, err := txn.DB().Exec(query, args...)
+	return err
+}
+
 func updateObjectByID(txn *txnState, t string, object interface{}, updateEmptyValues bool) error {
 	ensureTx(txn)
 	_, err := txn.DB().NamedExec(
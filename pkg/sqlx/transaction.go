@@ -2,8 +2,10 @@ package sqlx
 
 import (
 	"database/sql"
+	"time"
 
 	"github.com/jmoiron/sqlx"
+	"github.com/stashapp/stash-box/pkg/metrics"
 	"github.com/stashapp/stash-box/pkg/models"
 	"github.com/stashapp/stash-box/pkg/txn"
 )
@@ -25,6 +27,9 @@ type txnState struct {
 	dialect Dialect
 }
 
+// WithTxn runs fn in a transaction, which is committed if fn succeeds and
+// rolled back if it returns an error or panics. Calls inside an existing
+// transaction run in that transaction.
 func (m *txnState) WithTxn(fn func() error) (txErr error) {
 	if !m.InTxn() {
 		tx, err := m.rootDB.Beginx()
@@ -33,16 +38,21 @@ func (m *txnState) WithTxn(fn func() error) (txErr error) {
 		}
 
 		m.tx = tx
+		start := time.Now()
 
-		var txErr error
 		defer func() {
 			m.tx = nil
+			if p := recover(); p != nil {
+				_ = tx.Rollback()
+				panic(p)
+			}
 			if txErr != nil {
 				// ignore rollback errors
 				_ = tx.Rollback()
 			} else {
 				txErr = tx.Commit()
 			}
+			metrics.TransactionDuration.Observe(time.Since(start).Seconds())
 		}()
 
 		return fn()
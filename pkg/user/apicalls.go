@@ -0,0 +1,65 @@
+package user
+
+import (
+	"sync"
+	"time"
+
+	"github.com/gofrs/uuid"
+
+	"github.com/stashapp/stash-box/pkg/models"
+)
+
+type apiCallCount struct {
+	calls    int
+	lastCall time.Time
+}
+
+// APICallCounter aggregates API calls per user in memory, so that they can
+// be written to the database periodically rather than on every request.
+type APICallCounter struct {
+	mutex  sync.Mutex
+	counts map[uuid.UUID]*apiCallCount
+}
+
+func NewAPICallCounter() *APICallCounter {
+	return &APICallCounter{
+		counts: make(map[uuid.UUID]*apiCallCount),
+	}
+}
+
+// Increment records an API call by the user.
+func (c *APICallCounter) Increment(userID uuid.UUID) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	count, found := c.counts[userID]
+	if !found {
+		count = &apiCallCount{}
+		c.counts[userID] = count
+	}
+	count.calls++
+	count.lastCall = time.Now()
+}
+
+// Flush writes the aggregated API calls to the database and updates the API
+// call count of each user to the number of calls made within window.
+func (c *APICallCounter) Flush(fac models.Repo, window time.Duration) error {
+	c.mutex.Lock()
+	counts := c.counts
+	c.counts = make(map[uuid.UUID]*apiCallCount)
+	c.mutex.Unlock()
+
+	now := time.Now()
+	period := now.Truncate(time.Minute)
+
+	return fac.WithTxn(func() error {
+		qb := fac.User()
+		for userID, count := range counts {
+			if err := qb.AddAPICalls(userID, period, count.calls, count.lastCall); err != nil {
+				return err
+			}
+		}
+
+		return qb.UpdateAPICallCounts(now.Add(-window))
+	})
+}
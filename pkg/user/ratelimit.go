@@ -0,0 +1,96 @@
+package user
+
+import (
+	"math"
+	"sync"
+	"time"
+
+	"github.com/gofrs/uuid"
+
+	"github.com/stashapp/stash-box/pkg/manager/config"
+	"github.com/stashapp/stash-box/pkg/models"
+)
+
+// GetRateLimit returns the number of API calls per minute permitted for a
+// user with the provided roles. Roles without a configured limit are ignored,
+// and the most permissive configured limit applies. Zero means unlimited.
+func GetRateLimit(roles []models.RoleEnum) int {
+	ret := -1
+	for _, role := range roles {
+		limit, found := config.GetRateLimit(role.String())
+		if !found {
+			continue
+		}
+
+		if limit <= 0 {
+			return 0
+		}
+		if limit > ret {
+			ret = limit
+		}
+	}
+
+	if ret < 0 {
+		return 0
+	}
+	return ret
+}
+
+type rateLimitBucket struct {
+	tokens  float64
+	updated time.Time
+}
+
+// RateLimiter limits the rate of API calls per user using a token bucket,
+// which permits bursts of up to a minute's worth of calls.
+type RateLimiter struct {
+	mutex   sync.Mutex
+	buckets map[uuid.UUID]*rateLimitBucket
+}
+
+func NewRateLimiter() *RateLimiter {
+	return &RateLimiter{
+		buckets: make(map[uuid.UUID]*rateLimitBucket),
+	}
+}
+
+// Allow consumes a call from the allowance of the user, as determined by the
+// user's roles. If no calls are available, it returns false and the duration
+// until the next call is permitted.
+func (l *RateLimiter) Allow(userID uuid.UUID, roles []models.RoleEnum) (bool, time.Duration) {
+	return l.allow(userID, GetRateLimit(roles))
+}
+
+func (l *RateLimiter) allow(userID uuid.UUID, limit int) (bool, time.Duration) {
+	if limit <= 0 {
+		return true, 0
+	}
+
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	now := time.Now()
+	capacity := float64(limit)
+	perSecond := capacity / time.Minute.Seconds()
+
+	bucket, found := l.buckets[userID]
+	if !found {
+		bucket = &rateLimitBucket{
+			tokens:  capacity,
+			updated: now,
+		}
+		l.buckets[userID] = bucket
+	}
+
+	elapsed := now.Sub(bucket.updated).Seconds()
+	bucket.tokens = math.Min(capacity, bucket.tokens+elapsed*perSecond)
+	bucket.updated = now
+
+	if bucket.tokens >= 1 {
+		bucket.tokens--
+		return true, 0
+	}
+
+	wait := (1 - bucket.tokens) / perSecond
+	return false, time.Duration(wait * float64(time.Second))
+}
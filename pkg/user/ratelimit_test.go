@@ -0,0 +1,67 @@
+package user
+
+import (
+	"testing"
+
+	"github.com/gofrs/uuid"
+
+	"github.com/stashapp/stash-box/pkg/manager/config"
+	"github.com/stashapp/stash-box/pkg/models"
+)
+
+func TestGetRateLimit(t *testing.T) {
+	config.C.RateLimits = map[string]int{
+		"read":  60,
+		"edit":  120,
+		"admin": 0,
+	}
+	defer func() {
+		config.C.RateLimits = nil
+	}()
+
+	tests := []struct {
+		roles []models.RoleEnum
+		limit int
+	}{
+		{[]models.RoleEnum{models.RoleEnumRead}, 60},
+		{[]models.RoleEnum{models.RoleEnumRead, models.RoleEnumVote, models.RoleEnumEdit}, 120},
+		{[]models.RoleEnum{models.RoleEnumRead, models.RoleEnumAdmin}, 0},
+		{[]models.RoleEnum{models.RoleEnumVote}, 0},
+		{nil, 0},
+	}
+
+	for _, v := range tests {
+		if limit := GetRateLimit(v.roles); limit != v.limit {
+			t.Errorf("GetRateLimit(%v): got %d want %d", v.roles, limit, v.limit)
+		}
+	}
+}
+
+func TestRateLimiterAllow(t *testing.T) {
+	limiter := NewRateLimiter()
+	userID, _ := uuid.NewV4()
+	otherID, _ := uuid.NewV4()
+
+	const limit = 10
+	for i := 0; i < limit; i++ {
+		if allowed, _ := limiter.allow(userID, limit); !allowed {
+			t.Fatalf("call %d: expected call to be allowed", i+1)
+		}
+	}
+
+	allowed, wait := limiter.allow(userID, limit)
+	if allowed {
+		t.Error("expected call over the limit to be rejected")
+	}
+	if wait <= 0 {
+		t.Errorf("expected positive wait duration, got %v", wait)
+	}
+
+	if allowed, _ := limiter.allow(otherID, limit); !allowed {
+		t.Error("expected call by other user to be allowed")
+	}
+
+	if allowed, _ := limiter.allow(userID, 0); !allowed {
+		t.Error("expected call without limit to be allowed")
+	}
+}
@@ -0,0 +1,27 @@
+package utils
+
+import (
+	"math/bits"
+	"strconv"
+)
+
+// ParsePHash parses a hexadecimal perceptual hash.
+func ParsePHash(hash string) (uint64, error) {
+	return strconv.ParseUint(hash, 16, 64)
+}
+
+// PHashDistance returns the Hamming distance between two hexadecimal
+// perceptual hashes.
+func PHashDistance(a string, b string) (int, error) {
+	x, err := ParsePHash(a)
+	if err != nil {
+		return 0, err
+	}
+
+	y, err := ParsePHash(b)
+	if err != nil {
+		return 0, err
+	}
+
+	return bits.OnesCount64(x ^ y), nil
+}
@@ -0,0 +1,31 @@
+package utils
+
+import "testing"
+
+var phashDistanceTests = []struct {
+	a        string
+	b        string
+	expected int
+	err      bool
+}{
+	{"0000000000000000", "0000000000000000", 0, false},
+	{"0000000000000000", "0000000000000001", 1, false},
+	{"ffffffffffffffff", "0000000000000000", 64, false},
+	{"8f8f8f8f8f8f8f8f", "0f8f8f8f8f8f8f0f", 2, false},
+	{"zzzz", "0000000000000000", 0, true},
+	{"0000000000000000", "", 0, true},
+}
+
+func TestPHashDistance(t *testing.T) {
+	for _, test := range phashDistanceTests {
+		distance, err := PHashDistance(test.a, test.b)
+		if (err != nil) != test.err {
+			t.Errorf("PHashDistance(%s, %s): unexpected error %v", test.a, test.b, err)
+			continue
+		}
+
+		if distance != test.expected {
+			t.Errorf("PHashDistance(%s, %s): got %d want %d", test.a, test.b, distance, test.expected)
+		}
+	}
+}
@@ -0,0 +1,169 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"time"
+
+	"github.com/gofrs/uuid"
+
+	"github.com/stashapp/stash-box/pkg/logger"
+	"github.com/stashapp/stash-box/pkg/manager/config"
+	"github.com/stashapp/stash-box/pkg/models"
+)
+
+const (
+	// deliveryBatchSize is the maximum number of deliveries sent per run
+	deliveryBatchSize = 100
+	// maxRetryDelay caps the exponential backoff of failed deliveries
+	maxRetryDelay = 24 * time.Hour
+	// deliveryRetention is the time completed deliveries are kept
+	deliveryRetention = 7 * 24 * time.Hour
+)
+
+// Deliverer sends pending webhook deliveries, retrying failed deliveries
+// with exponential backoff.
+type Deliverer struct {
+	Client      *http.Client
+	RetryDelay  time.Duration
+	MaxAttempts int
+}
+
+// NewDeliverer returns a Deliverer using the webhook settings.
+func NewDeliverer() *Deliverer {
+	return &Deliverer{
+		Client: &http.Client{
+			Timeout: config.GetWebhookTimeout(),
+		},
+		RetryDelay:  config.GetWebhookRetryDelay(),
+		MaxAttempts: config.GetWebhookMaxAttempts(),
+	}
+}
+
+// Deliver sends the pending deliveries which are due, and removes old
+// completed deliveries. Deliveries are claimed before they are sent, so
+// that multiple instances do not send the same delivery. Deliveries
+// interrupted by the context being done are sent again once their claim
+// expires.
+func (d *Deliverer) Deliver(ctx context.Context, fac models.Repo) error {
+	// claimed deliveries are not retried until the requests have timed out
+	lease := d.Client.Timeout*deliveryBatchSize + time.Minute
+
+	var deliveries []*models.WebhookDelivery
+	err := fac.WithTxn(func() error {
+		var err error
+		deliveries, err = fac.Webhook().ClaimDeliveries(deliveryBatchSize, lease)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	webhooks := make(map[uuid.UUID]*models.Webhook)
+	for _, delivery := range deliveries {
+		webhook, found := webhooks[delivery.WebhookID]
+		if !found {
+			err := fac.WithTxn(func() error {
+				var err error
+				webhook, err = fac.Webhook().Find(delivery.WebhookID)
+				return err
+			})
+			if err != nil {
+				return err
+			}
+			webhooks[delivery.WebhookID] = webhook
+		}
+
+		// deliveries of destroyed webhooks are removed along with the webhook
+		if webhook == nil {
+			continue
+		}
+
+		err := d.send(ctx, webhook, delivery)
+		if ctxErr := ctx.Err(); err != nil && ctxErr != nil {
+			return ctxErr
+		}
+
+		d.record(delivery, err)
+		if err := fac.WithTxn(func() error {
+			return fac.Webhook().UpdateDelivery(*delivery)
+		}); err != nil {
+			return err
+		}
+	}
+
+	return fac.WithTxn(func() error {
+		return fac.Webhook().DestroyCompletedDeliveries(time.Now().Add(-deliveryRetention))
+	})
+}
+
+// record updates the delivery with the result of an attempt.
+func (d *Deliverer) record(delivery *models.WebhookDelivery, err error) {
+	now := time.Now()
+	delivery.Attempts++
+	delivery.UpdatedAt = models.SQLiteTimestamp{Timestamp: now}
+
+	if err == nil {
+		delivery.Status = models.WebhookDeliveryStatusDelivered
+		delivery.LastError = sql.NullString{}
+		return
+	}
+
+	delivery.LastError = sql.NullString{String: err.Error(), Valid: true}
+	if delivery.Attempts >= d.MaxAttempts {
+		delivery.Status = models.WebhookDeliveryStatusFailed
+		logger.Errorf("Webhook delivery %s failed after %d attempts: %s", delivery.ID, delivery.Attempts, err.Error())
+		return
+	}
+
+	delivery.NextAttemptAt = models.SQLiteTimestamp{Timestamp: now.Add(d.retryDelay(delivery.Attempts))}
+}
+
+// retryDelay returns the delay after the given number of failed attempts,
+// doubling with each attempt.
+func (d *Deliverer) retryDelay(attempts int) time.Duration {
+	delay := d.RetryDelay
+	for i := 1; i < attempts && delay < maxRetryDelay; i++ {
+		delay *= 2
+	}
+
+	if delay > maxRetryDelay {
+		return maxRetryDelay
+	}
+	return delay
+}
+
+// send posts the payload of the delivery to the webhook, returning an error
+// if the webhook does not respond with a 2xx status.
+func (d *Deliverer) send(ctx context.Context, webhook *models.Webhook, delivery *models.WebhookDelivery) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, webhook.URL, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "stash-box")
+	req.Header.Set(EventHeader, delivery.Event)
+	req.Header.Set(DeliveryHeader, delivery.ID.String())
+	req.Header.Set(SignatureHeader, Sign(webhook.Secret, delivery.Payload))
+
+	resp, err := d.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	// read the body so that the connection can be reused
+	_, _ = io.Copy(ioutil.Discard, io.LimitReader(resp.Body, 64*1024))
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("unexpected response: %s", resp.Status)
+	}
+
+	return nil
+}
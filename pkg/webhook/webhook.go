@@ -0,0 +1,148 @@
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"time"
+
+	"github.com/gofrs/uuid"
+
+	"github.com/stashapp/stash-box/pkg/models"
+	"github.com/stashapp/stash-box/pkg/utils"
+)
+
+const (
+	EventHeader     = "X-StashBox-Event"
+	DeliveryHeader  = "X-StashBox-Delivery"
+	SignatureHeader = "X-StashBox-Signature"
+)
+
+// Payload is the JSON body sent to webhooks.
+type Payload struct {
+	// ID identifies the event, and is the same for each webhook
+	ID        uuid.UUID               `json:"id"`
+	Event     models.WebhookEventEnum `json:"event"`
+	Timestamp time.Time               `json:"timestamp"`
+	Edit      EditPayload             `json:"edit"`
+}
+
+// EditPayload describes the edit an event relates to.
+type EditPayload struct {
+	ID         uuid.UUID `json:"id"`
+	TargetType string    `json:"target_type"`
+	// TargetID is nil for create edits which have not been applied
+	TargetID  *uuid.UUID `json:"target_id"`
+	Operation string     `json:"operation"`
+	Status    string     `json:"status"`
+	Applied   bool       `json:"applied"`
+	VoteCount int        `json:"vote_count"`
+}
+
+// Enqueue queues a delivery of the event to each webhook subscribed to it.
+// It should be called in the transaction which changed the edit, so that
+// deliveries are only sent for committed changes.
+func Enqueue(fac models.Repo, event models.WebhookEventEnum, edit *models.Edit) error {
+	return fac.WithTxn(func() error {
+		qb := fac.Webhook()
+		webhooks, err := qb.FindByEvent(event)
+		if err != nil || len(webhooks) == 0 {
+			return err
+		}
+
+		payload, err := newPayload(fac, event, edit)
+		if err != nil {
+			return err
+		}
+		data, err := json.Marshal(payload)
+		if err != nil {
+			return err
+		}
+
+		now := models.SQLiteTimestamp{Timestamp: time.Now()}
+		for _, webhook := range webhooks {
+			id, err := uuid.NewV4()
+			if err != nil {
+				return err
+			}
+
+			err = qb.CreateDelivery(models.WebhookDelivery{
+				ID:            id,
+				WebhookID:     webhook.ID,
+				Event:         event.String(),
+				Payload:       data,
+				Status:        models.WebhookDeliveryStatusPending,
+				NextAttemptAt: now,
+				CreatedAt:     now,
+				UpdatedAt:     now,
+			})
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+func newPayload(fac models.Repo, event models.WebhookEventEnum, edit *models.Edit) (*Payload, error) {
+	id, err := uuid.NewV4()
+	if err != nil {
+		return nil, err
+	}
+
+	targetID, err := findTargetID(fac, edit)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Payload{
+		ID:        id,
+		Event:     event,
+		Timestamp: time.Now(),
+		Edit: EditPayload{
+			ID:         edit.ID,
+			TargetType: edit.TargetType,
+			TargetID:   targetID,
+			Operation:  edit.Operation,
+			Status:     edit.Status,
+			Applied:    edit.Applied,
+			VoteCount:  edit.VoteCount,
+		},
+	}, nil
+}
+
+// findTargetID returns the ID of the object changed by the edit, or nil if
+// the edit creates an object and has not been applied.
+func findTargetID(fac models.Repo, edit *models.Edit) (*uuid.UUID, error) {
+	var operation models.OperationEnum
+	utils.ResolveEnumString(edit.Operation, &operation)
+	if operation == models.OperationEnumCreate && !edit.Applied {
+		return nil, nil
+	}
+
+	eqb := fac.Edit()
+	var targetType models.TargetTypeEnum
+	utils.ResolveEnumString(edit.TargetType, &targetType)
+	switch targetType {
+	case models.TargetTypeEnumTag:
+		return eqb.FindTagID(edit.ID)
+	case models.TargetTypeEnumPerformer:
+		return eqb.FindPerformerID(edit.ID)
+	case models.TargetTypeEnumStudio:
+		return eqb.FindStudioID(edit.ID)
+	case models.TargetTypeEnumScene:
+		return eqb.FindSceneID(edit.ID)
+	}
+
+	return nil, nil
+}
+
+// Sign returns the signature of the payload sent in the signature header,
+// which is the hex-encoded HMAC-SHA256 of the body, prefixed with sha256=.
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	_, _ = mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,330 @@
+package webhook
+
+import (
+	"context"
+	"errors"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gofrs/uuid"
+
+	"github.com/stashapp/stash-box/pkg/models"
+)
+
+type fakeRepo struct {
+	models.Repo
+	webhooks *fakeWebhookRepo
+	edits    *fakeEditRepo
+}
+
+func (r *fakeRepo) WithTxn(fn func() error) error {
+	return fn()
+}
+
+func (r *fakeRepo) Webhook() models.WebhookRepo {
+	return r.webhooks
+}
+
+func (r *fakeRepo) Edit() models.EditRepo {
+	return r.edits
+}
+
+type fakeEditRepo struct {
+	models.EditRepo
+	tagIDs map[uuid.UUID]uuid.UUID
+}
+
+func (r *fakeEditRepo) FindTagID(id uuid.UUID) (*uuid.UUID, error) {
+	tagID := r.tagIDs[id]
+	return &tagID, nil
+}
+
+type fakeWebhookRepo struct {
+	models.WebhookRepo
+	webhooks   []*models.Webhook
+	deliveries []*models.WebhookDelivery
+}
+
+func (r *fakeWebhookRepo) Find(id uuid.UUID) (*models.Webhook, error) {
+	for _, w := range r.webhooks {
+		if w.ID == id {
+			return w, nil
+		}
+	}
+	return nil, nil
+}
+
+func (r *fakeWebhookRepo) FindByEvent(event models.WebhookEventEnum) ([]*models.Webhook, error) {
+	var ret []*models.Webhook
+	for _, w := range r.webhooks {
+		for _, e := range w.Events {
+			if e == event.String() {
+				ret = append(ret, w)
+			}
+		}
+	}
+	return ret, nil
+}
+
+func (r *fakeWebhookRepo) CreateDelivery(newDelivery models.WebhookDelivery) error {
+	r.deliveries = append(r.deliveries, &newDelivery)
+	return nil
+}
+
+func (r *fakeWebhookRepo) UpdateDelivery(updatedDelivery models.WebhookDelivery) error {
+	for i, d := range r.deliveries {
+		if d.ID == updatedDelivery.ID {
+			r.deliveries[i] = &updatedDelivery
+		}
+	}
+	return nil
+}
+
+func (r *fakeWebhookRepo) ClaimDeliveries(limit int, lease time.Duration) ([]*models.WebhookDelivery, error) {
+	var ret []*models.WebhookDelivery
+	now := time.Now()
+	for _, d := range r.deliveries {
+		if d.Status == models.WebhookDeliveryStatusPending && !d.NextAttemptAt.Timestamp.After(now) && len(ret) < limit {
+			d.NextAttemptAt = models.SQLiteTimestamp{Timestamp: now.Add(lease)}
+			claimed := *d
+			ret = append(ret, &claimed)
+		}
+	}
+	return ret, nil
+}
+
+func (r *fakeWebhookRepo) DestroyCompletedDeliveries(updatedBefore time.Time) error {
+	return nil
+}
+
+func newTestWebhook(url string, events ...models.WebhookEventEnum) *models.Webhook {
+	w := &models.Webhook{
+		ID:     uuid.Must(uuid.NewV4()),
+		URL:    url,
+		Secret: "secret",
+	}
+	for _, e := range events {
+		w.Events = append(w.Events, e.String())
+	}
+	return w
+}
+
+func newTestEdit(operation models.OperationEnum) *models.Edit {
+	return &models.Edit{
+		ID:         uuid.Must(uuid.NewV4()),
+		TargetType: models.TargetTypeEnumTag.String(),
+		Operation:  operation.String(),
+		Status:     models.VoteStatusEnumPending.String(),
+	}
+}
+
+func TestEnqueue(t *testing.T) {
+	subscribed := newTestWebhook("http://localhost/subscribed", models.WebhookEventEnumEditCreated, models.WebhookEventEnumEditApplied)
+	other := newTestWebhook("http://localhost/other", models.WebhookEventEnumEditApplied)
+
+	edit := newTestEdit(models.OperationEnumModify)
+	tagID := uuid.Must(uuid.NewV4())
+	fac := &fakeRepo{
+		webhooks: &fakeWebhookRepo{webhooks: []*models.Webhook{subscribed, other}},
+		edits:    &fakeEditRepo{tagIDs: map[uuid.UUID]uuid.UUID{edit.ID: tagID}},
+	}
+
+	if err := Enqueue(fac, models.WebhookEventEnumEditCreated, edit); err != nil {
+		t.Fatal(err)
+	}
+
+	deliveries := fac.webhooks.deliveries
+	if len(deliveries) != 1 {
+		t.Fatalf("deliveries: got %d want 1", len(deliveries))
+	}
+	if deliveries[0].WebhookID != subscribed.ID {
+		t.Errorf("webhook: got %s want %s", deliveries[0].WebhookID, subscribed.ID)
+	}
+	if deliveries[0].Status != models.WebhookDeliveryStatusPending {
+		t.Errorf("status: got %s want %s", deliveries[0].Status, models.WebhookDeliveryStatusPending)
+	}
+
+	var payload Payload
+	if err := json.Unmarshal(deliveries[0].Payload, &payload); err != nil {
+		t.Fatal(err)
+	}
+	if payload.Event != models.WebhookEventEnumEditCreated {
+		t.Errorf("event: got %s want %s", payload.Event, models.WebhookEventEnumEditCreated)
+	}
+	if payload.Edit.ID != edit.ID || payload.Edit.Status != edit.Status || payload.Edit.TargetType != edit.TargetType {
+		t.Errorf("edit: got %+v", payload.Edit)
+	}
+	if payload.Edit.TargetID == nil || *payload.Edit.TargetID != tagID {
+		t.Errorf("target id: got %v want %s", payload.Edit.TargetID, tagID)
+	}
+
+	// unapplied create edits have no target
+	if err := Enqueue(fac, models.WebhookEventEnumEditCreated, newTestEdit(models.OperationEnumCreate)); err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal(fac.webhooks.deliveries[1].Payload, &payload); err != nil {
+		t.Fatal(err)
+	}
+	if payload.Edit.TargetID != nil {
+		t.Errorf("create target id: got %s want nil", payload.Edit.TargetID)
+	}
+}
+
+func TestDeliver(t *testing.T) {
+	type received struct {
+		header http.Header
+		body   []byte
+	}
+	var requests []received
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		requests = append(requests, received{header: r.Header, body: body})
+	}))
+	defer receiver.Close()
+
+	webhook := newTestWebhook(receiver.URL, models.WebhookEventEnumEditApplied)
+	fac := &fakeRepo{
+		webhooks: &fakeWebhookRepo{webhooks: []*models.Webhook{webhook}},
+	}
+
+	if err := Enqueue(fac, models.WebhookEventEnumEditApplied, newTestEdit(models.OperationEnumCreate)); err != nil {
+		t.Fatal(err)
+	}
+
+	d := &Deliverer{Client: receiver.Client(), RetryDelay: time.Minute, MaxAttempts: 3}
+	if err := d.Deliver(context.Background(), fac); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(requests) != 1 {
+		t.Fatalf("requests: got %d want 1", len(requests))
+	}
+	delivery := fac.webhooks.deliveries[0]
+	if got := requests[0].header.Get(SignatureHeader); got != Sign(webhook.Secret, requests[0].body) {
+		t.Errorf("signature: got %s want %s", got, Sign(webhook.Secret, requests[0].body))
+	}
+	if got := requests[0].header.Get(EventHeader); got != models.WebhookEventEnumEditApplied.String() {
+		t.Errorf("event header: got %s want %s", got, models.WebhookEventEnumEditApplied)
+	}
+	if got := requests[0].header.Get(DeliveryHeader); got != delivery.ID.String() {
+		t.Errorf("delivery header: got %s want %s", got, delivery.ID)
+	}
+	if string(requests[0].body) != string(delivery.Payload) {
+		t.Errorf("body: got %s want %s", requests[0].body, delivery.Payload)
+	}
+	if delivery.Status != models.WebhookDeliveryStatusDelivered || delivery.Attempts != 1 {
+		t.Errorf("delivery: got status %s after %d attempts", delivery.Status, delivery.Attempts)
+	}
+
+	// delivered payloads are not sent again
+	if err := d.Deliver(context.Background(), fac); err != nil {
+		t.Fatal(err)
+	}
+	if len(requests) != 1 {
+		t.Errorf("requests after delivery: got %d want 1", len(requests))
+	}
+}
+
+func TestDeliverCancelled(t *testing.T) {
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer receiver.Close()
+
+	fac := &fakeRepo{
+		webhooks: &fakeWebhookRepo{webhooks: []*models.Webhook{
+			newTestWebhook(receiver.URL, models.WebhookEventEnumEditApplied),
+		}},
+	}
+	if err := Enqueue(fac, models.WebhookEventEnumEditApplied, newTestEdit(models.OperationEnumCreate)); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	// interrupted deliveries are not counted as failed attempts
+	d := &Deliverer{Client: receiver.Client(), RetryDelay: time.Minute, MaxAttempts: 3}
+	if err := d.Deliver(ctx, fac); !errors.Is(err, context.Canceled) {
+		t.Errorf("deliver: got %v want %v", err, context.Canceled)
+	}
+
+	delivery := fac.webhooks.deliveries[0]
+	if delivery.Status != models.WebhookDeliveryStatusPending || delivery.Attempts != 0 {
+		t.Errorf("cancelled delivery: got status %s after %d attempts", delivery.Status, delivery.Attempts)
+	}
+}
+
+func TestDeliverRetry(t *testing.T) {
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer receiver.Close()
+
+	fac := &fakeRepo{
+		webhooks: &fakeWebhookRepo{webhooks: []*models.Webhook{
+			newTestWebhook(receiver.URL, models.WebhookEventEnumEditRejected),
+		}},
+	}
+	if err := Enqueue(fac, models.WebhookEventEnumEditRejected, newTestEdit(models.OperationEnumCreate)); err != nil {
+		t.Fatal(err)
+	}
+
+	d := &Deliverer{Client: receiver.Client(), RetryDelay: time.Minute, MaxAttempts: 2}
+	start := time.Now()
+	if err := d.Deliver(context.Background(), fac); err != nil {
+		t.Fatal(err)
+	}
+
+	delivery := fac.webhooks.deliveries[0]
+	if delivery.Status != models.WebhookDeliveryStatusPending || delivery.Attempts != 1 {
+		t.Fatalf("failed delivery: got status %s after %d attempts", delivery.Status, delivery.Attempts)
+	}
+	if !delivery.LastError.Valid {
+		t.Error("failed delivery: error not recorded")
+	}
+	if next := delivery.NextAttemptAt.Timestamp; next.Before(start.Add(time.Minute)) {
+		t.Errorf("next attempt: got %s want after %s", next, start.Add(time.Minute))
+	}
+
+	// retry immediately, abandoning the delivery after the maximum attempts
+	delivery.NextAttemptAt = models.SQLiteTimestamp{Timestamp: start}
+	if err := d.Deliver(context.Background(), fac); err != nil {
+		t.Fatal(err)
+	}
+
+	delivery = fac.webhooks.deliveries[0]
+	if delivery.Status != models.WebhookDeliveryStatusFailed || delivery.Attempts != 2 {
+		t.Errorf("abandoned delivery: got status %s after %d attempts", delivery.Status, delivery.Attempts)
+	}
+}
+
+func TestRetryDelay(t *testing.T) {
+	d := &Deliverer{RetryDelay: time.Minute}
+
+	tests := []struct {
+		attempts int
+		want     time.Duration
+	}{
+		{1, time.Minute},
+		{2, 2 * time.Minute},
+		{4, 8 * time.Minute},
+		{20, maxRetryDelay},
+	}
+
+	for _, tt := range tests {
+		if got := d.retryDelay(tt.attempts); got != tt.want {
+			t.Errorf("retryDelay(%d) = %s, want %s", tt.attempts, got, tt.want)
+		}
+	}
+}
+
+func TestSign(t *testing.T) {
+	// echo -n '{}' | openssl dgst -sha256 -hmac secret
+	const want = "sha256=77325902caca812dc259733aacd046b73817372c777b8d95b402647474516e13"
+	if got := Sign("secret", []byte("{}")); got != want {
+		t.Errorf("Sign: got %s want %s", got, want)
+	}
+}